@@ -0,0 +1,34 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/hekanemre/taxihub/domain"
+)
+
+type contextKey int
+
+const (
+	actorKey contextKey = iota
+	requestIDKey
+)
+
+// WithActor stores who is making the changes of a request, Authenticate sets the signed in user
+func WithActor(ctx context.Context, actor domain.AuditActor) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// WithRequestID stores the request id every record of the request carries
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+func actorFromContext(ctx context.Context) domain.AuditActor {
+	actor, _ := ctx.Value(actorKey).(domain.AuditActor)
+	return actor
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
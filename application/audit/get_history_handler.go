@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type GetHistoryHandler struct {
+	repo Repository
+}
+
+type GetHistoryRequest struct {
+	Resource   string `json:"-" validate:"required"` // set by the route
+	ResourceID string `params:"id" validate:"required"`
+	Cursor     string `query:"cursor"`
+	PageSize   int    `query:"pageSize" validate:"min=1,max=100"`
+}
+
+type GetHistoryResponse struct {
+	Records []*domain.AuditRecord `json:"records"`
+	Next    string                `json:"next,omitempty"` // cursor of the next page, empty on the last one
+}
+
+func NewGetHistoryHandler(repo Repository) *GetHistoryHandler {
+	return &GetHistoryHandler{
+		repo: repo,
+	}
+}
+
+// GetDriverHistory godoc
+// @Summary      Driver change history
+// @Description  Pages through every change made to a driver, newest first: who made it, when, in which request and the fields before and after. Deleted and purged drivers keep their history.
+// @Tags         drivers
+// @Produce      json
+// @Param        id        path      string  true   "Driver ID"
+// @Param        cursor    query     string  false  "next of the previous page"
+// @Param        pageSize  query     int     false  "Records per page" default(20)
+// @Success      200  {object}  GetHistoryResponse
+// @Failure 400 {object} ErrorResponse "Invalid cursor"
+// @Failure 422 {object} ErrorResponse "Invalid request"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /driver/{id}/history [get]
+func (h *GetHistoryHandler) Handle(ctx context.Context, req *GetHistoryRequest) (*GetHistoryResponse, error) {
+	ctx, span := tracing.Start(ctx, "GetHistoryHandler.Handle")
+	defer span.End()
+
+	query := HistoryQuery{
+		Resource:   req.Resource,
+		ResourceID: req.ResourceID,
+		// one more than asked tells whether there is a next page
+		Limit: req.PageSize + 1,
+	}
+	if req.Cursor != "" {
+		cursor, err := DecodeCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		query.Before = cursor
+	}
+
+	records, err := h.repo.GetAuditHistory(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &GetHistoryResponse{Records: records}
+	if len(records) > req.PageSize {
+		res.Records = records[:req.PageSize]
+		res.Next = CursorBefore(res.Records[req.PageSize-1]).Encode()
+	}
+	if res.Records == nil {
+		res.Records = []*domain.AuditRecord{}
+	}
+	return res, nil
+}
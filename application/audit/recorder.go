@@ -0,0 +1,52 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/log"
+	"go.uber.org/zap"
+)
+
+// how long writing one record may take once the request that made the change is gone
+const recordTimeout = 3 * time.Second
+
+// Recorder writes audit records, taking actor and request id from the context
+type Recorder struct {
+	repo Repository
+}
+
+func NewRecorder(repo Repository) *Recorder {
+	return &Recorder{
+		repo: repo,
+	}
+}
+
+// Record appends a record of a change that already happened. It cannot be undone when the record
+// fails to write, so the failure is logged and the request goes on.
+func (r *Recorder) Record(ctx context.Context, resource, resourceID string, action domain.AuditAction, changes []domain.FieldChange) {
+	record := &domain.AuditRecord{
+		ID:         uuid.NewString(),
+		Resource:   resource,
+		ResourceID: resourceID,
+		Action:     action,
+		Actor:      actorFromContext(ctx),
+		RequestID:  requestIDFromContext(ctx),
+		Changes:    changes,
+		Timestamp:  time.Now(),
+	}
+
+	// the record is written even when the request was cancelled right after the change
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+	defer cancel()
+	if err := r.repo.AppendAuditRecord(ctx, record); err != nil {
+		log.FromContext(ctx).Error("Failed to write audit record",
+			zap.Error(err),
+			zap.String("resource", resource),
+			zap.String("resource_id", resourceID),
+			zap.String("action", string(action)),
+		)
+	}
+}
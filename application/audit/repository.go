@@ -0,0 +1,57 @@
+package audit
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+)
+
+var ErrInvalidCursor = domain.NewValidationError("INVALID_CURSOR", "cursor is invalid")
+
+// append only, records are never updated or removed
+type Repository interface {
+	AppendAuditRecord(ctx context.Context, record *domain.AuditRecord) error
+	// GetAuditHistory returns up to query.Limit records of one resource, newest first, before the cursor
+	GetAuditHistory(ctx context.Context, query HistoryQuery) ([]*domain.AuditRecord, error)
+}
+
+type HistoryQuery struct {
+	Resource   string
+	ResourceID string
+	Before     *HistoryCursor // nil starts with the newest record
+	Limit      int
+}
+
+// HistoryCursor is the last record of a page, the timestamp with the id breaking ties.
+// Clients get it base64 encoded and must treat it as opaque.
+type HistoryCursor struct {
+	Timestamp time.Time `json:"t"`
+	ID        string    `json:"i"`
+}
+
+func CursorBefore(record *domain.AuditRecord) *HistoryCursor {
+	return &HistoryCursor{Timestamp: record.Timestamp, ID: record.ID}
+}
+
+func (c *HistoryCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func DecodeCursor(s string) (*HistoryCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor.Wrap(err)
+	}
+	var cursor HistoryCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor.Wrap(err)
+	}
+	if cursor.ID == "" || cursor.Timestamp.IsZero() {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
@@ -0,0 +1,130 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+)
+
+// AuditRecorder writes an audit record of a change that already happened,
+// the actor and request id come from the context
+type AuditRecorder interface {
+	Record(ctx context.Context, resource, resourceID string, action domain.AuditAction, changes []domain.FieldChange)
+}
+
+// fields that are the same on every create or change on every write, they only add noise to a diff
+var unauditedDriverFields = []string{"id", "createdAt", "updatedAt", "version"}
+
+// AuditingRepository decorates a Repository and records every write to a driver but location pings,
+// which are too many to keep and are only ever the latest position. Recording is best effort,
+// see audit.Recorder.
+type AuditingRepository struct {
+	Repository
+	recorder AuditRecorder
+}
+
+func NewAuditingRepository(repo Repository, recorder AuditRecorder) *AuditingRepository {
+	return &AuditingRepository{
+		Repository: repo,
+		recorder:   recorder,
+	}
+}
+
+func (r *AuditingRepository) CreateDriver(ctx context.Context, driver *domain.Driver) error {
+	if err := r.Repository.CreateDriver(ctx, driver); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, domain.AuditResourceDriver, driver.ID, domain.AuditCreated,
+		domain.DiffFields(nil, driver, unauditedDriverFields...))
+	return nil
+}
+
+// UpdateDriver reads the stored driver first for the diff. The version check of the update makes
+// sure it is exactly the driver that was overwritten.
+func (r *AuditingRepository) UpdateDriver(ctx context.Context, driver *domain.Driver) error {
+	before, err := r.Repository.GetDriverByID(ctx, driver.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := r.Repository.UpdateDriver(ctx, driver); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, domain.AuditResourceDriver, driver.ID, domain.AuditUpdated,
+		domain.DiffFields(before, driver, unauditedDriverFields...))
+	return nil
+}
+
+func (r *AuditingRepository) UpdateDriverStatus(ctx context.Context, id string, from, to domain.DriverStatus) error {
+	if err := r.Repository.UpdateDriverStatus(ctx, id, from, to); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, domain.AuditResourceDriver, id, domain.AuditStatusChanged, []domain.FieldChange{
+		{Field: "status", Before: from, After: to},
+	})
+	return nil
+}
+
+// SetCurrentShift records the shift link, the shift itself lives in its own collection
+func (r *AuditingRepository) SetCurrentShift(ctx context.Context, id, from, to string) error {
+	if err := r.Repository.SetCurrentShift(ctx, id, from, to); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, domain.AuditResourceDriver, id, domain.AuditShiftChanged, []domain.FieldChange{
+		{Field: "currentShiftId", Before: shiftLink(from), After: shiftLink(to)},
+	})
+	return nil
+}
+
+// shiftLink leaves a missing shift out of the change, like it is left out of the driver
+func shiftLink(id string) any {
+	if id == "" {
+		return nil
+	}
+	return id
+}
+
+func (r *AuditingRepository) DeleteDriver(ctx context.Context, id, reason string) error {
+	if err := r.Repository.DeleteDriver(ctx, id, reason); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, domain.AuditResourceDriver, id, domain.AuditDeleted, []domain.FieldChange{
+		{Field: "deletionReason", After: reason},
+	})
+	return nil
+}
+
+func (r *AuditingRepository) DeactivateDriver(ctx context.Context, id, reason string) error {
+	if err := r.Repository.DeactivateDriver(ctx, id, reason); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, domain.AuditResourceDriver, id, domain.AuditDeactivated, []domain.FieldChange{
+		{Field: "deletionReason", After: reason},
+		{Field: "deactivated", After: true},
+	})
+	return nil
+}
+
+func (r *AuditingRepository) RestoreDriver(ctx context.Context, id string) error {
+	if err := r.Repository.RestoreDriver(ctx, id); err != nil {
+		return err
+	}
+
+	r.recorder.Record(ctx, domain.AuditResourceDriver, id, domain.AuditRestored, nil)
+	return nil
+}
+
+func (r *AuditingRepository) PurgeDeletedDrivers(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	purged, err := r.Repository.PurgeDeletedDrivers(ctx, deletedBefore)
+	for _, id := range purged {
+		r.recorder.Record(ctx, domain.AuditResourceDriver, id, domain.AuditPurged, nil)
+	}
+	return purged, err
+}
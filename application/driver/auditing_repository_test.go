@@ -0,0 +1,56 @@
+package application_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	application "github.com/hekanemre/taxihub/application/driver"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/infrastructure"
+)
+
+type auditEntry struct {
+	resourceID string
+	action     domain.AuditAction
+	changes    []domain.FieldChange
+}
+
+// recordingAuditor keeps every recorded change in order
+type recordingAuditor struct {
+	entries []auditEntry
+}
+
+func (a *recordingAuditor) Record(ctx context.Context, resource, resourceID string, action domain.AuditAction, changes []domain.FieldChange) {
+	a.entries = append(a.entries, auditEntry{resourceID: resourceID, action: action, changes: changes})
+}
+
+func TestAuditingRepository_RecordsStatusAndShiftChanges(t *testing.T) {
+	ctx := context.Background()
+	memory := infrastructure.NewMemoryRepository(1000)
+	if err := memory.CreateDriver(ctx, &domain.Driver{ID: "d1", Plate: "34AB123", TaxiType: "yellow", Status: domain.DriverOffline, Version: 1}); err != nil {
+		t.Fatalf("CreateDriver: %v", err)
+	}
+
+	auditor := &recordingAuditor{}
+	repo := application.NewAuditingRepository(memory, auditor)
+
+	if err := repo.SetCurrentShift(ctx, "d1", "", "s1"); err != nil {
+		t.Fatalf("SetCurrentShift: %v", err)
+	}
+	if err := repo.UpdateDriverStatus(ctx, "d1", domain.DriverOffline, domain.DriverOnline); err != nil {
+		t.Fatalf("UpdateDriverStatus: %v", err)
+	}
+	// a status change that did not happen is not recorded
+	if err := repo.UpdateDriverStatus(ctx, "d1", domain.DriverOffline, domain.DriverOnline); err == nil {
+		t.Fatal("UpdateDriverStatus from a stale status succeeded")
+	}
+
+	want := []auditEntry{
+		{resourceID: "d1", action: domain.AuditShiftChanged, changes: []domain.FieldChange{{Field: "currentShiftId", After: "s1"}}},
+		{resourceID: "d1", action: domain.AuditStatusChanged, changes: []domain.FieldChange{{Field: "status", Before: domain.DriverOffline, After: domain.DriverOnline}}},
+	}
+	if !reflect.DeepEqual(auditor.entries, want) {
+		t.Fatalf("recorded %+v, want %+v", auditor.entries, want)
+	}
+}
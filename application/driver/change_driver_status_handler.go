@@ -0,0 +1,101 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type ChangeDriverStatusHandler struct {
+	repo   Repository
+	shifts ShiftRepository
+}
+
+type ChangeDriverStatusRequest struct {
+	DriverID string              `json:"-" validate:"required"`
+	Status   domain.DriverStatus `json:"-" validate:"oneof=ONLINE OFFLINE ON_BREAK"` // ONLINE, OFFLINE or ON_BREAK, ON_TRIP is set by rides only
+}
+
+type ChangeDriverStatusResponse struct {
+	Driver *domain.Driver `json:"driver"`
+}
+
+func NewChangeDriverStatusHandler(repo Repository, shifts ShiftRepository) *ChangeDriverStatusHandler {
+	return &ChangeDriverStatusHandler{
+		repo:   repo,
+		shifts: shifts,
+	}
+}
+
+// ChangeDriverStatus godoc
+// @Summary      Go online, offline or on a break
+// @Description  Changes the availability of a driver during an open shift.
+// @Tags         drivers
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Driver ID"
+// @Success      200  {object}  ChangeDriverStatusResponse
+// @Failure 404 {object} ErrorResponse "Driver not found"
+// @Failure 409 {object} ErrorResponse "Invalid status transition, no open shift or driver on a trip"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /driver/{id}/online [post]
+// @Router       /driver/{id}/offline [post]
+// @Router       /driver/{id}/break [post]
+func (h *ChangeDriverStatusHandler) Handle(ctx context.Context, req *ChangeDriverStatusRequest) (*ChangeDriverStatusResponse, error) {
+	ctx, span := tracing.Start(ctx, "ChangeDriverStatusHandler.Handle")
+	defer span.End()
+
+	if req.Status == domain.DriverOnTrip {
+		return nil, domain.ErrInvalidDriverTransition
+	}
+
+	driver, err := h.repo.GetDriverByID(ctx, req.DriverID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !driver.OnShift() {
+		return nil, domain.ErrNoOpenShift
+	}
+
+	// only the ride flow takes a driver off a trip, going online here would bring new offers mid ride
+	current := driver.CurrentStatus()
+	if current == domain.DriverOnTrip {
+		return nil, domain.ErrDriverOnTrip
+	}
+	if !domain.CanTransitionDriver(current, req.Status) {
+		return nil, domain.ErrInvalidDriverTransition
+	}
+
+	if err := h.repo.UpdateDriverStatus(ctx, driver.ID, current, req.Status); err != nil {
+		return nil, err
+	}
+	driver.Status = req.Status
+
+	// breaks are kept on the shift so they can be left out of hours worked
+	if current == domain.DriverOnBreak || req.Status == domain.DriverOnBreak {
+		shift, err := h.shifts.GetShiftByID(ctx, driver.CurrentShiftID)
+		if err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		if req.Status == domain.DriverOnBreak {
+			if err := shift.StartBreak(now); err != nil {
+				return nil, err
+			}
+		} else {
+			shift.EndBreak(now)
+		}
+
+		if err := h.shifts.UpdateShift(ctx, shift); err != nil {
+			return nil, err
+		}
+	}
+
+	return &ChangeDriverStatusResponse{
+		Driver: driver,
+	}, nil
+}
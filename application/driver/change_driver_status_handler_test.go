@@ -0,0 +1,36 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	application "github.com/hekanemre/taxihub/application/driver"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/infrastructure"
+)
+
+func TestChangeDriverStatusHandler_RejectsDriverOnTrip(t *testing.T) {
+	ctx := context.Background()
+	repo := infrastructure.NewMemoryRepository(1000)
+	driver := &domain.Driver{ID: "d1", Plate: "34AB123", TaxiType: "yellow", Status: domain.DriverOnTrip, CurrentShiftID: "s1", Version: 1}
+	if err := repo.CreateDriver(ctx, driver); err != nil {
+		t.Fatalf("CreateDriver: %v", err)
+	}
+
+	handler := application.NewChangeDriverStatusHandler(repo, repo)
+	for _, status := range []domain.DriverStatus{domain.DriverOnline, domain.DriverOffline, domain.DriverOnBreak} {
+		_, err := handler.Handle(ctx, &application.ChangeDriverStatusRequest{DriverID: "d1", Status: status})
+		if !errors.Is(err, domain.ErrDriverOnTrip) {
+			t.Fatalf("Handle(%s) = %v, want ErrDriverOnTrip", status, err)
+		}
+	}
+
+	stored, err := repo.GetDriverByID(ctx, "d1")
+	if err != nil {
+		t.Fatalf("GetDriverByID: %v", err)
+	}
+	if stored.CurrentStatus() != domain.DriverOnTrip {
+		t.Fatalf("status = %s, want %s", stored.CurrentStatus(), domain.DriverOnTrip)
+	}
+}
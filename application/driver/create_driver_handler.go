@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
 )
 
 type CreateDriverHandler struct {
@@ -13,12 +14,13 @@ type CreateDriverHandler struct {
 }
 
 type CreateDriverRequest struct {
-	FirstName string          `bson:"firstName" json:"firstName"`
-	LastName  string          `bson:"lastName" json:"lastName"`
-	Plate     string          `bson:"plate" json:"plate"`
-	TaxiType  string          `bson:"taxiType" json:"taksiType"`
-	CarBrand  string          `bson:"carBrand" json:"carBrand"`
-	CarModel  string          `bson:"carModel" json:"carModel"`
+	UserID    string          `bson:"userId" json:"userId"` // account the driver logs in with
+	FirstName string          `bson:"firstName" json:"firstName" validate:"required,max=100"`
+	LastName  string          `bson:"lastName" json:"lastName" validate:"required,max=100"`
+	Plate     string          `bson:"plate" json:"plate" validate:"required,max=20"`
+	TaxiType  string          `bson:"taxiType" json:"taksiType" validate:"required,taxitype"`
+	CarBrand  string          `bson:"carBrand" json:"carBrand" validate:"max=50"`
+	CarModel  string          `bson:"carModel" json:"carModel" validate:"max=50"`
 	Location  domain.Location `bson:"location" json:"location"`
 	CreatedAt time.Time       `bson:"createdAt" json:"createdAt"`
 	UpdatedAt time.Time       `bson:"updatedAt" json:"updatedAt"`
@@ -44,11 +46,15 @@ func NewCreateDriverHandler(repo Repository) *CreateDriverHandler {
 // @Param        driver  body      CreateDriverRequest  true  "Driver creation data"
 // @Success      200  {object}  CreateDriverResponse
 // @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 409 {object} ErrorResponse "A driver with the same plate exists"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router       /drivers/create [post]
 func (h *CreateDriverHandler) Handle(ctx context.Context, req *CreateDriverRequest) (*CreateDriverResponse, error) {
+	ctx, span := tracing.Start(ctx, "CreateDriverHandler.Handle")
+	defer span.End()
 
 	driver := &domain.Driver{
+		UserID:    req.UserID,
 		FirstName: req.FirstName,
 		LastName:  req.LastName,
 		Plate:     req.Plate,
@@ -56,6 +62,8 @@ func (h *CreateDriverHandler) Handle(ctx context.Context, req *CreateDriverReque
 		CarBrand:  req.CarBrand,
 		CarModel:  req.CarModel,
 		Location:  req.Location,
+		Status:    domain.DriverOffline,
+		Version:   1,
 		CreatedAt: req.CreatedAt,
 		UpdatedAt: req.UpdatedAt,
 	}
@@ -0,0 +1,79 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type DeleteDriverHandler struct {
+	repo Repository
+}
+
+type DeleteDriverRequest struct {
+	DriverID   string `json:"-" validate:"required"`
+	Reason     string `json:"reason" validate:"required,max=500"`
+	Deactivate bool   `json:"-"` // set by the route, deactivated drivers are never purged
+}
+
+type DeleteDriverResponse struct {
+	Driver *domain.Driver `json:"driver"`
+}
+
+func NewDeleteDriverHandler(repo Repository) *DeleteDriverHandler {
+	return &DeleteDriverHandler{
+		repo: repo,
+	}
+}
+
+// DeleteDriver godoc
+// @Summary      Delete or deactivate a driver
+// @Description  Soft deletes a driver, it disappears from every listing and search right away. Deleted drivers are purged after the retention period, deactivated ones are kept until restored. The driver must end the open shift first.
+// @Tags         drivers
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string               true  "Driver ID"
+// @Param        request  body      DeleteDriverRequest  true  "Why the driver is removed"
+// @Success      200  {object}  DeleteDriverResponse
+// @Failure 404 {object} ErrorResponse "Driver not found"
+// @Failure 409 {object} ErrorResponse "Driver has an open shift"
+// @Failure 422 {object} ErrorResponse "Invalid request"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /driver/{id} [delete]
+// @Router       /driver/{id}/deactivate [post]
+func (h *DeleteDriverHandler) Handle(ctx context.Context, req *DeleteDriverRequest) (*DeleteDriverResponse, error) {
+	ctx, span := tracing.Start(ctx, "DeleteDriverHandler.Handle")
+	defer span.End()
+
+	driver, err := h.repo.GetDriverByID(ctx, req.DriverID)
+	if err != nil {
+		return nil, err
+	}
+
+	// a driver in the middle of a shift may be on a trip, the shift is ended first
+	if driver.OnShift() {
+		return nil, domain.ErrDriverOnShift
+	}
+
+	if req.Deactivate {
+		err = h.repo.DeactivateDriver(ctx, driver.ID, req.Reason)
+	} else {
+		err = h.repo.DeleteDriver(ctx, driver.ID, req.Reason)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	driver.DeletedAt = &now
+	driver.DeletionReason = req.Reason
+	driver.Deactivated = req.Deactivate
+	driver.UpdatedAt = now
+	driver.Version++
+
+	return &DeleteDriverResponse{
+		Driver: driver,
+	}, nil
+}
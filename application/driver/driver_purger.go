@@ -0,0 +1,49 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DriverPurger hard deletes drivers that were soft deleted longer than the retention period ago.
+// Their shifts and rides are kept, they still count in reports.
+type DriverPurger struct {
+	repo      Repository
+	retention time.Duration
+}
+
+func NewDriverPurger(repo Repository, retention time.Duration) *DriverPurger {
+	return &DriverPurger{
+		repo:      repo,
+		retention: retention,
+	}
+}
+
+// Purge removes every driver deleted before now minus the retention period
+func (p *DriverPurger) Purge(ctx context.Context) ([]string, error) {
+	return p.repo.PurgeDeletedDrivers(ctx, time.Now().Add(-p.retention))
+}
+
+// Run purges every interval until ctx is cancelled
+func (p *DriverPurger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := p.Purge(ctx)
+			if err != nil {
+				zap.L().Error("Failed to purge deleted drivers", zap.Error(err))
+				continue
+			}
+			if len(purged) > 0 {
+				zap.L().Info("Purged deleted drivers", zap.Int("count", len(purged)))
+			}
+		}
+	}
+}
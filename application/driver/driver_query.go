@@ -0,0 +1,84 @@
+package application
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+)
+
+const (
+	SortByName      = "name"
+	SortByCreatedAt = "createdAt"
+	SortByUpdatedAt = "updatedAt"
+)
+
+var ErrInvalidCursor = domain.NewValidationError("INVALID_CURSOR", "cursor is invalid or was issued for another sort order")
+
+// DriverFilter narrows a driver listing, zero fields match everything.
+// CreatedFrom is inclusive, CreatedTo exclusive.
+type DriverFilter struct {
+	TaxiType    string
+	CarBrand    string
+	Status      domain.DriverStatus
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+}
+
+// DriverQuery is one page of a driver listing. Drivers are ordered by SortBy with the id
+// breaking ties, so a page continues exactly after the After cursor even while drivers are inserted.
+type DriverQuery struct {
+	Filter DriverFilter
+	SortBy string
+	Desc   bool
+	After  *DriverCursor // nil starts at the first page
+	Limit  int
+}
+
+// DriverCursor is the sort key of the last driver of a page.
+// Clients get it base64 encoded and must treat it as opaque.
+type DriverCursor struct {
+	SortBy    string     `json:"s"`
+	Desc      bool       `json:"d,omitempty"`
+	FirstName string     `json:"f,omitempty"`
+	LastName  string     `json:"l,omitempty"`
+	Time      *time.Time `json:"t,omitempty"`
+	ID        string     `json:"i"`
+}
+
+// CursorAfter returns the cursor a page ending with driver continues from
+func CursorAfter(driver *domain.Driver, sortBy string, desc bool) *DriverCursor {
+	cursor := &DriverCursor{SortBy: sortBy, Desc: desc, ID: driver.ID}
+	switch sortBy {
+	case SortByName:
+		cursor.FirstName = driver.FirstName
+		cursor.LastName = driver.LastName
+	case SortByCreatedAt:
+		cursor.Time = &driver.CreatedAt
+	case SortByUpdatedAt:
+		cursor.Time = &driver.UpdatedAt
+	}
+	return cursor
+}
+
+func (c *DriverCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor reads a cursor back, it must have been issued for the same sort order
+func DecodeCursor(s, sortBy string, desc bool) (*DriverCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor.Wrap(err)
+	}
+	var cursor DriverCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor.Wrap(err)
+	}
+	if cursor.SortBy != sortBy || cursor.Desc != desc || cursor.ID == "" || (sortBy != SortByName && cursor.Time == nil) {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
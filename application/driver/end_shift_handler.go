@@ -0,0 +1,89 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type EndShiftHandler struct {
+	repo   Repository
+	shifts ShiftRepository
+}
+
+type EndShiftRequest struct {
+	DriverID string `json:"-" validate:"required"`
+}
+
+type EndShiftResponse struct {
+	Shift       *domain.Shift `json:"shift"`
+	WorkedHours float64       `json:"workedHours"`
+}
+
+func NewEndShiftHandler(repo Repository, shifts ShiftRepository) *EndShiftHandler {
+	return &EndShiftHandler{
+		repo:   repo,
+		shifts: shifts,
+	}
+}
+
+// EndShift godoc
+// @Summary      End a shift
+// @Description  Clocks the driver out and puts them offline. Not possible while on a trip.
+// @Tags         drivers
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Driver ID"
+// @Success      200  {object}  EndShiftResponse
+// @Failure 404 {object} ErrorResponse "Driver not found"
+// @Failure 409 {object} ErrorResponse "Driver has no open shift or is on a trip"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /driver/{id}/shift/end [post]
+func (h *EndShiftHandler) Handle(ctx context.Context, req *EndShiftRequest) (*EndShiftResponse, error) {
+	ctx, span := tracing.Start(ctx, "EndShiftHandler.Handle")
+	defer span.End()
+
+	driver, err := h.repo.GetDriverByID(ctx, req.DriverID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !driver.OnShift() {
+		return nil, domain.ErrNoOpenShift
+	}
+
+	current := driver.CurrentStatus()
+	if current != domain.DriverOffline {
+		if !domain.CanTransitionDriver(current, domain.DriverOffline) {
+			return nil, domain.ErrInvalidDriverTransition
+		}
+		if err := h.repo.UpdateDriverStatus(ctx, driver.ID, current, domain.DriverOffline); err != nil {
+			return nil, err
+		}
+	}
+
+	shift, err := h.shifts.GetShiftByID(ctx, driver.CurrentShiftID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := shift.End(now); err != nil {
+		return nil, err
+	}
+
+	if err := h.shifts.UpdateShift(ctx, shift); err != nil {
+		return nil, err
+	}
+
+	if err := h.repo.SetCurrentShift(ctx, driver.ID, shift.ID, ""); err != nil {
+		return nil, err
+	}
+
+	return &EndShiftResponse{
+		Shift:       shift,
+		WorkedHours: shift.WorkedDuration(now).Hours(),
+	}, nil
+}
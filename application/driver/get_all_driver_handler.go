@@ -2,8 +2,10 @@ package application
 
 import (
 	"context"
+	"time"
 
 	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
 )
 
 type GetAllDriverHandler struct {
@@ -11,12 +13,29 @@ type GetAllDriverHandler struct {
 }
 
 type GetAllFilterRequest struct {
-	Page     int `query:"page"`
-	PageSize int `query:"page_size"`
+	Cursor      string     `query:"cursor"`
+	PageSize    int        `query:"pageSize" validate:"min=1,max=100"`
+	SortBy      string     `query:"sortBy" validate:"oneof=name createdAt updatedAt"`
+	Order       string     `query:"order" validate:"oneof=asc desc"`
+	TaxiType    string     `query:"taxiType" validate:"omitempty,taxitype"`
+	CarBrand    string     `query:"carBrand" validate:"omitempty,max=50"`
+	Status      string     `query:"status" validate:"omitempty,oneof=OFFLINE ONLINE ON_TRIP ON_BREAK"`
+	CreatedFrom *time.Time `query:"createdFrom"`
+	CreatedTo   *time.Time `query:"createdTo"`
+}
+
+// ValidateFields compares the creation range only when both ends are given, either works alone
+func (r *GetAllFilterRequest) ValidateFields() []domain.FieldError {
+	if r.CreatedFrom != nil && r.CreatedTo != nil && !r.CreatedTo.After(*r.CreatedFrom) {
+		return []domain.FieldError{{Field: "createdTo", Rule: "gtfield", Message: "must be after createdFrom"}}
+	}
+	return nil
 }
 
 type GetAllDriverResponse struct {
 	Driver []*domain.Driver `json:"drivers"`
+	Total  int64            `json:"total"`          // drivers matching the filters, on every page
+	Next   string           `json:"next,omitempty"` // cursor of the next page, missing on the last one
 }
 
 func NewGetAllDriverHandler(repo Repository) *GetAllDriverHandler {
@@ -27,23 +46,69 @@ func NewGetAllDriverHandler(repo Repository) *GetAllDriverHandler {
 
 // GetAllDriver godoc
 // @Summary      Get all drivers
-// @Description  Retrieves a paginated list of all drivers.
+// @Description  Retrieves drivers page by page. Pass the next cursor of a response to get the following page.
 // @Tags         drivers
 // @Accept       json
 // @Produce      json
-// @Param        page       query     int     false  "Page number"       default(1)
-// @Param        page_size  query     int     false  "Number of items per page" default(10)
+// @Param        cursor       query     string  false  "Cursor from the previous page"
+// @Param        pageSize     query     int     false  "Number of items per page" default(20)
+// @Param        sortBy       query     string  false  "name, createdAt or updatedAt" default(createdAt)
+// @Param        order        query     string  false  "asc or desc" default(asc)
+// @Param        taxiType     query     string  false  "Taxi type"
+// @Param        carBrand     query     string  false  "Car brand"
+// @Param        status       query     string  false  "Driver status"
+// @Param        createdFrom  query     string  false  "Created at or after, RFC 3339"
+// @Param        createdTo    query     string  false  "Created before, RFC 3339"
 // @Success      200  {object}  GetAllDriverResponse
-// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 400 {object} ErrorResponse "Invalid request or cursor"
+// @Failure 422 {object} ErrorResponse "Invalid filters"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router       /drivers/getall [get]
 func (h *GetAllDriverHandler) Handle(ctx context.Context, req *GetAllFilterRequest) (*GetAllDriverResponse, error) {
-	drivers, err := h.repo.GetAllDrivers(ctx, req.Page, req.PageSize)
+	ctx, span := tracing.Start(ctx, "GetAllDriverHandler.Handle")
+	defer span.End()
+
+	desc := req.Order == "desc"
+	query := DriverQuery{
+		Filter: DriverFilter{
+			TaxiType:    req.TaxiType,
+			CarBrand:    req.CarBrand,
+			Status:      domain.DriverStatus(req.Status),
+			CreatedFrom: req.CreatedFrom,
+			CreatedTo:   req.CreatedTo,
+		},
+		SortBy: req.SortBy,
+		Desc:   desc,
+		// one more than asked tells whether there is a next page
+		Limit: req.PageSize + 1,
+	}
+	if req.Cursor != "" {
+		after, err := DecodeCursor(req.Cursor, req.SortBy, desc)
+		if err != nil {
+			return nil, err
+		}
+		query.After = after
+	}
+
+	drivers, err := h.repo.GetAllDrivers(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	total, err := h.repo.CountDrivers(ctx, query.Filter)
 	if err != nil {
 		return nil, err
 	}
 
-	return &GetAllDriverResponse{
+	res := &GetAllDriverResponse{
 		Driver: drivers,
-	}, nil
+		Total:  total,
+	}
+	if len(drivers) > req.PageSize {
+		res.Driver = drivers[:req.PageSize]
+		res.Next = CursorAfter(res.Driver[req.PageSize-1], req.SortBy, desc).Encode()
+	}
+	if res.Driver == nil {
+		res.Driver = []*domain.Driver{}
+	}
+	return res, nil
 }
@@ -0,0 +1,55 @@
+package application_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	application "github.com/hekanemre/taxihub/application/driver"
+	"github.com/hekanemre/taxihub/application/validation"
+	"github.com/hekanemre/taxihub/domain"
+)
+
+func TestGetAllFilterRequest_CreatedRange(t *testing.T) {
+	jan := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	feb := jan.AddDate(0, 1, 0)
+
+	tests := []struct {
+		name    string
+		from    *time.Time
+		to      *time.Time
+		invalid bool
+	}{
+		{name: "only createdTo", to: &jan},
+		{name: "only createdFrom", from: &jan},
+		{name: "ordered range", from: &jan, to: &feb},
+		{name: "reversed range", from: &feb, to: &jan, invalid: true},
+		{name: "empty range", from: &jan, to: &jan, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validation.Struct(&application.GetAllFilterRequest{
+				PageSize:    20,
+				SortBy:      application.SortByCreatedAt,
+				Order:       "asc",
+				CreatedFrom: tt.from,
+				CreatedTo:   tt.to,
+			})
+			if !tt.invalid {
+				if err != nil {
+					t.Fatalf("Struct = %v, want nil", err)
+				}
+				return
+			}
+
+			var derr *domain.Error
+			if !errors.Is(err, domain.ErrValidationFailed) || !errors.As(err, &derr) {
+				t.Fatalf("Struct = %v, want %v", err, domain.ErrValidationFailed)
+			}
+			if len(derr.Fields) != 1 || derr.Fields[0].Field != "createdTo" {
+				t.Fatalf("fields = %+v, want createdTo", derr.Fields)
+			}
+		})
+	}
+}
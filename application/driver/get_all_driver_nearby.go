@@ -3,6 +3,9 @@ package application
 import (
 	"context"
 	"sort"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
 )
 
 type GetAllDriverNearbyHandler struct {
@@ -10,16 +13,19 @@ type GetAllDriverNearbyHandler struct {
 }
 
 type GetAllDriverNearbyRequest struct {
-	Lat      float64 `bson:"lat" json:"lat"`
-	Lon      float64 `bson:"lon" json:"lon"`
-	TaxiType string  `bson:"taxiType" json:"taxiType"`
+	Lat      float64 `bson:"lat" json:"lat" validate:"latitude"`
+	Lon      float64 `bson:"lon" json:"lon" validate:"longitude"`
+	TaxiType string  `bson:"taxiType" json:"taxiType" validate:"required,taxitype"`
+	// only available (online) drivers are returned unless this is set
+	IncludeUnavailable bool `bson:"includeUnavailable" json:"includeUnavailable" query:"includeUnavailable"`
 }
 
 type GetAllDriverNearbyResponse struct {
-	FirstName  string  `json:"firstName"`
-	LastName   string  `json:"lastName"`
-	Plate      string  `json:"plate"`
-	DistanceKm float64 `json:"distanceKm"`
+	FirstName  string              `json:"firstName"`
+	LastName   string              `json:"lastName"`
+	Plate      string              `json:"plate"`
+	Status     domain.DriverStatus `json:"status"`
+	DistanceKm float64             `json:"distanceKm"`
 }
 
 func NewGetAllDriverNearbyHandler(repo Repository) *GetAllDriverNearbyHandler {
@@ -37,24 +43,33 @@ func NewGetAllDriverNearbyHandler(repo Repository) *GetAllDriverNearbyHandler {
 // @Param        lat       query     float64     true  "Latitude"
 // @Param        lon       query     float64     true  "Longitude"
 // @Param        taxiType  query     string      true  "Type of taxi"
+// @Param        includeUnavailable  query  bool  false  "Also return offline, busy or on break drivers"
 // @Success      200  {array}  GetAllDriverNearbyResponse
 // @Failure 400 {object} ErrorResponse "Invalid request"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router       /drivers/getallnearby [get]
 func (h *GetAllDriverNearbyHandler) Handle(ctx context.Context, req *GetAllDriverNearbyRequest) ([]*GetAllDriverNearbyResponse, error) {
-	drivers, err := h.repo.GetAllDriversNearby(ctx, req.Lat, req.Lon, req.TaxiType)
+	ctx, span := tracing.Start(ctx, "GetAllDriverNearbyHandler.Handle")
+	defer span.End()
+
+	drivers, err := h.repo.GetAllDriversNearby(ctx, req.Lat, req.Lon, req.TaxiType, !req.IncludeUnavailable)
 	if err != nil {
 		return nil, err
 	}
 
 	var responses []*GetAllDriverNearbyResponse
 	for _, driver := range drivers {
+		// drivers stored before locations were validated may have broken points
+		if len(driver.Location.Coordinates) != 2 {
+			continue
+		}
 		// MongoDB $geoNear returns distance in meters; convert to km
 		distanceKm := HaversineKm(req.Lat, req.Lon, driver.Location.Coordinates[1], driver.Location.Coordinates[0])
 		responses = append(responses, &GetAllDriverNearbyResponse{
 			FirstName:  driver.FirstName,
 			LastName:   driver.LastName,
 			Plate:      driver.Plate,
+			Status:     driver.CurrentStatus(),
 			DistanceKm: distanceKm,
 		})
 	}
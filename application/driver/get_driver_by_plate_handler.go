@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
 )
 
 type GetDriverByPlateHandler struct {
@@ -11,7 +12,7 @@ type GetDriverByPlateHandler struct {
 }
 
 type GetDriverByPlateRequest struct {
-	Plate string `json:"plate"`
+	Plate string `json:"plate" validate:"required"`
 }
 
 type GetDriverByPlateResponse struct {
@@ -25,6 +26,9 @@ func NewGetDriverByPlateHandler(repo Repository) *GetDriverByPlateHandler {
 }
 
 func (h *GetDriverByPlateHandler) Handle(ctx context.Context, req *GetDriverByPlateRequest) (*GetDriverByPlateResponse, error) {
+	ctx, span := tracing.Start(ctx, "GetDriverByPlateHandler.Handle")
+	defer span.End()
+
 	driver, err := h.repo.GetDriverByPlate(ctx, req.Plate)
 	if err != nil {
 		return nil, err
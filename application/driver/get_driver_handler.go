@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
 )
 
 type GetDriverHandler struct {
@@ -11,7 +12,7 @@ type GetDriverHandler struct {
 }
 
 type GetDriverRequest struct {
-	ID string `json:"id"`
+	ID string `json:"id" validate:"required"`
 }
 
 type GetDriverResponse struct {
@@ -36,6 +37,9 @@ func NewGetDriverHandler(repo Repository) *GetDriverHandler {
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router       /drivers/getbyid/ [get]
 func (h *GetDriverHandler) Handle(ctx context.Context, req *GetDriverRequest) (*GetDriverResponse, error) {
+	ctx, span := tracing.Start(ctx, "GetDriverHandler.Handle")
+	defer span.End()
+
 	driver, err := h.repo.GetDriverByID(ctx, req.ID)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,75 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type GetDriverShiftsHandler struct {
+	shifts ShiftRepository
+}
+
+type GetDriverShiftsRequest struct {
+	DriverID string    `json:"-" validate:"required"`
+	From     time.Time `json:"-" query:"from"`
+	To       time.Time `json:"-" query:"to" validate:"gtefield=From"`
+}
+
+type ShiftSummary struct {
+	*domain.Shift
+	WorkedHours float64 `json:"workedHours"`
+	BreakHours  float64 `json:"breakHours"`
+}
+
+type GetDriverShiftsResponse struct {
+	Shifts           []*ShiftSummary `json:"shifts"`
+	TotalWorkedHours float64         `json:"totalWorkedHours"`
+}
+
+func NewGetDriverShiftsHandler(shifts ShiftRepository) *GetDriverShiftsHandler {
+	return &GetDriverShiftsHandler{
+		shifts: shifts,
+	}
+}
+
+// GetDriverShifts godoc
+// @Summary      Get shift history of a driver
+// @Description  Lists the shifts started in the given range with hours worked, breaks excluded.
+// @Tags         drivers
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string  true   "Driver ID"
+// @Param        from  query     string  false  "Range start (RFC3339), defaults to 7 days ago"
+// @Param        to    query     string  false  "Range end (RFC3339), defaults to now"
+// @Success      200  {object}  GetDriverShiftsResponse
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /driver/{id}/shifts [get]
+func (h *GetDriverShiftsHandler) Handle(ctx context.Context, req *GetDriverShiftsRequest) (*GetDriverShiftsResponse, error) {
+	ctx, span := tracing.Start(ctx, "GetDriverShiftsHandler.Handle")
+	defer span.End()
+
+	shifts, err := h.shifts.GetShiftsByDriver(ctx, req.DriverID, req.From, req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	res := &GetDriverShiftsResponse{
+		Shifts: []*ShiftSummary{},
+	}
+	for _, shift := range shifts {
+		summary := &ShiftSummary{
+			Shift:       shift,
+			WorkedHours: shift.WorkedDuration(now).Hours(),
+			BreakHours:  shift.BreakDuration(now).Hours(),
+		}
+		res.Shifts = append(res.Shifts, summary)
+		res.TotalWorkedHours += summary.WorkedHours
+	}
+
+	return res, nil
+}
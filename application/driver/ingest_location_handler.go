@@ -0,0 +1,116 @@
+package application
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+const (
+	MaxPingsPerRequest = 100
+	// pings from the future are rejected, a little device clock skew is fine
+	maxClockSkew = time.Minute
+)
+
+type IngestLocationHandler struct {
+	batcher *LocationBatcher
+}
+
+type LocationPing struct {
+	Lat       float64   `json:"lat"`
+	Lon       float64   `json:"lon"`
+	Timestamp time.Time `json:"timestamp"` // device time, RFC3339
+}
+
+type IngestLocationRequest struct {
+	DriverID string         `json:"-" validate:"required"`
+	Pings    []LocationPing `json:"pings"`
+}
+
+type RejectedPing struct {
+	Index  int    `json:"index"`
+	Reason string `json:"reason"`
+}
+
+type IngestLocationResponse struct {
+	Accepted   int            `json:"accepted"`
+	OutOfOrder int            `json:"outOfOrder"`
+	Rejected   []RejectedPing `json:"rejected"`
+}
+
+func NewIngestLocationHandler(batcher *LocationBatcher) *IngestLocationHandler {
+	return &IngestLocationHandler{
+		batcher: batcher,
+	}
+}
+
+// IngestLocation godoc
+// @Summary      Report driver location
+// @Description  Accepts a single location ping or a batch of pings from a driver device.
+// @Description  Only location is updated, pings older than the last accepted one are dropped. Writes are batched.
+// @Tags         drivers
+// @Accept       json
+// @Produce      json
+// @Param        id     path      string          true  "Driver ID"
+// @Param        pings  body      []LocationPing  true  "A ping object or an array of pings"
+// @Success      202  {object}  IngestLocationResponse
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Router       /driver/{id}/location [post]
+func (h *IngestLocationHandler) Handle(ctx context.Context, req *IngestLocationRequest) (*IngestLocationResponse, error) {
+	ctx, span := tracing.Start(ctx, "IngestLocationHandler.Handle")
+	defer span.End()
+
+	res := &IngestLocationResponse{
+		Rejected: []RejectedPing{},
+	}
+
+	now := time.Now()
+	var valid []LocationPing
+	for i, ping := range req.Pings {
+		if reason := validatePing(ping, now); reason != "" {
+			res.Rejected = append(res.Rejected, RejectedPing{Index: i, Reason: reason})
+			continue
+		}
+		valid = append(valid, ping)
+	}
+
+	// devices may send a buffered batch in any order, replay it oldest first
+	sort.SliceStable(valid, func(i, j int) bool {
+		return valid[i].Timestamp.Before(valid[j].Timestamp)
+	})
+
+	for _, ping := range valid {
+		update := domain.LocationUpdate{
+			DriverID: req.DriverID,
+			// GeoJSON keeps coordinates as [lon, lat]
+			Location:   domain.Location{Type: "Point", Coordinates: []float64{ping.Lon, ping.Lat}},
+			RecordedAt: ping.Timestamp,
+		}
+
+		if h.batcher.Submit(update) {
+			res.Accepted++
+		} else {
+			res.OutOfOrder++
+		}
+	}
+
+	return res, nil
+}
+
+func validatePing(ping LocationPing, now time.Time) string {
+	switch {
+	case math.IsNaN(ping.Lat) || ping.Lat < -90 || ping.Lat > 90:
+		return "lat must be between -90 and 90"
+	case math.IsNaN(ping.Lon) || ping.Lon < -180 || ping.Lon > 180:
+		return "lon must be between -180 and 180"
+	case ping.Timestamp.IsZero():
+		return "timestamp is required"
+	case ping.Timestamp.After(now.Add(maxClockSkew)):
+		return "timestamp is in the future"
+	}
+	return ""
+}
@@ -0,0 +1,145 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"go.uber.org/zap"
+)
+
+// LocationBatcher collects location pings and writes them to the repository in batches.
+// Only the newest ping per driver is kept between flushes, the rest would be overwritten anyway.
+type LocationBatcher struct {
+	repo          Repository
+	flushInterval time.Duration
+	maxBatchSize  int
+
+	mu       sync.Mutex
+	pending  map[string]domain.LocationUpdate
+	lastSeen map[string]time.Time // newest accepted device time per driver, to drop late pings early
+	full     chan struct{}
+	flushErr error // result of the last flush, reported by Check
+}
+
+func NewLocationBatcher(repo Repository, flushInterval time.Duration, maxBatchSize int) *LocationBatcher {
+	return &LocationBatcher{
+		repo:          repo,
+		flushInterval: flushInterval,
+		maxBatchSize:  maxBatchSize,
+		pending:       make(map[string]domain.LocationUpdate),
+		lastSeen:      make(map[string]time.Time),
+		full:          make(chan struct{}, 1),
+	}
+}
+
+// Submit queues an update and reports false when it is older than what we already have.
+// The repository checks again on write, so late pings from other instances are dropped too.
+func (b *LocationBatcher) Submit(update domain.LocationUpdate) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if last, ok := b.lastSeen[update.DriverID]; ok && !update.RecordedAt.After(last) {
+		return false
+	}
+
+	b.lastSeen[update.DriverID] = update.RecordedAt
+	b.pending[update.DriverID] = update
+
+	if len(b.pending) >= b.maxBatchSize {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+
+	return true
+}
+
+// Flush writes everything queued so far
+func (b *LocationBatcher) Flush(ctx context.Context) error {
+	b.mu.Lock()
+	if len(b.pending) == 0 {
+		b.mu.Unlock()
+		return nil
+	}
+
+	updates := make([]domain.LocationUpdate, 0, len(b.pending))
+	for _, update := range b.pending {
+		updates = append(updates, update)
+	}
+	b.pending = make(map[string]domain.LocationUpdate)
+
+	// forget drivers that stopped sending, the repository still guards the order for them
+	cutoff := time.Now().Add(-10 * time.Minute)
+	for driverID, last := range b.lastSeen {
+		if last.Before(cutoff) {
+			delete(b.lastSeen, driverID)
+		}
+	}
+	b.mu.Unlock()
+
+	applied, err := b.repo.UpdateDriverLocations(ctx, updates)
+	b.mu.Lock()
+	b.flushErr = err
+	b.mu.Unlock()
+	if err != nil {
+		b.requeue(updates)
+		return err
+	}
+
+	zap.L().Debug("Flushed driver locations", zap.Int("queued", len(updates)), zap.Int("applied", len(applied)))
+	return nil
+}
+
+// requeue puts back a failed batch unless newer pings arrived in the meantime
+func (b *LocationBatcher) requeue(updates []domain.LocationUpdate) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, update := range updates {
+		if _, newer := b.pending[update.DriverID]; !newer {
+			b.pending[update.DriverID] = update
+		}
+	}
+}
+
+// Run flushes every interval or as soon as a batch is full, and once more when ctx is done
+func (b *LocationBatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			// ctx is already cancelled, give the last batch its own deadline
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			if err := b.Flush(flushCtx); err != nil {
+				zap.L().Error("Failed to flush driver locations on shutdown", zap.Error(err))
+			}
+			cancel()
+			return
+		case <-ticker.C:
+		case <-b.full:
+		}
+
+		flushCtx, cancel := context.WithTimeout(ctx, b.flushInterval+5*time.Second)
+		if err := b.Flush(flushCtx); err != nil {
+			zap.L().Error("Failed to flush driver locations", zap.Error(err))
+		}
+		cancel()
+	}
+}
+
+// Check fails while the last flush failed, pings are piling up in memory meanwhile
+func (b *LocationBatcher) Check(ctx context.Context) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.flushErr != nil {
+		return fmt.Errorf("last flush failed, %d drivers waiting: %w", len(b.pending), b.flushErr)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package application
+
+// mergePatch applies an RFC 7396 JSON Merge Patch to a decoded JSON document:
+// objects are merged key by key, null removes a key and anything else replaces the target
+func mergePatch(target, patch any) any {
+	patchObject, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]any)
+	if !ok {
+		targetObject = map[string]any{}
+	}
+
+	for key, value := range patchObject {
+		if value == nil {
+			delete(targetObject, key)
+			continue
+		}
+		targetObject[key] = mergePatch(targetObject[key], value)
+	}
+	return targetObject
+}
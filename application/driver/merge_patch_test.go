@@ -0,0 +1,173 @@
+package application
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/hekanemre/taxihub/domain"
+)
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		target any
+		patch  any
+		want   any
+	}{
+		{
+			name:   "replaces a key",
+			target: map[string]any{"a": "b"},
+			patch:  map[string]any{"a": "c"},
+			want:   map[string]any{"a": "c"},
+		},
+		{
+			name:   "adds a key",
+			target: map[string]any{"a": "b"},
+			patch:  map[string]any{"b": "c"},
+			want:   map[string]any{"a": "b", "b": "c"},
+		},
+		{
+			name:   "null removes a key",
+			target: map[string]any{"a": "b", "b": "c"},
+			patch:  map[string]any{"a": nil},
+			want:   map[string]any{"b": "c"},
+		},
+		{
+			name:   "null on a missing key changes nothing",
+			target: map[string]any{"a": "b"},
+			patch:  map[string]any{"c": nil},
+			want:   map[string]any{"a": "b"},
+		},
+		{
+			name:   "merges nested objects",
+			target: map[string]any{"a": map[string]any{"b": "c", "d": "e"}},
+			patch:  map[string]any{"a": map[string]any{"d": "f"}},
+			want:   map[string]any{"a": map[string]any{"b": "c", "d": "f"}},
+		},
+		{
+			name:   "null removes a nested key",
+			target: map[string]any{"a": map[string]any{"b": "c", "d": "e"}},
+			patch:  map[string]any{"a": map[string]any{"b": nil}},
+			want:   map[string]any{"a": map[string]any{"d": "e"}},
+		},
+		{
+			name:   "arrays are replaced whole",
+			target: map[string]any{"a": []any{1.0, 2.0}},
+			patch:  map[string]any{"a": []any{3.0}},
+			want:   map[string]any{"a": []any{3.0}},
+		},
+		{
+			name:   "an object replaces a scalar",
+			target: map[string]any{"a": "b"},
+			patch:  map[string]any{"a": map[string]any{"c": "d"}},
+			want:   map[string]any{"a": map[string]any{"c": "d"}},
+		},
+		{
+			name:   "a patch that is not an object replaces the target",
+			target: map[string]any{"a": "b"},
+			patch:  "c",
+			want:   "c",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergePatch(tt.target, tt.patch); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("mergePatch = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyDriverPatch(t *testing.T) {
+	newDriver := func() *domain.Driver {
+		return &domain.Driver{
+			FirstName: "Ada",
+			LastName:  "Lovelace",
+			Plate:     "34 AB 123",
+			TaxiType:  "yellow",
+			CarBrand:  "Fiat",
+			CarModel:  "Egea",
+			Location:  domain.Location{Type: "Point", Coordinates: []float64{29, 41}},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		patch     map[string]any
+		want      func(*patchableDriver)
+		wrongType bool
+	}{
+		{
+			name:  "changes a field",
+			patch: map[string]any{"firstName": "Grace"},
+			want:  func(p *patchableDriver) { p.FirstName = "Grace" },
+		},
+		{
+			name:  "null clears a field",
+			patch: map[string]any{"carModel": nil},
+			want:  func(p *patchableDriver) { p.CarModel = "" },
+		},
+		{
+			name:  "replaces the coordinates",
+			patch: map[string]any{"location": map[string]any{"coordinates": []any{28.9, 41.1}}},
+			want:  func(p *patchableDriver) { p.Location.Coordinates = []float64{28.9, 41.1} },
+		},
+		{
+			name:  "null removes the coordinates",
+			patch: map[string]any{"location": map[string]any{"coordinates": nil}},
+			want:  func(p *patchableDriver) { p.Location.Coordinates = nil },
+		},
+		{
+			name:  "null removes the location",
+			patch: map[string]any{"location": nil},
+			want:  func(p *patchableDriver) { p.Location = domain.Location{} },
+		},
+		{
+			name:      "a string where an object belongs",
+			patch:     map[string]any{"location": "Istanbul"},
+			wrongType: true,
+		},
+		{
+			name:      "a number where a string belongs",
+			patch:     map[string]any{"firstName": 42.0},
+			wrongType: true,
+		},
+		{
+			name:      "strings where coordinates belong",
+			patch:     map[string]any{"location": map[string]any{"coordinates": []any{"29", "41"}}},
+			wrongType: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyDriverPatch(newDriver(), tt.patch)
+			if tt.wrongType {
+				if !errors.Is(err, domain.ErrInvalidRequest) {
+					t.Fatalf("applyDriverPatch = %v, want ErrInvalidRequest", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("applyDriverPatch: %v", err)
+			}
+
+			driver := newDriver()
+			want := &patchableDriver{
+				FirstName: driver.FirstName,
+				LastName:  driver.LastName,
+				Plate:     driver.Plate,
+				TaxiType:  driver.TaxiType,
+				CarBrand:  driver.CarBrand,
+				CarModel:  driver.CarModel,
+				Location:  driver.Location,
+			}
+			tt.want(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Fatalf("applyDriverPatch = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
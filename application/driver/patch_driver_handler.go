@@ -0,0 +1,165 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/hekanemre/taxihub/application/validation"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type PatchDriverHandler struct {
+	repo Repository
+}
+
+type PatchDriverRequest struct {
+	DriverID string          `json:"-" validate:"required"`
+	Patch    json.RawMessage `json:"-" validate:"required"` // RFC 7396 merge patch of the driver
+	IfMatch  *int64          `json:"-"`                     // version from the If-Match header, nil skips the check
+}
+
+type PatchDriverResponse struct {
+	Driver *domain.Driver `json:"driver"`
+}
+
+// patchableDriver is the part of a driver a merge patch may change, with the rules of UpdateDriverRequest.
+// Keys are the ones drivers are read with, so a client patches what it got.
+type patchableDriver struct {
+	FirstName string          `json:"firstName" validate:"required,max=100"`
+	LastName  string          `json:"lastName" validate:"required,max=100"`
+	Plate     string          `json:"plate" validate:"required,max=20"`
+	TaxiType  string          `json:"taxiType" validate:"required,taxitype"`
+	CarBrand  string          `json:"carBrand" validate:"max=50"`
+	CarModel  string          `json:"carModel" validate:"max=50"`
+	Location  domain.Location `json:"location"`
+}
+
+var patchableDriverFields = []string{"firstName", "lastName", "plate", "taxiType", "carBrand", "carModel", "location"}
+
+func NewPatchDriverHandler(repo Repository) *PatchDriverHandler {
+	return &PatchDriverHandler{
+		repo: repo,
+	}
+}
+
+// PatchDriver godoc
+// @Summary      Partially update a driver
+// @Description  Applies a JSON Merge Patch (RFC 7396) to the driver: only the fields in the patch change, null clears a field. firstName, lastName, plate, taxiType, carBrand, carModel and location can be patched. Send the ETag of the driver read as If-Match to not overwrite a change made since.
+// @Tags         drivers
+// @Accept       application/merge-patch+json
+// @Produce      json
+// @Param        id        path      string  true   "Driver ID"
+// @Param        patch     body      object  true   "Merge patch"
+// @Param        If-Match  header    string  false  "ETag of the driver the patch is based on"
+// @Success      200  {object}  PatchDriverResponse
+// @Failure 400 {object} ErrorResponse "Patch is not a JSON object"
+// @Failure 404 {object} ErrorResponse "Driver not found"
+// @Failure 409 {object} ErrorResponse "A driver with the same plate exists"
+// @Failure 412 {object} ErrorResponse "Driver was changed since it was read"
+// @Failure 422 {object} ErrorResponse "Patched driver is invalid or a read only field was patched"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /driver/{id} [patch]
+func (h *PatchDriverHandler) Handle(ctx context.Context, req *PatchDriverRequest) (*PatchDriverResponse, error) {
+	ctx, span := tracing.Start(ctx, "PatchDriverHandler.Handle")
+	defer span.End()
+
+	var patch map[string]any
+	if err := json.Unmarshal(req.Patch, &patch); err != nil || patch == nil {
+		return nil, domain.ErrInvalidRequest.WithMessage("Patch must be a JSON object")
+	}
+	if err := checkPatchableFields(patch); err != nil {
+		return nil, err
+	}
+
+	driver, err := h.repo.GetDriverByID(ctx, req.DriverID)
+	if err != nil {
+		return nil, err
+	}
+	if req.IfMatch != nil && *req.IfMatch != driver.Version {
+		return nil, domain.ErrDriverVersionMismatch
+	}
+
+	// nothing to change, the version stays
+	if len(patch) == 0 {
+		return &PatchDriverResponse{Driver: driver}, nil
+	}
+
+	fields, err := applyDriverPatch(driver, patch)
+	if err != nil {
+		return nil, err
+	}
+	if err := validation.Struct(fields); err != nil {
+		return nil, err
+	}
+
+	driver.FirstName = fields.FirstName
+	driver.LastName = fields.LastName
+	driver.Plate = fields.Plate
+	driver.TaxiType = fields.TaxiType
+	driver.CarBrand = fields.CarBrand
+	driver.CarModel = fields.CarModel
+	driver.Location = fields.Location
+	driver.UpdatedAt = time.Now()
+
+	// the version read above guards against a change made since
+	if err := h.repo.UpdateDriver(ctx, driver); err != nil {
+		return nil, err
+	}
+
+	return &PatchDriverResponse{
+		Driver: driver,
+	}, nil
+}
+
+// checkPatchableFields rejects a patch touching anything but the patchable fields,
+// ids, status and versions are never written by clients
+func checkPatchableFields(patch map[string]any) error {
+	var fields []domain.FieldError
+	for key := range patch {
+		if !slices.Contains(patchableDriverFields, key) {
+			fields = append(fields, domain.FieldError{Field: key, Rule: "readonly", Message: "cannot be patched"})
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Field < fields[j].Field })
+	return domain.ErrValidationFailed.WithFields(fields)
+}
+
+// applyDriverPatch merges the patch into the patchable fields of driver
+func applyDriverPatch(driver *domain.Driver, patch map[string]any) (*patchableDriver, error) {
+	current, err := json.Marshal(patchableDriver{
+		FirstName: driver.FirstName,
+		LastName:  driver.LastName,
+		Plate:     driver.Plate,
+		TaxiType:  driver.TaxiType,
+		CarBrand:  driver.CarBrand,
+		CarModel:  driver.CarModel,
+		Location:  driver.Location,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var document any
+	if err := json.Unmarshal(current, &document); err != nil {
+		return nil, err
+	}
+
+	patched, err := json.Marshal(mergePatch(document, patch))
+	if err != nil {
+		return nil, err
+	}
+
+	var fields patchableDriver
+	if err := json.Unmarshal(patched, &fields); err != nil {
+		return nil, domain.ErrInvalidRequest.WithMessage("Patch sets a field to a value of the wrong type").Wrap(err)
+	}
+	return &fields, nil
+}
@@ -0,0 +1,124 @@
+package application
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+)
+
+// EventPublisher receives driver changes for live subscribers.
+// Publish must never block, writes go through it on the hot path.
+type EventPublisher interface {
+	Publish(event domain.DriverEvent)
+}
+
+// PublishingRepository decorates a Repository and publishes every successful
+// status and location change and every removal, so handlers don't need to know about streaming.
+// Events carry the taxi type and location subscribers filter on, the hub never reads drivers itself.
+type PublishingRepository struct {
+	Repository
+	publisher EventPublisher
+}
+
+func NewPublishingRepository(repo Repository, publisher EventPublisher) *PublishingRepository {
+	return &PublishingRepository{
+		Repository: repo,
+		publisher:  publisher,
+	}
+}
+
+// UpdateDriver publishes a location event only if the driver moved or changed its taxi type,
+// most edits are about names and cars and subscribers would just see the same position again
+func (r *PublishingRepository) UpdateDriver(ctx context.Context, driver *domain.Driver) error {
+	// a driver that can not be read is reported by the update below
+	before, _ := r.Repository.GetDriverByID(ctx, driver.ID)
+
+	if err := r.Repository.UpdateDriver(ctx, driver); err != nil {
+		return err
+	}
+
+	if before != nil && before.TaxiType == driver.TaxiType && sameLocation(before.Location, driver.Location) {
+		return nil
+	}
+
+	location := driver.Location
+	r.publisher.Publish(domain.DriverEvent{
+		Type:      domain.DriverLocationChanged,
+		DriverID:  driver.ID,
+		TaxiType:  driver.TaxiType,
+		Location:  &location,
+		Timestamp: time.Now(),
+	})
+	return nil
+}
+
+func (r *PublishingRepository) UpdateDriverStatus(ctx context.Context, id string, from, to domain.DriverStatus) error {
+	if err := r.Repository.UpdateDriverStatus(ctx, id, from, to); err != nil {
+		return err
+	}
+
+	event := domain.DriverEvent{
+		Type:      domain.DriverStatusChanged,
+		DriverID:  id,
+		Status:    to,
+		Timestamp: time.Now(),
+	}
+	// status changes are rare, unlike pings, reading the driver here is cheap
+	if driver, err := r.Repository.GetDriverByID(ctx, id); err == nil {
+		location := driver.Location
+		event.TaxiType = driver.TaxiType
+		event.Location = &location
+	}
+	r.publisher.Publish(event)
+	return nil
+}
+
+func (r *PublishingRepository) DeleteDriver(ctx context.Context, id, reason string) error {
+	if err := r.Repository.DeleteDriver(ctx, id, reason); err != nil {
+		return err
+	}
+	r.publishRemoved(id)
+	return nil
+}
+
+func (r *PublishingRepository) DeactivateDriver(ctx context.Context, id, reason string) error {
+	if err := r.Repository.DeactivateDriver(ctx, id, reason); err != nil {
+		return err
+	}
+	r.publishRemoved(id)
+	return nil
+}
+
+func (r *PublishingRepository) publishRemoved(id string) {
+	r.publisher.Publish(domain.DriverEvent{
+		Type:      domain.DriverRemoved,
+		DriverID:  id,
+		Timestamp: time.Now(),
+	})
+}
+
+// UpdateDriverLocations publishes only what was applied, a late ping did not move anybody
+func (r *PublishingRepository) UpdateDriverLocations(ctx context.Context, updates []domain.LocationUpdate) ([]domain.LocationUpdate, error) {
+	applied, err := r.Repository.UpdateDriverLocations(ctx, updates)
+	if err != nil {
+		return applied, err
+	}
+
+	for _, u := range applied {
+		location := u.Location
+		r.publisher.Publish(domain.DriverEvent{
+			Type:      domain.DriverLocationChanged,
+			DriverID:  u.DriverID,
+			TaxiType:  u.TaxiType,
+			Location:  &location,
+			Timestamp: u.RecordedAt,
+		})
+	}
+	return applied, nil
+}
+
+func sameLocation(a, b domain.Location) bool {
+	return a.Type == b.Type && slices.Equal(a.Coordinates, b.Coordinates)
+}
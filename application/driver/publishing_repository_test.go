@@ -0,0 +1,131 @@
+package application_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	application "github.com/hekanemre/taxihub/application/driver"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/infrastructure"
+)
+
+// recordingPublisher keeps every published event in order
+type recordingPublisher struct {
+	events []domain.DriverEvent
+}
+
+func (p *recordingPublisher) Publish(event domain.DriverEvent) {
+	p.events = append(p.events, event)
+}
+
+func newPublishingRepository(t *testing.T) (*application.PublishingRepository, *recordingPublisher) {
+	t.Helper()
+
+	repo := infrastructure.NewMemoryRepository(1000)
+	driver := &domain.Driver{
+		ID:        "d1",
+		FirstName: "Ada",
+		LastName:  "Lovelace",
+		Plate:     "34AB123",
+		TaxiType:  "yellow",
+		Location:  domain.Location{Type: "Point", Coordinates: []float64{29, 41}},
+		Version:   1,
+	}
+	if err := repo.CreateDriver(context.Background(), driver); err != nil {
+		t.Fatalf("CreateDriver: %v", err)
+	}
+
+	publisher := &recordingPublisher{}
+	return application.NewPublishingRepository(repo, publisher), publisher
+}
+
+func locationUpdate(driverID string, lon, lat float64, recordedAt time.Time) domain.LocationUpdate {
+	return domain.LocationUpdate{
+		DriverID:   driverID,
+		Location:   domain.Location{Type: "Point", Coordinates: []float64{lon, lat}},
+		RecordedAt: recordedAt,
+	}
+}
+
+func TestPublishingRepository_UpdateDriverLocationsPublishesApplied(t *testing.T) {
+	ctx := context.Background()
+	repo, publisher := newPublishingRepository(t)
+	now := time.Now()
+
+	if _, err := repo.UpdateDriverLocations(ctx, []domain.LocationUpdate{locationUpdate("d1", 29.1, 41.1, now)}); err != nil {
+		t.Fatalf("UpdateDriverLocations: %v", err)
+	}
+
+	// a late ping and an unknown driver move nobody
+	applied, err := repo.UpdateDriverLocations(ctx, []domain.LocationUpdate{
+		locationUpdate("d1", 29.2, 41.2, now.Add(-time.Minute)),
+		locationUpdate("missing", 29.2, 41.2, now),
+	})
+	if err != nil {
+		t.Fatalf("UpdateDriverLocations: %v", err)
+	}
+	if len(applied) != 0 {
+		t.Fatalf("%d updates applied, want none", len(applied))
+	}
+
+	if len(publisher.events) != 1 {
+		t.Fatalf("%d events published, want 1", len(publisher.events))
+	}
+	event := publisher.events[0]
+	if event.Type != domain.DriverLocationChanged || event.DriverID != "d1" || event.TaxiType != "yellow" {
+		t.Fatalf("published %+v, want a location event of yellow driver d1", event)
+	}
+	if event.Location.Coordinates[0] != 29.1 {
+		t.Fatalf("published location %v, want the applied one", event.Location.Coordinates)
+	}
+}
+
+func TestPublishingRepository_EventsCarryFilterFields(t *testing.T) {
+	ctx := context.Background()
+	repo, publisher := newPublishingRepository(t)
+
+	if err := repo.UpdateDriverStatus(ctx, "d1", domain.DriverOffline, domain.DriverOnBreak); err != nil {
+		t.Fatalf("UpdateDriverStatus: %v", err)
+	}
+	if err := repo.DeleteDriver(ctx, "d1", "left the company"); err != nil {
+		t.Fatalf("DeleteDriver: %v", err)
+	}
+
+	if len(publisher.events) != 2 {
+		t.Fatalf("%d events published, want 2", len(publisher.events))
+	}
+	status := publisher.events[0]
+	if status.Type != domain.DriverStatusChanged || status.TaxiType != "yellow" || status.Location == nil {
+		t.Fatalf("status event %+v, want taxi type and location filled in", status)
+	}
+	if removed := publisher.events[1]; removed.Type != domain.DriverRemoved || removed.DriverID != "d1" {
+		t.Fatalf("second event %+v, want d1 removed", removed)
+	}
+}
+
+func TestPublishingRepository_UpdateDriverPublishesMoves(t *testing.T) {
+	ctx := context.Background()
+	repo, publisher := newPublishingRepository(t)
+
+	driver, err := repo.GetDriverByID(ctx, "d1")
+	if err != nil {
+		t.Fatalf("GetDriverByID: %v", err)
+	}
+
+	driver.CarBrand = "Fiat"
+	if err := repo.UpdateDriver(ctx, driver); err != nil {
+		t.Fatalf("UpdateDriver: %v", err)
+	}
+	if len(publisher.events) != 0 {
+		t.Fatalf("%d events published for an edit that did not move the driver, want none", len(publisher.events))
+	}
+
+	driver.Location.Coordinates = []float64{29.5, 41.5}
+	if err := repo.UpdateDriver(ctx, driver); err != nil {
+		t.Fatalf("UpdateDriver: %v", err)
+	}
+	if len(publisher.events) != 1 || publisher.events[0].Type != domain.DriverLocationChanged {
+		t.Fatalf("published %+v, want one location event", publisher.events)
+	}
+}
@@ -2,17 +2,66 @@ package application
 
 import (
 	"context"
+	"time"
 
 	"github.com/hekanemre/taxihub/domain"
 )
 
+var (
+	// ErrDriverStateConflict is returned when the driver is not in the expected status or shift anymore
+	ErrDriverStateConflict = domain.NewConflictError("DRIVER_STATE_CONFLICT", "driver was changed concurrently")
+	ErrDuplicatePlate      = domain.NewConflictError("DUPLICATE_PLATE", "Driver with the same plate already exists")
+	ErrDriverNotDeleted    = domain.NewNotFoundError("DELETED_DRIVER_NOT_FOUND", "no deleted driver with this id")
+	// plates are unique among drivers that are not deleted, another driver may take the plate meanwhile
+	ErrPlateTakenOnRestore = domain.NewConflictError("PLATE_TAKEN", "another driver holds the plate of this driver now, change one of the plates first")
+)
+
 // we add this to lose coupling. We used dependency inversion.
-// so app is not directly dependent to repository
+// so app is not directly dependent to repository.
+// Soft deleted drivers are invisible to every method but RestoreDriver and PurgeDeletedDrivers.
 type Repository interface {
 	CreateDriver(ctx context.Context, driver *domain.Driver) error
+	// UpdateDriver writes the editable fields of driver only if the stored version still is driver.Version,
+	// then bumps driver.Version. A driver changed in the meantime gives domain.ErrDriverVersionMismatch,
+	// one deleted in the meantime domain.ErrDriverNotFound.
 	UpdateDriver(ctx context.Context, driver *domain.Driver) error
-	GetAllDrivers(ctx context.Context, page, pageSiz int) ([]*domain.Driver, error)
+	// GetAllDrivers returns up to query.Limit drivers matching the filter, after the cursor
+	GetAllDrivers(ctx context.Context, query DriverQuery) ([]*domain.Driver, error)
+	CountDrivers(ctx context.Context, filter DriverFilter) (int64, error)
 	GetDriverByID(ctx context.Context, id string) (*domain.Driver, error)
 	GetDriverByPlate(ctx context.Context, plate string) (*domain.Driver, error)
-	GetAllDriversNearby(ctx context.Context, lat, lon float64, taxiType string) ([]*domain.Driver, error)
+	// GetDriverByUserID returns the driver an account drives as, ErrDriverNotFound if it has none
+	GetDriverByUserID(ctx context.Context, userID string) (*domain.Driver, error)
+	// SearchDrivers returns up to limit drivers sharing search grams with grams, most shared first
+	SearchDrivers(ctx context.Context, grams []string, limit int) ([]*domain.Driver, error)
+	GetAllDriversNearby(ctx context.Context, lat, lon float64, taxiType string, onlyAvailable bool) ([]*domain.Driver, error)
+	// UpdateDriverStatus moves the driver from one status to another, only if it is still in from
+	UpdateDriverStatus(ctx context.Context, id string, from, to domain.DriverStatus) error
+	// SetCurrentShift swaps the open shift link from one id to another, an empty id means no shift
+	SetCurrentShift(ctx context.Context, id, from, to string) error
+	// UpdateDriverLocations writes only location, skipping updates older than the stored one.
+	// It returns the updates that actually moved a driver, with the driver's taxi type filled in.
+	UpdateDriverLocations(ctx context.Context, updates []domain.LocationUpdate) ([]domain.LocationUpdate, error)
+	// GetAvailableDrivers returns every ONLINE driver, surge counts them as supply
+	GetAvailableDrivers(ctx context.Context) ([]*domain.Driver, error)
+	// DeleteDriver soft deletes a driver without an open shift, the purge removes it after the retention period
+	DeleteDriver(ctx context.Context, id, reason string) error
+	// DeactivateDriver soft deletes like DeleteDriver, but the purge keeps the driver until it is restored
+	DeactivateDriver(ctx context.Context, id, reason string) error
+	// RestoreDriver undoes DeleteDriver and DeactivateDriver, ErrDriverNotDeleted if there is nothing to restore
+	// and ErrPlateTakenOnRestore if a driver that is not deleted has the same plate
+	RestoreDriver(ctx context.Context, id string) error
+	// PurgeDeletedDrivers hard deletes drivers deleted before the cutoff and returns their ids, deactivated ones are kept
+	PurgeDeletedDrivers(ctx context.Context, deletedBefore time.Time) ([]string, error)
+}
+
+// shifts live in their own collection
+type ShiftRepository interface {
+	CreateShift(ctx context.Context, shift *domain.Shift) error
+	UpdateShift(ctx context.Context, shift *domain.Shift) error
+	GetShiftByID(ctx context.Context, id string) (*domain.Shift, error)
+	// DeleteShift removes a shift that never got going, it is not meant for finished shifts
+	DeleteShift(ctx context.Context, id string) error
+	// GetShiftsByDriver returns shifts started in [from, to), newest first
+	GetShiftsByDriver(ctx context.Context, driverID string, from, to time.Time) ([]*domain.Shift, error)
 }
@@ -0,0 +1,55 @@
+package application
+
+import (
+	"context"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type RestoreDriverHandler struct {
+	repo Repository
+}
+
+type RestoreDriverRequest struct {
+	DriverID string `json:"-" validate:"required"`
+}
+
+type RestoreDriverResponse struct {
+	Driver *domain.Driver `json:"driver"`
+}
+
+func NewRestoreDriverHandler(repo Repository) *RestoreDriverHandler {
+	return &RestoreDriverHandler{
+		repo: repo,
+	}
+}
+
+// RestoreDriver godoc
+// @Summary      Restore a deleted driver
+// @Description  Brings back a deleted or deactivated driver that was not purged yet. The driver comes back offline.
+// @Tags         drivers
+// @Produce      json
+// @Param        id   path      string  true  "Driver ID"
+// @Success      200  {object}  RestoreDriverResponse
+// @Failure 404 {object} ErrorResponse "No deleted driver with this id"
+// @Failure 409 {object} ErrorResponse "Another driver holds the plate now"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /driver/{id}/restore [post]
+func (h *RestoreDriverHandler) Handle(ctx context.Context, req *RestoreDriverRequest) (*RestoreDriverResponse, error) {
+	ctx, span := tracing.Start(ctx, "RestoreDriverHandler.Handle")
+	defer span.End()
+
+	if err := h.repo.RestoreDriver(ctx, req.DriverID); err != nil {
+		return nil, err
+	}
+
+	driver, err := h.repo.GetDriverByID(ctx, req.DriverID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RestoreDriverResponse{
+		Driver: driver,
+	}, nil
+}
@@ -0,0 +1,169 @@
+package application
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+// drivers sharing the most grams with the query are ranked, the rest is never looked at
+const searchCandidates = 200
+
+type SearchDriversHandler struct {
+	repo Repository
+}
+
+type SearchDriversRequest struct {
+	Query string `query:"q" validate:"required,max=100"`
+	Limit int    `query:"limit" validate:"min=1,max=50"`
+}
+
+type SearchDriverResult struct {
+	Driver *domain.Driver `json:"driver"`
+	Score  float64        `json:"score"` // 1 is an exact match on every word
+}
+
+type SearchDriversResponse struct {
+	Results []*SearchDriverResult `json:"results"`
+}
+
+func NewSearchDriversHandler(repo Repository) *SearchDriversHandler {
+	return &SearchDriversHandler{
+		repo: repo,
+	}
+}
+
+// SearchDrivers godoc
+// @Summary      Search drivers
+// @Description  Finds drivers by first name, last name, plate, car brand and model. Words may be prefixes, contain typos and be typed without Turkish letters. Every word must match.
+// @Tags         drivers
+// @Produce      json
+// @Param        q      query     string  true   "Search text"
+// @Param        limit  query     int     false  "Maximum number of results" default(10)
+// @Success      200  {object}  SearchDriversResponse
+// @Failure 422 {object} ErrorResponse "Invalid request"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /driver/search [get]
+func (h *SearchDriversHandler) Handle(ctx context.Context, req *SearchDriversRequest) (*SearchDriversResponse, error) {
+	ctx, span := tracing.Start(ctx, "SearchDriversHandler.Handle")
+	defer span.End()
+
+	res := &SearchDriversResponse{Results: []*SearchDriverResult{}}
+
+	grams := domain.QueryGrams(req.Query)
+	if len(grams) == 0 {
+		return res, nil
+	}
+
+	candidates, err := h.repo.SearchDrivers(ctx, grams, searchCandidates)
+	if err != nil {
+		return nil, err
+	}
+
+	queryTokens := domain.SearchTokens(req.Query)
+	for _, driver := range candidates {
+		if score := scoreDriver(driver, queryTokens); score > 0 {
+			res.Results = append(res.Results, &SearchDriverResult{Driver: driver, Score: score})
+		}
+	}
+
+	sort.SliceStable(res.Results, func(i, j int) bool {
+		a, b := res.Results[i], res.Results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Driver.FirstName+" "+a.Driver.LastName < b.Driver.FirstName+" "+b.Driver.LastName
+	})
+	if len(res.Results) > req.Limit {
+		res.Results = res.Results[:req.Limit]
+	}
+
+	return res, nil
+}
+
+// scoreDriver averages how well each query word matches its best driver word,
+// a query word matching nothing rules the driver out with 0
+func scoreDriver(driver *domain.Driver, queryTokens []string) float64 {
+	fields := domain.DriverSearchFields(driver)
+
+	total := 0.0
+	for _, query := range queryTokens {
+		best := 0.0
+		for _, field := range fields {
+			for _, token := range domain.SearchTokens(field.Text) {
+				best = max(best, matchToken(query, token)*field.Weight)
+			}
+		}
+		if best == 0 {
+			return 0
+		}
+		total += best
+	}
+
+	return math.Round(total/float64(len(queryTokens))*1000) / 1000
+}
+
+// matchToken scores one query word against one driver word:
+// exact beats prefix, prefix beats a match with typos
+func matchToken(query, token string) float64 {
+	if query == token {
+		return 1
+	}
+	if strings.HasPrefix(token, query) {
+		return 0.8 + 0.2*float64(len(query))/float64(len(token))
+	}
+
+	// longer words may carry more typos, short ones none at all
+	allowed := 0
+	switch q := len([]rune(query)); {
+	case q >= 8:
+		allowed = 2
+	case q >= 4:
+		allowed = 1
+	}
+	if allowed == 0 {
+		return 0
+	}
+
+	distance := editDistance(query, token)
+	// a typo in what is meant to be a prefix of a longer word
+	if tokenRunes := []rune(token); len(tokenRunes) > len([]rune(query)) {
+		distance = min(distance, editDistance(query, string(tokenRunes[:len([]rune(query))])))
+	}
+	if distance > allowed {
+		return 0
+	}
+	return 0.6 - 0.1*float64(distance)
+}
+
+// editDistance counts the single letter edits turning a into b, swapping two neighbouring
+// letters counts as one edit since that is the most common typo
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
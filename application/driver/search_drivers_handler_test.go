@@ -0,0 +1,91 @@
+package application
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hekanemre/taxihub/domain"
+)
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "abc", 3},
+		{"ahmet", "ahmet", 0},
+		{"kitten", "sitting", 3},
+		// neighbouring letters swapped are one edit
+		{"amhet", "ahmet", 1},
+		{"sukur", "sukru", 1},
+		{"ba", "ab", 1},
+		// counted in letters, not bytes
+		{"şükrü", "şukrü", 1},
+	}
+
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestMatchToken(t *testing.T) {
+	tests := []struct {
+		name         string
+		query, token string
+		want         float64
+	}{
+		{"exact", "sukru", "sukru", 1},
+		{"prefix", "ahm", "ahmet", 0.92},
+		{"compact plate prefix", "34abc", "34abc123", 0.925},
+		{"transposed letters", "amhet", "ahmet", 0.5},
+		{"one typo", "ahmat", "ahmet", 0.5},
+		{"typo in a prefix", "ahmte", "ahmetcan", 0.5},
+		{"two typos in a short word", "ahmxx", "ahmet", 0},
+		{"two typos in a long word", "yilmazogxx", "yilmazoglu", 0.4},
+		{"three typos in a long word", "yilmazoxxx", "yilmazoglu", 0},
+		// words under four letters allow no typos
+		{"short word typo", "ali", "alo", 0},
+		{"short word transposed", "lai", "ali", 0},
+		{"no match", "mehmet", "ahmet", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchToken(tt.query, tt.token); math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("matchToken(%q, %q) = %v, want %v", tt.query, tt.token, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoreDriver(t *testing.T) {
+	driver := &domain.Driver{FirstName: "Şükrü", LastName: "Yılmaz", Plate: "34 ABC 123", CarBrand: "Fiat", CarModel: "Egea"}
+
+	tests := []struct {
+		query string
+		want  float64
+	}{
+		{"Şükrü", 1},
+		{"sukru", 1},
+		{"SUKRU YILMAZ", 1},
+		{"34abc", 0.925},
+		{"34 abc 123", 1},
+		{"sukru fiat", 0.8},
+		{"sukur", 0.5},
+		// every word must match
+		{"sukru mehmet", 0},
+		{"ali", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := scoreDriver(driver, domain.SearchTokens(tt.query)); math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("scoreDriver(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/log"
+	"github.com/hekanemre/taxihub/tracing"
+	"go.uber.org/zap"
+)
+
+const discardShiftTimeout = 3 * time.Second
+
+type StartShiftHandler struct {
+	repo   Repository
+	shifts ShiftRepository
+}
+
+type StartShiftRequest struct {
+	DriverID string `json:"-" validate:"required"`
+}
+
+type StartShiftResponse struct {
+	Shift *domain.Shift `json:"shift"`
+}
+
+func NewStartShiftHandler(repo Repository, shifts ShiftRepository) *StartShiftHandler {
+	return &StartShiftHandler{
+		repo:   repo,
+		shifts: shifts,
+	}
+}
+
+// StartShift godoc
+// @Summary      Start a shift
+// @Description  Clocks the driver in and puts them online.
+// @Tags         drivers
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Driver ID"
+// @Success      200  {object}  StartShiftResponse
+// @Failure 404 {object} ErrorResponse "Driver not found"
+// @Failure 409 {object} ErrorResponse "Driver already has an open shift"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /driver/{id}/shift/start [post]
+func (h *StartShiftHandler) Handle(ctx context.Context, req *StartShiftRequest) (*StartShiftResponse, error) {
+	ctx, span := tracing.Start(ctx, "StartShiftHandler.Handle")
+	defer span.End()
+
+	driver, err := h.repo.GetDriverByID(ctx, req.DriverID)
+	if err != nil {
+		return nil, err
+	}
+
+	if driver.OnShift() {
+		return nil, domain.ErrShiftAlreadyOpen
+	}
+
+	current := driver.CurrentStatus()
+	if !domain.CanTransitionDriver(current, domain.DriverOnline) {
+		return nil, domain.ErrInvalidDriverTransition
+	}
+
+	shift := &domain.Shift{
+		ID:        uuid.New().String(),
+		DriverID:  driver.ID,
+		StartedAt: time.Now(),
+		Breaks:    []domain.Break{},
+	}
+
+	if err := h.shifts.CreateShift(ctx, shift); err != nil {
+		return nil, err
+	}
+
+	// linking first makes a concurrent start fail before the driver goes online twice
+	if err := h.repo.SetCurrentShift(ctx, driver.ID, "", shift.ID); err != nil {
+		h.discardShift(ctx, shift, false)
+		return nil, err
+	}
+
+	if err := h.repo.UpdateDriverStatus(ctx, driver.ID, current, domain.DriverOnline); err != nil {
+		h.discardShift(ctx, shift, true)
+		return nil, err
+	}
+
+	return &StartShiftResponse{
+		Shift: shift,
+	}, nil
+}
+
+// discardShift undoes a start that failed half way, so no open shift is left behind without a driver
+// linked to it. It runs even if ctx is already done, the failure may well have been its deadline.
+func (h *StartShiftHandler) discardShift(ctx context.Context, shift *domain.Shift, linked bool) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), discardShiftTimeout)
+	defer cancel()
+
+	if linked {
+		if err := h.repo.SetCurrentShift(ctx, shift.DriverID, shift.ID, ""); err != nil {
+			log.FromContext(ctx).Error("Failed to unlink discarded shift", zap.String("shift_id", shift.ID), zap.Error(err))
+			return
+		}
+	}
+
+	if err := h.shifts.DeleteShift(ctx, shift.ID); err != nil {
+		log.FromContext(ctx).Error("Failed to delete discarded shift", zap.String("shift_id", shift.ID), zap.Error(err))
+	}
+}
@@ -0,0 +1,58 @@
+package application_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	application "github.com/hekanemre/taxihub/application/driver"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/infrastructure"
+)
+
+var errStatusWrite = errors.New("status write failed")
+
+// failingStatusRepository loses every status change, the shift is already created and linked by then
+type failingStatusRepository struct {
+	*infrastructure.MemoryRepository
+}
+
+func (r failingStatusRepository) UpdateDriverStatus(ctx context.Context, id string, from, to domain.DriverStatus) error {
+	return errStatusWrite
+}
+
+func TestStartShiftHandler_DiscardsShiftOnFailure(t *testing.T) {
+	ctx := context.Background()
+	repo := infrastructure.NewMemoryRepository(1000)
+	driver := &domain.Driver{ID: "d1", FirstName: "Ada", LastName: "Lovelace", Plate: "34AB123", TaxiType: "yellow", Version: 1}
+	if err := repo.CreateDriver(ctx, driver); err != nil {
+		t.Fatalf("CreateDriver: %v", err)
+	}
+
+	handler := application.NewStartShiftHandler(failingStatusRepository{repo}, repo)
+	if _, err := handler.Handle(ctx, &application.StartShiftRequest{DriverID: "d1"}); !errors.Is(err, errStatusWrite) {
+		t.Fatalf("Handle = %v, want the status write error", err)
+	}
+
+	stored, err := repo.GetDriverByID(ctx, "d1")
+	if err != nil {
+		t.Fatalf("GetDriverByID: %v", err)
+	}
+	if stored.OnShift() {
+		t.Fatalf("driver still linked to shift %s", stored.CurrentShiftID)
+	}
+
+	shifts, err := repo.GetShiftsByDriver(ctx, "d1", time.Time{}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("GetShiftsByDriver: %v", err)
+	}
+	if len(shifts) != 0 {
+		t.Fatalf("%d shifts left behind, want none", len(shifts))
+	}
+
+	// with the status write back the driver can start a shift again
+	if _, err := application.NewStartShiftHandler(repo, repo).Handle(ctx, &application.StartShiftRequest{DriverID: "d1"}); err != nil {
+		t.Fatalf("Handle after the failure: %v", err)
+	}
+}
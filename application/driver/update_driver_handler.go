@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
 )
 
 type UpdateDriverHandler struct {
@@ -12,14 +13,15 @@ type UpdateDriverHandler struct {
 }
 
 type UpdateDriverRequest struct {
-	ID        string          `bson:"id" json:"id"`
-	FirstName string          `bson:"firstName" json:"firstName"`
-	LastName  string          `bson:"lastName" json:"lastName"`
-	Plate     string          `bson:"plate" json:"plate"`
-	TaxiType  string          `bson:"taxiType" json:"taksiType"`
-	CarBrand  string          `bson:"carBrand" json:"carBrand"`
-	CarModel  string          `bson:"carModel" json:"carModel"`
+	ID        string          `bson:"id" json:"id" validate:"required"`
+	FirstName string          `bson:"firstName" json:"firstName" validate:"required,max=100"`
+	LastName  string          `bson:"lastName" json:"lastName" validate:"required,max=100"`
+	Plate     string          `bson:"plate" json:"plate" validate:"required,max=20"`
+	TaxiType  string          `bson:"taxiType" json:"taksiType" validate:"required,taxitype"`
+	CarBrand  string          `bson:"carBrand" json:"carBrand" validate:"max=50"`
+	CarModel  string          `bson:"carModel" json:"carModel" validate:"max=50"`
 	Location  domain.Location `bson:"location" json:"location"`
+	IfMatch   *int64          `bson:"-" json:"-"` // version from the If-Match header, nil skips the check
 }
 
 type UpdateDriverResponse struct {
@@ -34,30 +36,42 @@ func NewUpdateDriverHandler(repo Repository) *UpdateDriverHandler {
 
 // UpdateDriver godoc
 // @Summary      Update an existing driver
-// @Description  Updates the details of an existing driver.
+// @Description  Replaces the editable fields of a driver, status, shift and owner are kept. Send the ETag of the driver read as If-Match to not overwrite a change made since.
 // @Tags         drivers
 // @Accept       json
 // @Produce      json
-// @Param        driver  body      UpdateDriverRequest  true  "Driver update data"
+// @Param        driver    body      UpdateDriverRequest  true   "Driver update data"
+// @Param        If-Match  header    string               false  "ETag of the driver the update is based on"
 // @Success      200  {object}  UpdateDriverResponse
 // @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 404 {object} ErrorResponse "Driver not found"
+// @Failure 409 {object} ErrorResponse "A driver with the same plate exists"
+// @Failure 412 {object} ErrorResponse "Driver was changed since it was read"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router       /drivers/update [put]
 func (h *UpdateDriverHandler) Handle(ctx context.Context, req *UpdateDriverRequest) (*UpdateDriverResponse, error) {
-	driver := &domain.Driver{
-		ID:        req.ID,
-		FirstName: req.FirstName,
-		LastName:  req.LastName,
-		Plate:     req.Plate,
-		TaxiType:  req.TaxiType,
-		CarBrand:  req.CarBrand,
-		CarModel:  req.CarModel,
-		Location:  req.Location,
+	ctx, span := tracing.Start(ctx, "UpdateDriverHandler.Handle")
+	defer span.End()
+
+	driver, err := h.repo.GetDriverByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+	if req.IfMatch != nil && *req.IfMatch != driver.Version {
+		return nil, domain.ErrDriverVersionMismatch
 	}
 
+	driver.FirstName = req.FirstName
+	driver.LastName = req.LastName
+	driver.Plate = req.Plate
+	driver.TaxiType = req.TaxiType
+	driver.CarBrand = req.CarBrand
+	driver.CarModel = req.CarModel
+	driver.Location = req.Location
 	driver.UpdatedAt = time.Now()
-	err := h.repo.UpdateDriver(ctx, driver)
-	if err != nil {
+
+	// the version read above guards against a change made since
+	if err := h.repo.UpdateDriver(ctx, driver); err != nil {
 		return nil, err
 	}
 
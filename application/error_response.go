@@ -1,5 +1,10 @@
 package application
 
+import "github.com/hekanemre/taxihub/domain"
+
+// ErrorResponse is the body of every failed request
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Code   string              `json:"code"`             // stable and machine readable, e.g. DRIVER_NOT_FOUND
+	Error  string              `json:"error"`            // meant for humans, may change
+	Fields []domain.FieldError `json:"fields,omitempty"` // invalid fields on 422 responses
 }
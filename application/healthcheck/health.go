@@ -1,6 +1,11 @@
 package healthcheck
 
-import "context"
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/tracing"
+)
 
 type HealthCheckRequest struct {
 }
@@ -9,6 +14,7 @@ type HealthCheckResponse struct {
 	Status string `json:"status"`
 }
 
+// HealthCheckHandler answers liveness, it only tells the process is serving requests
 type HealthCheckHandler struct {
 }
 
@@ -17,5 +23,58 @@ func NewHealthCheckHandler() *HealthCheckHandler {
 }
 
 func (h *HealthCheckHandler) Handle(ctx context.Context, req *HealthCheckRequest) (*HealthCheckResponse, error) {
+	_, span := tracing.Start(ctx, "HealthCheckHandler.Handle")
+	defer span.End()
+
 	return &HealthCheckResponse{Status: "OK"}, nil
 }
+
+type ReadinessRequest struct {
+}
+
+type ReadinessResponse struct {
+	Status     string        `json:"status"` // UP only when every check is up
+	DurationMs float64       `json:"durationMs"`
+	Checks     []CheckResult `json:"checks"`
+}
+
+// ReadinessHandler answers readiness, it runs every registered check
+type ReadinessHandler struct {
+	registry *Registry
+}
+
+func NewReadinessHandler(registry *Registry) *ReadinessHandler {
+	return &ReadinessHandler{
+		registry: registry,
+	}
+}
+
+// Readiness godoc
+// @Summary      Readiness
+// @Description  Runs every registered dependency check, e.g. the Mongo ping and required indexes. Answers 503 when one is down. format=plain answers only the status as text.
+// @Tags         health
+// @Produce      json
+// @Param        format  query  string  false  "plain for a text body"
+// @Success      200  {object}  ReadinessResponse
+// @Failure      503  {object}  ReadinessResponse
+// @Router       /health/ready [get]
+func (h *ReadinessHandler) Handle(ctx context.Context, req *ReadinessRequest) (*ReadinessResponse, error) {
+	ctx, span := tracing.Start(ctx, "ReadinessHandler.Handle")
+	defer span.End()
+
+	started := time.Now()
+	checks := h.registry.Run(ctx)
+
+	res := &ReadinessResponse{
+		Status: StatusUp,
+		Checks: checks,
+	}
+	for _, check := range checks {
+		if check.Status != StatusUp {
+			res.Status = StatusDown
+		}
+	}
+	res.DurationMs = float64(time.Since(started).Microseconds()) / 1000
+
+	return res, nil
+}
@@ -0,0 +1,91 @@
+package healthcheck
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	StatusUp   = "UP"
+	StatusDown = "DOWN"
+)
+
+// Checker reports whether one dependency works, a nil error means healthy
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc lets a plain function be registered as a Checker
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+type CheckResult struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+type namedChecker struct {
+	name    string
+	checker Checker
+}
+
+// Registry holds the checks readiness depends on. Components register their own
+// checks at startup, every check gets timeout before it counts as down.
+type Registry struct {
+	timeout time.Duration
+
+	mu       sync.RWMutex
+	checkers []namedChecker
+}
+
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{
+		timeout: timeout,
+	}
+}
+
+func (r *Registry) Register(name string, checker Checker) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkers = append(r.checkers, namedChecker{name: name, checker: checker})
+}
+
+// Run runs every check in parallel and returns the results in registration order
+func (r *Registry) Run(ctx context.Context) []CheckResult {
+	r.mu.RLock()
+	checkers := append([]namedChecker(nil), r.checkers...)
+	r.mu.RUnlock()
+
+	results := make([]CheckResult, len(checkers))
+	var wg sync.WaitGroup
+	for i, c := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, r.timeout)
+			defer cancel()
+
+			started := time.Now()
+			err := c.checker.Check(checkCtx)
+			results[i] = CheckResult{
+				Name:      c.name,
+				Status:    StatusUp,
+				LatencyMs: float64(time.Since(started).Microseconds()) / 1000,
+			}
+			if err != nil {
+				results[i].Status = StatusDown
+				results[i].Error = err.Error()
+			}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
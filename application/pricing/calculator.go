@@ -0,0 +1,89 @@
+package pricing
+
+import (
+	"math"
+	"slices"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+)
+
+// Calendar decides when night and holiday multipliers apply
+type Calendar struct {
+	Location   *time.Location
+	NightStart int      // hour of day, inclusive
+	NightEnd   int      // hour of day, exclusive, may be smaller than NightStart
+	Holidays   []string // dates as 2006-01-02 in Location
+}
+
+func (c Calendar) IsNight(at time.Time) bool {
+	hour := at.In(c.Location).Hour()
+	if c.NightStart <= c.NightEnd {
+		return hour >= c.NightStart && hour < c.NightEnd
+	}
+	// window wraps around midnight, e.g. 22 to 6
+	return hour >= c.NightStart || hour < c.NightEnd
+}
+
+func (c Calendar) IsHoliday(at time.Time) bool {
+	return slices.Contains(c.Holidays, at.In(c.Location).Format(time.DateOnly))
+}
+
+type Quote struct {
+	TaxiType           string  `json:"taxiType"`
+	Currency           string  `json:"currency"`
+	DistanceKm         float64 `json:"distanceKm"`
+	DurationMinutes    float64 `json:"durationMinutes"`
+	BaseFare           float64 `json:"baseFare"`
+	DistanceFare       float64 `json:"distanceFare"`
+	TimeFare           float64 `json:"timeFare"`
+	Subtotal           float64 `json:"subtotal"`
+	NightMultiplier    float64 `json:"nightMultiplier"`
+	HolidayMultiplier  float64 `json:"holidayMultiplier"`
+	AppliedMultiplier  float64 `json:"appliedMultiplier"`
+	SurgeMultiplier    float64 `json:"surgeMultiplier"`
+	MinimumFareApplied bool    `json:"minimumFareApplied"`
+	Total              float64 `json:"total"`
+}
+
+// Calculate prices a trip with the given tariff.
+// Night and holiday multipliers do not stack, the higher one wins. Surge comes on top of it.
+func Calculate(tariff *domain.Tariff, calendar Calendar, distanceKm, durationMinutes float64, at time.Time, surgeMultiplier float64) *Quote {
+	quote := &Quote{
+		TaxiType:          tariff.TaxiType,
+		Currency:          tariff.Currency,
+		DistanceKm:        round(distanceKm),
+		DurationMinutes:   round(durationMinutes),
+		BaseFare:          round(tariff.BaseFare),
+		DistanceFare:      round(distanceKm * tariff.PerKm),
+		TimeFare:          round(durationMinutes * tariff.PerMinute),
+		NightMultiplier:   1,
+		HolidayMultiplier: 1,
+		SurgeMultiplier:   1,
+	}
+	quote.Subtotal = round(quote.BaseFare + quote.DistanceFare + quote.TimeFare)
+
+	if calendar.IsNight(at) && tariff.NightMultiplier > 0 {
+		quote.NightMultiplier = tariff.NightMultiplier
+	}
+	if calendar.IsHoliday(at) && tariff.HolidayMultiplier > 0 {
+		quote.HolidayMultiplier = tariff.HolidayMultiplier
+	}
+	quote.AppliedMultiplier = math.Max(quote.NightMultiplier, quote.HolidayMultiplier)
+
+	if surgeMultiplier > 1 {
+		quote.SurgeMultiplier = surgeMultiplier
+	}
+
+	quote.Total = round(quote.Subtotal * quote.AppliedMultiplier * quote.SurgeMultiplier)
+	if quote.Total < tariff.MinimumFare {
+		quote.Total = round(tariff.MinimumFare)
+		quote.MinimumFareApplied = true
+	}
+
+	return quote
+}
+
+func round(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,189 @@
+package pricing_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hekanemre/taxihub/application/pricing"
+	"github.com/hekanemre/taxihub/domain"
+)
+
+// Istanbul time, which has no daylight saving
+var istanbul = time.FixedZone("TRT", 3*60*60)
+
+func localTime(day, hour, minute int) time.Time {
+	return time.Date(2026, time.October, day, hour, minute, 0, 0, istanbul)
+}
+
+func TestCalendar_IsNight(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end int
+		hour       int
+		want       bool
+	}{
+		{"crossing midnight, before the start", 22, 6, 21, false},
+		{"crossing midnight, start is inclusive", 22, 6, 22, true},
+		{"crossing midnight, at midnight", 22, 6, 0, true},
+		{"crossing midnight, after midnight", 22, 6, 5, true},
+		{"crossing midnight, end is exclusive", 22, 6, 6, false},
+		{"crossing midnight, midday", 22, 6, 12, false},
+		{"same day, inside", 0, 6, 3, true},
+		{"same day, end is exclusive", 0, 6, 6, false},
+		{"same day, before the start", 1, 6, 0, false},
+		{"empty window", 0, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calendar := pricing.Calendar{Location: istanbul, NightStart: tt.start, NightEnd: tt.end}
+			if got := calendar.IsNight(localTime(20, tt.hour, 30)); got != tt.want {
+				t.Fatalf("IsNight at %02d:30 = %v, want %v", tt.hour, got, tt.want)
+			}
+		})
+	}
+
+	// hours are taken in the calendar's location, 20:30 UTC is 23:30 in Istanbul
+	calendar := pricing.Calendar{Location: istanbul, NightStart: 22, NightEnd: 6}
+	if !calendar.IsNight(time.Date(2026, time.October, 20, 20, 30, 0, 0, time.UTC)) {
+		t.Fatal("IsNight ignores the calendar's location")
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	tariff := &domain.Tariff{
+		TaxiType:          "yellow",
+		Currency:          "TRY",
+		BaseFare:          50,
+		PerKm:             20,
+		PerMinute:         2,
+		NightMultiplier:   1.5,
+		HolidayMultiplier: 2,
+		MinimumFare:       100,
+	}
+	calendar := pricing.Calendar{Location: istanbul, NightStart: 22, NightEnd: 6, Holidays: []string{"2026-10-29"}}
+
+	// 10 km in 20 minutes is 50 + 200 + 40
+	trip := pricing.Quote{
+		TaxiType:          "yellow",
+		Currency:          "TRY",
+		DistanceKm:        10,
+		DurationMinutes:   20,
+		BaseFare:          50,
+		DistanceFare:      200,
+		TimeFare:          40,
+		Subtotal:          290,
+		NightMultiplier:   1,
+		HolidayMultiplier: 1,
+		AppliedMultiplier: 1,
+		SurgeMultiplier:   1,
+	}
+	// 1 km in 2 minutes is 50 + 20 + 4
+	shortTrip := pricing.Quote{
+		TaxiType:          "yellow",
+		Currency:          "TRY",
+		DistanceKm:        1,
+		DurationMinutes:   2,
+		BaseFare:          50,
+		DistanceFare:      20,
+		TimeFare:          4,
+		Subtotal:          74,
+		NightMultiplier:   1,
+		HolidayMultiplier: 1,
+		AppliedMultiplier: 1,
+		SurgeMultiplier:   1,
+	}
+
+	tests := []struct {
+		name      string
+		distance  float64
+		duration  float64
+		at        time.Time
+		surge     float64
+		base      pricing.Quote
+		adjust    func(q *pricing.Quote)
+		wantTotal float64
+	}{
+		{
+			name: "day", distance: 10, duration: 20, at: localTime(20, 12, 0), surge: 1, base: trip,
+			adjust: func(q *pricing.Quote) {}, wantTotal: 290,
+		},
+		{
+			name: "night before midnight", distance: 10, duration: 20, at: localTime(20, 23, 0), surge: 1, base: trip,
+			adjust: func(q *pricing.Quote) { q.NightMultiplier, q.AppliedMultiplier = 1.5, 1.5 }, wantTotal: 435,
+		},
+		{
+			name: "night after midnight", distance: 10, duration: 20, at: localTime(21, 3, 0), surge: 1, base: trip,
+			adjust: func(q *pricing.Quote) { q.NightMultiplier, q.AppliedMultiplier = 1.5, 1.5 }, wantTotal: 435,
+		},
+		{
+			name: "night is over at its end hour", distance: 10, duration: 20, at: localTime(21, 6, 0), surge: 1, base: trip,
+			adjust: func(q *pricing.Quote) {}, wantTotal: 290,
+		},
+		{
+			name: "holiday", distance: 10, duration: 20, at: localTime(29, 12, 0), surge: 1, base: trip,
+			adjust: func(q *pricing.Quote) { q.HolidayMultiplier, q.AppliedMultiplier = 2, 2 }, wantTotal: 580,
+		},
+		{
+			// night and holiday do not stack, the higher one applies
+			name: "holiday night", distance: 10, duration: 20, at: localTime(29, 23, 0), surge: 1, base: trip,
+			adjust: func(q *pricing.Quote) {
+				q.NightMultiplier, q.HolidayMultiplier, q.AppliedMultiplier = 1.5, 2, 2
+			},
+			wantTotal: 580,
+		},
+		{
+			// the holiday is the day in Istanbul, 21:30 UTC on the 28th is already the 29th there
+			name: "holiday night in the calendar's location", distance: 10, duration: 20,
+			at: time.Date(2026, time.October, 28, 21, 30, 0, 0, time.UTC), surge: 1, base: trip,
+			adjust: func(q *pricing.Quote) {
+				q.NightMultiplier, q.HolidayMultiplier, q.AppliedMultiplier = 1.5, 2, 2
+			},
+			wantTotal: 580,
+		},
+		{
+			name: "surge on top of night", distance: 10, duration: 20, at: localTime(20, 23, 0), surge: 1.4, base: trip,
+			adjust: func(q *pricing.Quote) {
+				q.NightMultiplier, q.AppliedMultiplier, q.SurgeMultiplier = 1.5, 1.5, 1.4
+			},
+			wantTotal: 609,
+		},
+		{
+			name: "surge below 1 is ignored", distance: 10, duration: 20, at: localTime(20, 12, 0), surge: 0.8, base: trip,
+			adjust: func(q *pricing.Quote) {}, wantTotal: 290,
+		},
+		{
+			name: "minimum fare", distance: 1, duration: 2, at: localTime(20, 12, 0), surge: 1, base: shortTrip,
+			adjust: func(q *pricing.Quote) { q.MinimumFareApplied = true }, wantTotal: 100,
+		},
+		{
+			// the minimum is compared with the total after multipliers, 74 * 1.5 is 111
+			name: "multipliers lift the fare over the minimum", distance: 1, duration: 2, at: localTime(20, 23, 0), surge: 1, base: shortTrip,
+			adjust: func(q *pricing.Quote) { q.NightMultiplier, q.AppliedMultiplier = 1.5, 1.5 }, wantTotal: 111,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := tt.base
+			tt.adjust(&want)
+			want.Total = tt.wantTotal
+
+			got := pricing.Calculate(tariff, calendar, tt.distance, tt.duration, tt.at, tt.surge)
+			if !reflect.DeepEqual(*got, want) {
+				t.Fatalf("Calculate = %+v, want %+v", *got, want)
+			}
+		})
+	}
+}
+
+func TestCalculate_UnsetMultipliersCountAsOne(t *testing.T) {
+	tariff := &domain.Tariff{TaxiType: "yellow", Currency: "TRY", BaseFare: 100}
+	calendar := pricing.Calendar{Location: istanbul, NightStart: 22, NightEnd: 6, Holidays: []string{"2026-10-29"}}
+
+	quote := pricing.Calculate(tariff, calendar, 0, 0, localTime(29, 23, 0), 1)
+	if quote.AppliedMultiplier != 1 || quote.Total != 100 {
+		t.Fatalf("Calculate = %+v, want multiplier 1 and total 100", *quote)
+	}
+}
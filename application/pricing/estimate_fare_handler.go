@@ -0,0 +1,90 @@
+package pricing
+
+import (
+	"context"
+	"time"
+
+	driver "github.com/hekanemre/taxihub/application/driver"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+// Settings turn a straight line into an expected trip until we have real routing
+type Settings struct {
+	Calendar        Calendar
+	RouteFactor     float64 // road distance compared to the straight line
+	AverageSpeedKmh float64
+}
+
+// SurgeProvider gives the surge multiplier at the pickup point
+type SurgeProvider interface {
+	MultiplierAt(ctx context.Context, lat, lon float64) (float64, error)
+}
+
+type EstimateFareHandler struct {
+	repo     Repository
+	surge    SurgeProvider
+	settings Settings
+}
+
+type EstimateFareRequest struct {
+	TaxiType   string    `json:"taxiType" validate:"required,taxitype"`
+	PickupLat  float64   `json:"pickupLat" validate:"latitude"`
+	PickupLon  float64   `json:"pickupLon" validate:"longitude"`
+	DropoffLat float64   `json:"dropoffLat" validate:"latitude"`
+	DropoffLon float64   `json:"dropoffLon" validate:"longitude"`
+	At         time.Time `json:"at"` // departure time, defaults to now
+}
+
+type EstimateFareResponse struct {
+	Quote *Quote `json:"quote"`
+}
+
+func NewEstimateFareHandler(repo Repository, surge SurgeProvider, settings Settings) *EstimateFareHandler {
+	return &EstimateFareHandler{
+		repo:     repo,
+		surge:    surge,
+		settings: settings,
+	}
+}
+
+// EstimateFare godoc
+// @Summary      Estimate a fare
+// @Description  Returns an itemized fare quote between pickup and dropoff for a taxi type, including surge at the pickup.
+// @Tags         pricing
+// @Accept       json
+// @Produce      json
+// @Param        trip  body      EstimateFareRequest  true  "Trip to price"
+// @Success      200  {object}  EstimateFareResponse
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 404 {object} ErrorResponse "No tariff for taxi type"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /pricing/estimate [post]
+func (h *EstimateFareHandler) Handle(ctx context.Context, req *EstimateFareRequest) (*EstimateFareResponse, error) {
+	ctx, span := tracing.Start(ctx, "EstimateFareHandler.Handle")
+	defer span.End()
+
+	tariff, err := h.repo.GetTariff(ctx, req.TaxiType)
+	if err != nil {
+		return nil, err
+	}
+
+	at := req.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	distanceKm := driver.HaversineKm(req.PickupLat, req.PickupLon, req.DropoffLat, req.DropoffLon) * h.settings.RouteFactor
+	durationMinutes := 0.0
+	if h.settings.AverageSpeedKmh > 0 {
+		durationMinutes = distanceKm / h.settings.AverageSpeedKmh * 60
+	}
+
+	surgeMultiplier, err := h.surge.MultiplierAt(ctx, req.PickupLat, req.PickupLon)
+	if err != nil {
+		return nil, err
+	}
+
+	return &EstimateFareResponse{
+		Quote: Calculate(tariff, h.settings.Calendar, distanceKm, durationMinutes, at, surgeMultiplier),
+	}, nil
+}
@@ -0,0 +1,47 @@
+package pricing
+
+import (
+	"context"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type GetTariffsHandler struct {
+	repo Repository
+}
+
+type GetTariffsRequest struct {
+}
+
+type GetTariffsResponse struct {
+	Tariffs []*domain.Tariff `json:"tariffs"`
+}
+
+func NewGetTariffsHandler(repo Repository) *GetTariffsHandler {
+	return &GetTariffsHandler{
+		repo: repo,
+	}
+}
+
+// GetTariffs godoc
+// @Summary      List tariffs
+// @Description  Returns the current tariff of every taxi type.
+// @Tags         pricing
+// @Produce      json
+// @Success      200  {object}  GetTariffsResponse
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /pricing/tariffs [get]
+func (h *GetTariffsHandler) Handle(ctx context.Context, req *GetTariffsRequest) (*GetTariffsResponse, error) {
+	ctx, span := tracing.Start(ctx, "GetTariffsHandler.Handle")
+	defer span.End()
+
+	tariffs, err := h.repo.GetAllTariffs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetTariffsResponse{
+		Tariffs: tariffs,
+	}, nil
+}
@@ -0,0 +1,17 @@
+package pricing
+
+import (
+	"context"
+
+	"github.com/hekanemre/taxihub/domain"
+)
+
+// tariffs are kept in their own collection so finance can change them at runtime
+type Repository interface {
+	GetTariff(ctx context.Context, taxiType string) (*domain.Tariff, error)
+	GetAllTariffs(ctx context.Context) ([]*domain.Tariff, error)
+	// SaveTariff inserts or replaces the tariff of tariff.TaxiType
+	SaveTariff(ctx context.Context, tariff *domain.Tariff) error
+	// CreateTariffIfMissing only inserts, an existing tariff is left untouched
+	CreateTariffIfMissing(ctx context.Context, tariff *domain.Tariff) error
+}
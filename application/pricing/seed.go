@@ -0,0 +1,23 @@
+package pricing
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+)
+
+// SeedTariffs stores the configured tariffs for taxi types that have none yet.
+// Tariffs changed through the API are never overwritten by config.
+func SeedTariffs(ctx context.Context, repo Repository, tariffs []domain.Tariff) error {
+	for _, tariff := range tariffs {
+		if err := Validate(&tariff); err != nil {
+			return err
+		}
+		tariff.UpdatedAt = time.Now()
+		if err := repo.CreateTariffIfMissing(ctx, &tariff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package pricing
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+var ErrInvalidTariff = domain.NewValidationError("INVALID_TARIFF", "tariff amounts must not be negative and multipliers must be at least 1")
+
+type UpdateTariffHandler struct {
+	repo Repository
+}
+
+type UpdateTariffRequest struct {
+	Tariff domain.Tariff
+}
+
+type UpdateTariffResponse struct {
+	Tariff *domain.Tariff `json:"tariff"`
+}
+
+func NewUpdateTariffHandler(repo Repository) *UpdateTariffHandler {
+	return &UpdateTariffHandler{
+		repo: repo,
+	}
+}
+
+// UpdateTariff godoc
+// @Summary      Create or replace a tariff
+// @Description  Sets the tariff of a taxi type, it applies to the next estimate without a redeploy.
+// @Tags         pricing
+// @Accept       json
+// @Produce      json
+// @Param        taxiType  path      string         true  "Type of taxi"
+// @Param        tariff    body      domain.Tariff  true  "Tariff"
+// @Success      200  {object}  UpdateTariffResponse
+// @Failure 400 {object} ErrorResponse "Invalid tariff"
+// @Failure 403 {object} ErrorResponse "Only admins may change tariffs"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /pricing/tariffs/{taxiType} [put]
+func (h *UpdateTariffHandler) Handle(ctx context.Context, req *UpdateTariffRequest) (*UpdateTariffResponse, error) {
+	ctx, span := tracing.Start(ctx, "UpdateTariffHandler.Handle")
+	defer span.End()
+
+	tariff := req.Tariff
+	if err := Validate(&tariff); err != nil {
+		return nil, err
+	}
+
+	tariff.UpdatedAt = time.Now()
+	if err := h.repo.SaveTariff(ctx, &tariff); err != nil {
+		return nil, err
+	}
+
+	return &UpdateTariffResponse{
+		Tariff: &tariff,
+	}, nil
+}
+
+// Validate fills neutral multipliers and rejects amounts that make no sense
+func Validate(tariff *domain.Tariff) error {
+	if tariff.NightMultiplier == 0 {
+		tariff.NightMultiplier = 1
+	}
+	if tariff.HolidayMultiplier == 0 {
+		tariff.HolidayMultiplier = 1
+	}
+
+	if tariff.TaxiType == "" ||
+		tariff.BaseFare < 0 || tariff.PerKm < 0 || tariff.PerMinute < 0 || tariff.MinimumFare < 0 ||
+		tariff.NightMultiplier < 1 || tariff.HolidayMultiplier < 1 {
+		return ErrInvalidTariff
+	}
+	return nil
+}
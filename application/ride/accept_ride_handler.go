@@ -0,0 +1,79 @@
+package ride
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type AcceptRideHandler struct {
+	repo    Repository
+	drivers DriverRepository
+}
+
+type AcceptRideRequest struct {
+	ID     string `json:"-" validate:"required"`
+	UserID string `json:"-" validate:"required"` // account of the driver, taken from the token
+}
+
+type AcceptRideResponse struct {
+	Ride *domain.Ride `json:"ride"`
+}
+
+func NewAcceptRideHandler(repo Repository, drivers DriverRepository) *AcceptRideHandler {
+	return &AcceptRideHandler{
+		repo:    repo,
+		drivers: drivers,
+	}
+}
+
+// AcceptRide godoc
+// @Summary      Accept a ride offer
+// @Description  The offered driver accepts the ride before the offer times out.
+// @Tags         rides
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string             true  "Ride ID"
+// @Success      200  {object}  AcceptRideResponse
+// @Failure 403 {object} ErrorResponse "Caller is not a driver"
+// @Failure 404 {object} ErrorResponse "Ride not found"
+// @Failure 409 {object} ErrorResponse "Ride is not offered to this driver, offer expired or driver is not online"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /ride/{id}/accept [post]
+func (h *AcceptRideHandler) Handle(ctx context.Context, req *AcceptRideRequest) (*AcceptRideResponse, error) {
+	ctx, span := tracing.Start(ctx, "AcceptRideHandler.Handle")
+	defer span.End()
+
+	driverID, err := driverOf(ctx, h.drivers, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	ride, err := h.repo.GetRideByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := ride.Accept(driverID, now); err != nil {
+		return nil, err
+	}
+
+	// the driver has to be online, this also keeps them from taking two rides at once
+	if err := h.drivers.UpdateDriverStatus(ctx, driverID, domain.DriverOnline, domain.DriverOnTrip); err != nil {
+		return nil, err
+	}
+
+	ride.UpdatedAt = now
+	if err := h.repo.UpdateRide(ctx, ride); err != nil {
+		// give the driver back, the ride was not taken
+		revertDriverStatus(ctx, h.drivers, driverID, domain.DriverOnTrip, domain.DriverOnline)
+		return nil, err
+	}
+
+	return &AcceptRideResponse{
+		Ride: ride,
+	}, nil
+}
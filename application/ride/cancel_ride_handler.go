@@ -0,0 +1,79 @@
+package ride
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type CancelRideHandler struct {
+	repo    Repository
+	drivers DriverRepository
+}
+
+type CancelRideRequest struct {
+	ID     string `json:"-" validate:"required"`
+	Reason string `json:"reason" validate:"max=200"`
+}
+
+type CancelRideResponse struct {
+	Ride *domain.Ride `json:"ride"`
+}
+
+func NewCancelRideHandler(repo Repository, drivers DriverRepository) *CancelRideHandler {
+	return &CancelRideHandler{
+		repo:    repo,
+		drivers: drivers,
+	}
+}
+
+// CancelRide godoc
+// @Summary      Cancel a ride
+// @Description  Cancels a ride that has not started yet.
+// @Tags         rides
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string             true  "Ride ID"
+// @Param        reason  body      CancelRideRequest  false "Cancellation reason"
+// @Success      200  {object}  CancelRideResponse
+// @Failure 404 {object} ErrorResponse "Ride not found"
+// @Failure 409 {object} ErrorResponse "Ride can no longer be cancelled"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /ride/{id}/cancel [post]
+func (h *CancelRideHandler) Handle(ctx context.Context, req *CancelRideRequest) (*CancelRideResponse, error) {
+	ctx, span := tracing.Start(ctx, "CancelRideHandler.Handle")
+	defer span.End()
+
+	ride, err := h.repo.GetRideByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := ride.Cancel(req.Reason, now); err != nil {
+		return nil, err
+	}
+
+	// an accepted ride keeps its driver busy, free them first so a failed release never leaves
+	// a cancelled ride behind
+	if ride.DriverID != "" {
+		if err := h.drivers.UpdateDriverStatus(ctx, ride.DriverID, domain.DriverOnTrip, domain.DriverOnline); err != nil {
+			return nil, err
+		}
+	}
+
+	ride.UpdatedAt = now
+	if err := h.repo.UpdateRide(ctx, ride); err != nil {
+		if ride.DriverID != "" {
+			// the ride was not cancelled, the driver is still on it
+			revertDriverStatus(ctx, h.drivers, ride.DriverID, domain.DriverOnline, domain.DriverOnTrip)
+		}
+		return nil, err
+	}
+
+	return &CancelRideResponse{
+		Ride: ride,
+	}, nil
+}
@@ -0,0 +1,71 @@
+package ride
+
+import (
+	"context"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type DeclineRideHandler struct {
+	repo       Repository
+	drivers    DriverRepository
+	dispatcher *Dispatcher
+}
+
+type DeclineRideRequest struct {
+	ID     string `json:"-" validate:"required"`
+	UserID string `json:"-" validate:"required"` // account of the driver, taken from the token
+}
+
+type DeclineRideResponse struct {
+	Ride *domain.Ride `json:"ride"`
+}
+
+func NewDeclineRideHandler(repo Repository, drivers DriverRepository, dispatcher *Dispatcher) *DeclineRideHandler {
+	return &DeclineRideHandler{
+		repo:       repo,
+		drivers:    drivers,
+		dispatcher: dispatcher,
+	}
+}
+
+// DeclineRide godoc
+// @Summary      Decline a ride offer
+// @Description  The offered driver declines the ride, it is offered to the next closest driver.
+// @Tags         rides
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string              true  "Ride ID"
+// @Success      200  {object}  DeclineRideResponse
+// @Failure 403 {object} ErrorResponse "Caller is not a driver"
+// @Failure 404 {object} ErrorResponse "Ride not found"
+// @Failure 409 {object} ErrorResponse "Ride is not offered to this driver"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /ride/{id}/decline [post]
+func (h *DeclineRideHandler) Handle(ctx context.Context, req *DeclineRideRequest) (*DeclineRideResponse, error) {
+	ctx, span := tracing.Start(ctx, "DeclineRideHandler.Handle")
+	defer span.End()
+
+	driverID, err := driverOf(ctx, h.drivers, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	ride, err := h.repo.GetRideByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ride.Decline(driverID); err != nil {
+		return nil, err
+	}
+
+	if err := h.dispatcher.OfferNext(ctx, ride); err != nil {
+		return nil, err
+	}
+
+	return &DeclineRideResponse{
+		Ride: ride,
+	}, nil
+}
@@ -0,0 +1,132 @@
+package ride
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	driver "github.com/hekanemre/taxihub/application/driver"
+	"github.com/hekanemre/taxihub/domain"
+	"go.uber.org/zap"
+)
+
+const NoDriverReason = "no available driver"
+
+// Dispatcher offers rides to the closest free driver, one driver at a time.
+// When a driver declines or lets the offer time out, the next closest one is asked.
+type Dispatcher struct {
+	rides        Repository
+	drivers      DriverRepository
+	offerTimeout time.Duration
+}
+
+func NewDispatcher(rides Repository, drivers DriverRepository, offerTimeout time.Duration) *Dispatcher {
+	return &Dispatcher{
+		rides:        rides,
+		drivers:      drivers,
+		offerTimeout: offerTimeout,
+	}
+}
+
+// OfferNext offers the ride to the closest available driver that has not declined it yet.
+// If nobody is left the ride gets cancelled. The ride is saved in both cases.
+func (d *Dispatcher) OfferNext(ctx context.Context, ride *domain.Ride) error {
+	driverID, err := d.findDriver(ctx, ride)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	if driverID == "" {
+		if err := ride.Cancel(NoDriverReason, now); err != nil {
+			return err
+		}
+	} else if err := ride.Offer(driverID, now.Add(d.offerTimeout)); err != nil {
+		return err
+	}
+
+	ride.UpdatedAt = now
+	return d.rides.UpdateRide(ctx, ride)
+}
+
+func (d *Dispatcher) findDriver(ctx context.Context, ride *domain.Ride) (string, error) {
+	lat, lon := ride.Pickup.Coordinates[1], ride.Pickup.Coordinates[0]
+
+	candidates, err := d.drivers.GetAllDriversNearby(ctx, lat, lon, ride.TaxiType, true)
+	if err != nil {
+		return "", err
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return distanceKm(lat, lon, candidates[i]) < distanceKm(lat, lon, candidates[j])
+	})
+
+	for _, candidate := range candidates {
+		if slices.Contains(ride.DeclinedDriverIDs, candidate.ID) {
+			continue
+		}
+
+		busy, err := d.rides.HasActiveRide(ctx, candidate.ID)
+		if err != nil {
+			return "", err
+		}
+		if !busy {
+			return candidate.ID, nil
+		}
+	}
+
+	return "", nil
+}
+
+// ExpireOffers moves every timed out offer on to the next driver
+func (d *Dispatcher) ExpireOffers(ctx context.Context) error {
+	rides, err := d.rides.GetRidesWithExpiredOffers(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	for _, ride := range rides {
+		expiredDriverID := ride.OfferedDriverID
+		if err := ride.Decline(expiredDriverID); err != nil {
+			return err
+		}
+
+		if err := d.OfferNext(ctx, ride); err != nil {
+			// a driver may have accepted in the meantime, the next tick will see the fresh state
+			zap.L().Warn("Failed to re-offer expired ride", zap.String("ride_id", ride.ID), zap.Error(err))
+			continue
+		}
+
+		zap.L().Info("Ride offer expired",
+			zap.String("ride_id", ride.ID),
+			zap.String("expired_driver_id", expiredDriverID),
+			zap.String("next_driver_id", ride.OfferedDriverID))
+	}
+
+	return nil
+}
+
+// Run checks for expired offers every interval until ctx is done
+func (d *Dispatcher) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := d.ExpireOffers(ctx); err != nil {
+				zap.L().Error("Failed to expire ride offers", zap.Error(err))
+			}
+		}
+	}
+}
+
+func distanceKm(lat, lon float64, d *domain.Driver) float64 {
+	if len(d.Location.Coordinates) != 2 {
+		return 0
+	}
+	return driver.HaversineKm(lat, lon, d.Location.Coordinates[1], d.Location.Coordinates[0])
+}
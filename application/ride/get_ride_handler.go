@@ -0,0 +1,51 @@
+package ride
+
+import (
+	"context"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type GetRideHandler struct {
+	repo Repository
+}
+
+type GetRideRequest struct {
+	ID string `json:"id" validate:"required"`
+}
+
+type GetRideResponse struct {
+	Ride *domain.Ride `json:"ride"`
+}
+
+func NewGetRideHandler(repo Repository) *GetRideHandler {
+	return &GetRideHandler{
+		repo: repo,
+	}
+}
+
+// GetRide godoc
+// @Summary      Get ride by ID
+// @Description  Retrieves a ride with its current state.
+// @Tags         rides
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Ride ID"
+// @Success      200  {object}  GetRideResponse
+// @Failure 404 {object} ErrorResponse "Ride not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /ride/{id} [get]
+func (h *GetRideHandler) Handle(ctx context.Context, req *GetRideRequest) (*GetRideResponse, error) {
+	ctx, span := tracing.Start(ctx, "GetRideHandler.Handle")
+	defer span.End()
+
+	ride, err := h.repo.GetRideByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetRideResponse{
+		Ride: ride,
+	}, nil
+}
@@ -0,0 +1,70 @@
+package ride
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/log"
+	"go.uber.org/zap"
+)
+
+const revertDriverTimeout = 3 * time.Second
+
+var (
+	// ErrRideConflict is returned by UpdateRide when the stored version moved on
+	ErrRideConflict = domain.NewConflictError("RIDE_CONFLICT", "ride was modified concurrently")
+	// ErrNotADriver is returned for driver actions of an account no driver is linked to
+	ErrNotADriver = domain.NewForbiddenError("NOT_A_DRIVER", "no driver is linked to this account")
+)
+
+// same dependency inversion as the driver package, rides don't know about Mongo
+type Repository interface {
+	CreateRide(ctx context.Context, ride *domain.Ride) error
+	// UpdateRide writes the ride only if the stored version matches ride.Version, then bumps it
+	UpdateRide(ctx context.Context, ride *domain.Ride) error
+	GetRideByID(ctx context.Context, id string) (*domain.Ride, error)
+	// HasActiveRide reports whether the driver is on a ride or holds a pending offer
+	HasActiveRide(ctx context.Context, driverID string) (bool, error)
+	GetRidesWithExpiredOffers(ctx context.Context, now time.Time) ([]*domain.Ride, error)
+	// GetRidesRequestedSince returns every ride requested at or after since, whatever its status
+	GetRidesRequestedSince(ctx context.Context, since time.Time) ([]*domain.Ride, error)
+}
+
+// DriverRepository is the part of the driver repository rides need
+type DriverRepository interface {
+	GetDriverByUserID(ctx context.Context, userID string) (*domain.Driver, error)
+	GetAllDriversNearby(ctx context.Context, lat, lon float64, taxiType string, onlyAvailable bool) ([]*domain.Driver, error)
+	UpdateDriverStatus(ctx context.Context, id string, from, to domain.DriverStatus) error
+}
+
+// driverOf returns the id of the driver the account drives as.
+// Driver actions are always taken for the caller's own driver, never for one named in the request.
+func driverOf(ctx context.Context, drivers DriverRepository, userID string) (string, error) {
+	driver, err := drivers.GetDriverByUserID(ctx, userID)
+	if errors.Is(err, domain.ErrDriverNotFound) {
+		return "", ErrNotADriver
+	}
+	if err != nil {
+		return "", err
+	}
+	return driver.ID, nil
+}
+
+// revertDriverStatus moves the driver back after the ride write that went with the status change failed.
+// It runs even if ctx is already done, the failure may well have been its deadline. A failed revert
+// is only logged, the caller reports the ride write error.
+func revertDriverStatus(ctx context.Context, drivers DriverRepository, driverID string, from, to domain.DriverStatus) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), revertDriverTimeout)
+	defer cancel()
+
+	if err := drivers.UpdateDriverStatus(ctx, driverID, from, to); err != nil {
+		log.FromContext(ctx).Error("Failed to revert driver status",
+			zap.String("driver_id", driverID),
+			zap.String("from", string(from)),
+			zap.String("to", string(to)),
+			zap.Error(err),
+		)
+	}
+}
@@ -0,0 +1,78 @@
+package ride
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type RequestRideHandler struct {
+	repo       Repository
+	dispatcher *Dispatcher
+}
+
+type RequestRideRequest struct {
+	PassengerID string  `json:"-" validate:"required"`
+	TaxiType    string  `json:"taxiType" validate:"required,taxitype"`
+	PickupLat   float64 `json:"pickupLat" validate:"latitude"`
+	PickupLon   float64 `json:"pickupLon" validate:"longitude"`
+	DropoffLat  float64 `json:"dropoffLat" validate:"latitude"`
+	DropoffLon  float64 `json:"dropoffLon" validate:"longitude"`
+}
+
+type RequestRideResponse struct {
+	Ride *domain.Ride `json:"ride"`
+}
+
+func NewRequestRideHandler(repo Repository, dispatcher *Dispatcher) *RequestRideHandler {
+	return &RequestRideHandler{
+		repo:       repo,
+		dispatcher: dispatcher,
+	}
+}
+
+// RequestRide godoc
+// @Summary      Request a ride
+// @Description  Creates a ride and offers it to the closest available driver of the requested taxi type.
+// @Tags         rides
+// @Accept       json
+// @Produce      json
+// @Param        ride  body      RequestRideRequest  true  "Ride request data"
+// @Success      201  {object}  RequestRideResponse
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /ride/request [post]
+func (h *RequestRideHandler) Handle(ctx context.Context, req *RequestRideRequest) (*RequestRideResponse, error) {
+	ctx, span := tracing.Start(ctx, "RequestRideHandler.Handle")
+	defer span.End()
+
+	now := time.Now()
+
+	ride := &domain.Ride{
+		ID:          uuid.New().String(),
+		PassengerID: req.PassengerID,
+		TaxiType:    req.TaxiType,
+		// GeoJSON keeps coordinates as [lon, lat]
+		Pickup:            domain.Location{Type: "Point", Coordinates: []float64{req.PickupLon, req.PickupLat}},
+		Dropoff:           domain.Location{Type: "Point", Coordinates: []float64{req.DropoffLon, req.DropoffLat}},
+		Status:            domain.RideRequested,
+		DeclinedDriverIDs: []string{},
+		CreatedAt:         now,
+		UpdatedAt:         now,
+	}
+
+	if err := h.repo.CreateRide(ctx, ride); err != nil {
+		return nil, err
+	}
+
+	if err := h.dispatcher.OfferNext(ctx, ride); err != nil {
+		return nil, err
+	}
+
+	return &RequestRideResponse{
+		Ride: ride,
+	}, nil
+}
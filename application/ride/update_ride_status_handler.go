@@ -0,0 +1,96 @@
+package ride
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type UpdateRideStatusHandler struct {
+	repo    Repository
+	drivers DriverRepository
+}
+
+type UpdateRideStatusRequest struct {
+	ID     string            `json:"-" validate:"required"`
+	UserID string            `json:"-" validate:"required"`                                   // account of the driver, taken from the token
+	Status domain.RideStatus `json:"status" validate:"oneof=AT_PICKUP IN_PROGRESS COMPLETED"` // AT_PICKUP, IN_PROGRESS or COMPLETED
+}
+
+type UpdateRideStatusResponse struct {
+	Ride *domain.Ride `json:"ride"`
+}
+
+func NewUpdateRideStatusHandler(repo Repository, drivers DriverRepository) *UpdateRideStatusHandler {
+	return &UpdateRideStatusHandler{
+		repo:    repo,
+		drivers: drivers,
+	}
+}
+
+// UpdateRideStatus godoc
+// @Summary      Move a ride forward
+// @Description  The assigned driver reports arrival at pickup, start or completion of the ride.
+// @Tags         rides
+// @Accept       json
+// @Produce      json
+// @Param        id      path      string                   true  "Ride ID"
+// @Param        status  body      UpdateRideStatusRequest  true  "New ride status"
+// @Success      200  {object}  UpdateRideStatusResponse
+// @Failure 403 {object} ErrorResponse "Caller is not a driver"
+// @Failure 404 {object} ErrorResponse "Ride not found"
+// @Failure 409 {object} ErrorResponse "Invalid ride state transition or ride is not assigned to this driver"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /ride/{id}/status [put]
+func (h *UpdateRideStatusHandler) Handle(ctx context.Context, req *UpdateRideStatusRequest) (*UpdateRideStatusResponse, error) {
+	ctx, span := tracing.Start(ctx, "UpdateRideStatusHandler.Handle")
+	defer span.End()
+
+	driverID, err := driverOf(ctx, h.drivers, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	ride, err := h.repo.GetRideByID(ctx, req.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	switch req.Status {
+	case domain.RideAtPickup:
+		err = ride.ArriveAtPickup(driverID, now)
+	case domain.RideInProgress:
+		err = ride.Start(driverID, now)
+	case domain.RideCompleted:
+		err = ride.Complete(driverID, now)
+	default:
+		err = domain.ErrInvalidRideTransition
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// a completed ride frees the driver first, so a failed release never leaves a completed ride behind
+	completed := ride.Status == domain.RideCompleted
+	if completed {
+		if err := h.drivers.UpdateDriverStatus(ctx, ride.DriverID, domain.DriverOnTrip, domain.DriverOnline); err != nil {
+			return nil, err
+		}
+	}
+
+	ride.UpdatedAt = now
+	if err := h.repo.UpdateRide(ctx, ride); err != nil {
+		if completed {
+			// the ride is still going, so is the driver
+			revertDriverStatus(ctx, h.drivers, ride.DriverID, domain.DriverOnline, domain.DriverOnTrip)
+		}
+		return nil, err
+	}
+
+	return &UpdateRideStatusResponse{
+		Ride: ride,
+	}, nil
+}
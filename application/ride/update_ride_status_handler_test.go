@@ -0,0 +1,90 @@
+package ride_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hekanemre/taxihub/application/ride"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/infrastructure"
+)
+
+// conflictingRideRepository loses every ride write to a concurrent change
+type conflictingRideRepository struct {
+	*infrastructure.MemoryRepository
+}
+
+func (r conflictingRideRepository) UpdateRide(ctx context.Context, rd *domain.Ride) error {
+	return ride.ErrRideConflict
+}
+
+// newDriverOnRide stores driver d1 of account u1 on the trip of ride r1
+func newDriverOnRide(t *testing.T, status domain.RideStatus) *infrastructure.MemoryRepository {
+	t.Helper()
+	ctx := context.Background()
+	repo := infrastructure.NewMemoryRepository(1000)
+
+	driver := &domain.Driver{ID: "d1", UserID: "u1", Plate: "34AB123", TaxiType: "yellow", Status: domain.DriverOnTrip, CurrentShiftID: "s1", Version: 1}
+	if err := repo.CreateDriver(ctx, driver); err != nil {
+		t.Fatalf("CreateDriver: %v", err)
+	}
+	if err := repo.CreateRide(ctx, &domain.Ride{ID: "r1", PassengerID: "p1", DriverID: "d1", TaxiType: "yellow", Status: status}); err != nil {
+		t.Fatalf("CreateRide: %v", err)
+	}
+	return repo
+}
+
+func assertDriverStatus(t *testing.T, repo *infrastructure.MemoryRepository, want domain.DriverStatus) {
+	t.Helper()
+	driver, err := repo.GetDriverByID(context.Background(), "d1")
+	if err != nil {
+		t.Fatalf("GetDriverByID: %v", err)
+	}
+	if driver.CurrentStatus() != want {
+		t.Fatalf("driver status = %s, want %s", driver.CurrentStatus(), want)
+	}
+}
+
+func TestUpdateRideStatusHandler_Complete(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("frees the driver", func(t *testing.T) {
+		repo := newDriverOnRide(t, domain.RideInProgress)
+		res, err := ride.NewUpdateRideStatusHandler(repo, repo).Handle(ctx, &ride.UpdateRideStatusRequest{ID: "r1", UserID: "u1", Status: domain.RideCompleted})
+		if err != nil {
+			t.Fatalf("Handle: %v", err)
+		}
+		if res.Ride.Status != domain.RideCompleted {
+			t.Fatalf("ride status = %s, want %s", res.Ride.Status, domain.RideCompleted)
+		}
+		assertDriverStatus(t, repo, domain.DriverOnline)
+	})
+
+	t.Run("keeps the driver on the trip when the ride write fails", func(t *testing.T) {
+		repo := newDriverOnRide(t, domain.RideInProgress)
+		_, err := ride.NewUpdateRideStatusHandler(conflictingRideRepository{repo}, repo).Handle(ctx, &ride.UpdateRideStatusRequest{ID: "r1", UserID: "u1", Status: domain.RideCompleted})
+		if !errors.Is(err, ride.ErrRideConflict) {
+			t.Fatalf("Handle = %v, want ErrRideConflict", err)
+		}
+		assertDriverStatus(t, repo, domain.DriverOnTrip)
+	})
+}
+
+func TestCancelRideHandler_KeepsDriverWhenRideWriteFails(t *testing.T) {
+	repo := newDriverOnRide(t, domain.RideAccepted)
+
+	_, err := ride.NewCancelRideHandler(conflictingRideRepository{repo}, repo).Handle(context.Background(), &ride.CancelRideRequest{ID: "r1"})
+	if !errors.Is(err, ride.ErrRideConflict) {
+		t.Fatalf("Handle = %v, want ErrRideConflict", err)
+	}
+	assertDriverStatus(t, repo, domain.DriverOnTrip)
+
+	stored, err := repo.GetRideByID(context.Background(), "r1")
+	if err != nil {
+		t.Fatalf("GetRideByID: %v", err)
+	}
+	if stored.Status != domain.RideAccepted {
+		t.Fatalf("ride status = %s, want %s", stored.Status, domain.RideAccepted)
+	}
+}
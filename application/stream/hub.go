@@ -0,0 +1,154 @@
+package stream
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hekanemre/taxihub/domain"
+	"go.uber.org/zap"
+)
+
+// driverState is the last known view of a driver, used to filter events by type and area
+type driverState struct {
+	taxiType string
+	location *domain.Location
+	status   domain.DriverStatus
+}
+
+// Hub fans driver events out to live subscribers.
+// Publishers only ever do a non-blocking send into the inbound queue, a slow
+// subscriber loses events and is disconnected instead of holding anybody up.
+type Hub struct {
+	inbound    chan domain.DriverEvent
+	bufferSize int
+	maxDropped int
+
+	mu          sync.Mutex
+	subscribers map[*Subscription]struct{}
+
+	drivers        map[string]*driverState // only touched by Run, removed drivers are forgotten
+	droppedInbound atomic.Int64
+}
+
+func NewHub(bufferSize, maxDropped int) *Hub {
+	return &Hub{
+		inbound:     make(chan domain.DriverEvent, bufferSize*4),
+		bufferSize:  bufferSize,
+		maxDropped:  maxDropped,
+		subscribers: make(map[*Subscription]struct{}),
+		drivers:     make(map[string]*driverState),
+	}
+}
+
+// Publish queues an event without blocking, if the hub is behind the event is lost
+func (h *Hub) Publish(event domain.DriverEvent) {
+	select {
+	case h.inbound <- event:
+	default:
+		if h.droppedInbound.Add(1)%1000 == 1 {
+			zap.L().Warn("Driver event hub is full, dropping events", zap.Int64("dropped_total", h.droppedInbound.Load()))
+		}
+	}
+}
+
+func (h *Hub) Subscribe(filter Filter) *Subscription {
+	events := make(chan domain.DriverEvent, h.bufferSize)
+	sub := &Subscription{
+		Events: events,
+		events: events,
+		filter: filter,
+	}
+
+	h.mu.Lock()
+	h.subscribers[sub] = struct{}{}
+	h.mu.Unlock()
+
+	return sub
+}
+
+// Unsubscribe is safe to call for subscriptions the hub already dropped
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.subscribers[sub]; ok {
+		delete(h.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Run delivers queued events until ctx is done, then closes every subscription
+func (h *Hub) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			h.mu.Lock()
+			for sub := range h.subscribers {
+				delete(h.subscribers, sub)
+				close(sub.events)
+			}
+			h.mu.Unlock()
+			return
+		case event := <-h.inbound:
+			previous := h.drivers[event.DriverID]
+			var previousLocation *domain.Location
+			if previous != nil {
+				previousLocation = previous.location
+			}
+			h.broadcast(h.enrich(event), previousLocation)
+			if event.Type == domain.DriverRemoved {
+				delete(h.drivers, event.DriverID)
+			}
+		}
+	}
+}
+
+// enrich completes the event from the last known driver state and keeps that state current.
+// It never blocks, whatever the hub does not know yet comes with the publisher's events.
+func (h *Hub) enrich(event domain.DriverEvent) domain.DriverEvent {
+	state, ok := h.drivers[event.DriverID]
+	if !ok {
+		state = &driverState{}
+		h.drivers[event.DriverID] = state
+	}
+
+	if event.TaxiType != "" {
+		state.taxiType = event.TaxiType
+	}
+	if event.Location != nil {
+		state.location = event.Location
+	}
+	if event.Status != "" {
+		state.status = event.Status
+	}
+
+	event.TaxiType = state.taxiType
+	event.Location = state.location
+	event.Status = state.status
+	return event
+}
+
+// broadcast also tells subscribers about drivers leaving their area, so maps can remove them
+func (h *Hub) broadcast(event domain.DriverEvent, previousLocation *domain.Location) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for sub := range h.subscribers {
+		if !sub.filter.Matches(event) && !sub.filter.MatchesLocation(event.TaxiType, previousLocation) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+			sub.dropped = 0
+		default:
+			sub.dropped++
+			if sub.dropped >= h.maxDropped {
+				zap.L().Warn("Dropping slow driver stream subscriber", zap.Int("dropped_events", sub.dropped))
+				delete(h.subscribers, sub)
+				close(sub.events)
+			}
+		}
+	}
+}
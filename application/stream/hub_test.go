@@ -0,0 +1,54 @@
+package stream
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+)
+
+func receive(t *testing.T, sub *Subscription) (domain.DriverEvent, bool) {
+	t.Helper()
+
+	select {
+	case event := <-sub.Events:
+		return event, true
+	case <-time.After(100 * time.Millisecond):
+		return domain.DriverEvent{}, false
+	}
+}
+
+func TestHub_RemovedDriverIsForgotten(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub(16, 16)
+	go hub.Run(ctx)
+	sub := hub.Subscribe(Filter{TaxiType: "yellow"})
+
+	location := domain.Location{Type: "Point", Coordinates: []float64{29, 41}}
+	hub.Publish(domain.DriverEvent{Type: domain.DriverLocationChanged, DriverID: "d1", TaxiType: "yellow", Location: &location})
+	if _, ok := receive(t, sub); !ok {
+		t.Fatal("location event not delivered")
+	}
+
+	// a status event only names the driver, the hub fills in the taxi type it knows
+	hub.Publish(domain.DriverEvent{Type: domain.DriverStatusChanged, DriverID: "d1", Status: domain.DriverOnline})
+	event, ok := receive(t, sub)
+	if !ok || event.TaxiType != "yellow" {
+		t.Fatalf("status event = %+v, %v, want it enriched with yellow", event, ok)
+	}
+
+	hub.Publish(domain.DriverEvent{Type: domain.DriverRemoved, DriverID: "d1"})
+	event, ok = receive(t, sub)
+	if !ok || event.Type != domain.DriverRemoved {
+		t.Fatalf("removed event = %+v, %v, want it delivered", event, ok)
+	}
+
+	// the state is gone, a bare event no longer matches the taxi type filter
+	hub.Publish(domain.DriverEvent{Type: domain.DriverStatusChanged, DriverID: "d1", Status: domain.DriverOnline})
+	if event, ok := receive(t, sub); ok {
+		t.Fatalf("event %+v delivered for a removed driver", event)
+	}
+}
@@ -0,0 +1,49 @@
+package stream
+
+import "github.com/hekanemre/taxihub/domain"
+
+// BoundingBox limits a subscription to a map area, in degrees
+type BoundingBox struct {
+	MinLat float64
+	MinLon float64
+	MaxLat float64
+	MaxLon float64
+}
+
+func (b *BoundingBox) Contains(location *domain.Location) bool {
+	if location == nil || len(location.Coordinates) != 2 {
+		return false
+	}
+	// GeoJSON keeps coordinates as [lon, lat]
+	lon, lat := location.Coordinates[0], location.Coordinates[1]
+	return lat >= b.MinLat && lat <= b.MaxLat && lon >= b.MinLon && lon <= b.MaxLon
+}
+
+// Filter picks the events a subscriber wants, zero values match everything
+type Filter struct {
+	TaxiType string
+	BBox     *BoundingBox
+}
+
+func (f Filter) Matches(event domain.DriverEvent) bool {
+	return f.MatchesLocation(event.TaxiType, event.Location)
+}
+
+func (f Filter) MatchesLocation(taxiType string, location *domain.Location) bool {
+	if f.TaxiType != "" && f.TaxiType != taxiType {
+		return false
+	}
+	if f.BBox != nil && !f.BBox.Contains(location) {
+		return false
+	}
+	return true
+}
+
+// Subscription delivers matching events on Events.
+// The channel is closed when the subscriber is dropped for being too slow or unsubscribes.
+type Subscription struct {
+	Events  <-chan domain.DriverEvent
+	events  chan domain.DriverEvent
+	filter  Filter
+	dropped int // consecutive events that did not fit into the buffer
+}
@@ -0,0 +1,234 @@
+package surge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hekanemre/taxihub/application/ride"
+	"github.com/hekanemre/taxihub/domain"
+	"go.uber.org/zap"
+)
+
+type Settings struct {
+	Precision     int           // geohash length of a cell
+	Window        time.Duration // how far back unserved ride requests count as demand
+	Threshold     float64       // demand per driver that starts surging
+	Sensitivity   float64       // multiplier added per demand/supply unit above threshold
+	MaxMultiplier float64
+	Step          float64 // multipliers are rounded to this, avoids flapping between tiny changes
+}
+
+// Engine recomputes surge per cell from live supply and demand
+type Engine struct {
+	cells    Repository
+	history  HistoryRepository
+	drivers  DriverSource
+	rides    RideSource
+	settings Settings
+
+	mu           sync.Mutex
+	recomputeErr error // result of the last scheduled recompute, reported by Check
+}
+
+func NewEngine(cells Repository, history HistoryRepository, drivers DriverSource, rides RideSource, settings Settings) *Engine {
+	return &Engine{
+		cells:    cells,
+		history:  history,
+		drivers:  drivers,
+		rides:    rides,
+		settings: settings,
+	}
+}
+
+// Multiplier maps supply and demand of a cell to a capped surge multiplier
+func (e *Engine) Multiplier(supply, demand int) float64 {
+	ratio := float64(demand) / math.Max(float64(supply), 1)
+	if ratio <= e.settings.Threshold {
+		return 1
+	}
+
+	multiplier := 1 + (ratio-e.settings.Threshold)*e.settings.Sensitivity
+	if e.settings.Step > 0 {
+		multiplier = math.Round(multiplier/e.settings.Step) * e.settings.Step
+		multiplier = math.Round(multiplier*100) / 100 // keep float noise out of the stored value
+	}
+	return math.Min(multiplier, e.settings.MaxMultiplier)
+}
+
+// Cell returns the cell id of a point
+func (e *Engine) Cell(lat, lon float64) string {
+	return Encode(lat, lon, e.settings.Precision)
+}
+
+// MultiplierAt is the current multiplier at a point, 1 where nothing is known
+func (e *Engine) MultiplierAt(ctx context.Context, lat, lon float64) (float64, error) {
+	cell, err := e.cells.GetSurgeCell(ctx, e.Cell(lat, lon))
+	if errors.Is(err, domain.ErrSurgeCellNotFound) {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return cell.Multiplier, nil
+}
+
+// Recompute counts supply and demand per cell and stores every surging cell that changed.
+// Cells back at 1 are deleted, so only surging cells are kept. Multiplier changes are also appended to the history.
+func (e *Engine) Recompute(ctx context.Context) error {
+	now := time.Now()
+
+	drivers, err := e.drivers.GetAvailableDrivers(ctx)
+	if err != nil {
+		return err
+	}
+
+	rides, err := e.rides.GetRidesRequestedSince(ctx, now.Add(-e.settings.Window))
+	if err != nil {
+		return err
+	}
+
+	type counts struct{ supply, demand int }
+	perCell := make(map[string]*counts)
+	countFor := func(id string) *counts {
+		if perCell[id] == nil {
+			perCell[id] = &counts{}
+		}
+		return perCell[id]
+	}
+
+	for _, driver := range drivers {
+		if len(driver.Location.Coordinates) == 2 {
+			countFor(e.Cell(driver.Location.Coordinates[1], driver.Location.Coordinates[0])).supply++
+		}
+	}
+	for _, rd := range rides {
+		if !isDemand(rd) {
+			continue
+		}
+		if len(rd.Pickup.Coordinates) == 2 {
+			countFor(e.Cell(rd.Pickup.Coordinates[1], rd.Pickup.Coordinates[0])).demand++
+		}
+	}
+
+	existing, err := e.cells.GetAllSurgeCells(ctx)
+	if err != nil {
+		return err
+	}
+
+	// cells that went quiet are reset, not left surging
+	current := make(map[string]*domain.SurgeCell, len(existing))
+	for _, cell := range existing {
+		current[cell.ID] = cell
+		countFor(cell.ID)
+	}
+
+	var changedCells []*domain.SurgeCell
+	var calmCells []string
+	var changes []*domain.SurgeChange
+	for id, c := range perCell {
+		multiplier := e.Multiplier(c.supply, c.demand)
+
+		old, known := current[id]
+		switch {
+		case multiplier == 1:
+			// a missing cell reads as 1, keeping it would only grow the collection
+			if known {
+				calmCells = append(calmCells, id)
+			}
+		case known && old.Multiplier == multiplier && old.Supply == c.supply && old.Demand == c.demand:
+			continue
+		default:
+			changedCells = append(changedCells, &domain.SurgeCell{
+				ID:         id,
+				Multiplier: multiplier,
+				Supply:     c.supply,
+				Demand:     c.demand,
+				UpdatedAt:  now,
+			})
+		}
+
+		oldMultiplier := 1.0
+		if known {
+			oldMultiplier = old.Multiplier
+		}
+		if oldMultiplier != multiplier {
+			changes = append(changes, &domain.SurgeChange{
+				ID:            uuid.New().String(),
+				CellID:        id,
+				OldMultiplier: oldMultiplier,
+				NewMultiplier: multiplier,
+				Supply:        c.supply,
+				Demand:        c.demand,
+				ChangedAt:     now,
+			})
+		}
+	}
+
+	// history first, a cell must never change without its audit record
+	if len(changes) > 0 {
+		if err := e.history.AppendSurgeChanges(ctx, changes); err != nil {
+			return err
+		}
+	}
+
+	if len(changedCells) > 0 {
+		if err := e.cells.SaveSurgeCells(ctx, changedCells); err != nil {
+			return err
+		}
+	}
+
+	if len(calmCells) > 0 {
+		if err := e.cells.DeleteSurgeCells(ctx, calmCells); err != nil {
+			return err
+		}
+	}
+
+	if len(changes) > 0 {
+		zap.L().Info("Surge multipliers changed", zap.Int("cells", len(changes)))
+	}
+	return nil
+}
+
+// isDemand reports whether the ride is still waiting for a driver, or was dropped because nobody was free.
+// Rides that were cancelled for lack of drivers are exactly the demand surge should react to.
+func isDemand(rd *domain.Ride) bool {
+	return rd.Status == domain.RideRequested ||
+		(rd.Status == domain.RideCancelled && rd.CancelReason == ride.NoDriverReason)
+}
+
+// Run recomputes surge every interval until ctx is done
+func (e *Engine) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := e.Recompute(ctx)
+			if err != nil {
+				zap.L().Error("Failed to recompute surge", zap.Error(err))
+			}
+			e.mu.Lock()
+			e.recomputeErr = err
+			e.mu.Unlock()
+		}
+	}
+}
+
+// Check fails while the last scheduled recompute failed, multipliers are stale meanwhile
+func (e *Engine) Check(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.recomputeErr != nil {
+		return fmt.Errorf("last recompute failed: %w", e.recomputeErr)
+	}
+	return nil
+}
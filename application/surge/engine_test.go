@@ -0,0 +1,91 @@
+package surge_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hekanemre/taxihub/application/surge"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/infrastructure"
+)
+
+// fakeMarket is the supply and demand a recompute sees
+type fakeMarket struct {
+	drivers []*domain.Driver
+	rides   []*domain.Ride
+}
+
+func (m *fakeMarket) GetAvailableDrivers(ctx context.Context) ([]*domain.Driver, error) {
+	return m.drivers, nil
+}
+
+func (m *fakeMarket) GetRidesRequestedSince(ctx context.Context, since time.Time) ([]*domain.Ride, error) {
+	return m.rides, nil
+}
+
+func requestedRide(lat, lon float64) *domain.Ride {
+	return &domain.Ride{
+		Status:    domain.RideRequested,
+		Pickup:    domain.Location{Type: "Point", Coordinates: []float64{lon, lat}},
+		CreatedAt: time.Now(),
+	}
+}
+
+func TestEngine_RecomputeDropsCalmCells(t *testing.T) {
+	ctx := context.Background()
+	repo := infrastructure.NewMemoryRepository(1000)
+	market := &fakeMarket{}
+	engine := surge.NewEngine(repo, repo, market, market, surge.Settings{
+		Precision:     6,
+		Window:        10 * time.Minute,
+		Threshold:     1,
+		Sensitivity:   0.5,
+		MaxMultiplier: 2.5,
+		Step:          0.1,
+	})
+	cell := engine.Cell(41, 29)
+
+	// three open requests and nobody to serve them
+	market.rides = []*domain.Ride{requestedRide(41, 29), requestedRide(41, 29), requestedRide(41, 29)}
+	if err := engine.Recompute(ctx); err != nil {
+		t.Fatalf("Recompute: %v", err)
+	}
+	stored, err := repo.GetSurgeCell(ctx, cell)
+	if err != nil {
+		t.Fatalf("GetSurgeCell of the surging cell: %v", err)
+	}
+	if stored.Multiplier != 2 {
+		t.Fatalf("multiplier = %v, want 2", stored.Multiplier)
+	}
+
+	// demand is gone, the cell is back at 1 and forgotten
+	market.rides = nil
+	if err := engine.Recompute(ctx); err != nil {
+		t.Fatalf("Recompute: %v", err)
+	}
+	if _, err := repo.GetSurgeCell(ctx, cell); !errors.Is(err, domain.ErrSurgeCellNotFound) {
+		t.Fatalf("GetSurgeCell of the calm cell = %v, want ErrSurgeCellNotFound", err)
+	}
+	if multiplier, err := engine.MultiplierAt(ctx, 41, 29); err != nil || multiplier != 1 {
+		t.Fatalf("MultiplierAt = %v, %v, want 1", multiplier, err)
+	}
+
+	history, err := repo.GetSurgeHistory(ctx, cell, time.Time{}, time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("GetSurgeHistory: %v", err)
+	}
+	if len(history) != 2 || history[0].NewMultiplier != 1 || history[0].OldMultiplier != 2 {
+		t.Fatalf("history = %d changes, want the surge and its end recorded", len(history))
+	}
+
+	// a cell with drivers and no surge is never stored
+	market.drivers = []*domain.Driver{{ID: "d1", Location: domain.Location{Type: "Point", Coordinates: []float64{29, 41}}}}
+	if err := engine.Recompute(ctx); err != nil {
+		t.Fatalf("Recompute: %v", err)
+	}
+	if cells, _ := repo.GetAllSurgeCells(ctx); len(cells) != 0 {
+		t.Fatalf("%d cells stored, want none", len(cells))
+	}
+}
@@ -0,0 +1,81 @@
+package surge
+
+import "strings"
+
+const base32 = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// Encode returns the geohash of a point with the given number of characters
+func Encode(lat, lon float64, precision int) string {
+	minLat, maxLat := -90.0, 90.0
+	minLon, maxLon := -180.0, 180.0
+
+	var hash strings.Builder
+	bit, ch := 0, 0
+	evenBit := true // geohash starts with longitude
+
+	for hash.Len() < precision {
+		if evenBit {
+			mid := (minLon + maxLon) / 2
+			if lon >= mid {
+				ch = ch<<1 | 1
+				minLon = mid
+			} else {
+				ch = ch << 1
+				maxLon = mid
+			}
+		} else {
+			mid := (minLat + maxLat) / 2
+			if lat >= mid {
+				ch = ch<<1 | 1
+				minLat = mid
+			} else {
+				ch = ch << 1
+				maxLat = mid
+			}
+		}
+		evenBit = !evenBit
+
+		bit++
+		if bit == 5 {
+			hash.WriteByte(base32[ch])
+			bit, ch = 0, 0
+		}
+	}
+
+	return hash.String()
+}
+
+// Bounds returns the box a geohash covers, ok is false for invalid hashes
+func Bounds(hash string) (minLat, minLon, maxLat, maxLon float64, ok bool) {
+	minLat, maxLat = -90.0, 90.0
+	minLon, maxLon = -180.0, 180.0
+	evenBit := true
+
+	for _, c := range hash {
+		idx := strings.IndexRune(base32, c)
+		if idx < 0 {
+			return 0, 0, 0, 0, false
+		}
+		for n := 4; n >= 0; n-- {
+			bitSet := idx>>n&1 == 1
+			if evenBit {
+				mid := (minLon + maxLon) / 2
+				if bitSet {
+					minLon = mid
+				} else {
+					maxLon = mid
+				}
+			} else {
+				mid := (minLat + maxLat) / 2
+				if bitSet {
+					minLat = mid
+				} else {
+					maxLat = mid
+				}
+			}
+			evenBit = !evenBit
+		}
+	}
+
+	return minLat, minLon, maxLat, maxLon, true
+}
@@ -0,0 +1,77 @@
+package surge
+
+import (
+	"context"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type GetHeatmapHandler struct {
+	cells Repository
+}
+
+type GetHeatmapRequest struct {
+	OnlySurging bool `query:"onlySurging"`
+}
+
+type HeatmapCell struct {
+	*domain.SurgeCell
+	MinLat float64 `json:"minLat"`
+	MinLon float64 `json:"minLon"`
+	MaxLat float64 `json:"maxLat"`
+	MaxLon float64 `json:"maxLon"`
+}
+
+type GetHeatmapResponse struct {
+	Cells []*HeatmapCell `json:"cells"`
+}
+
+func NewGetHeatmapHandler(cells Repository) *GetHeatmapHandler {
+	return &GetHeatmapHandler{
+		cells: cells,
+	}
+}
+
+// GetHeatmap godoc
+// @Summary      Surge heatmap
+// @Description  Returns every surging cell with its bounds, multiplier, supply and demand. Cells not listed are at 1.
+// @Tags         surge
+// @Produce      json
+// @Param        onlySurging  query     bool  false  "Only cells with a multiplier above 1"
+// @Success      200  {object}  GetHeatmapResponse
+// @Failure 403 {object} ErrorResponse "Only drivers, dispatchers and admins may see surge"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /surge/heatmap [get]
+func (h *GetHeatmapHandler) Handle(ctx context.Context, req *GetHeatmapRequest) (*GetHeatmapResponse, error) {
+	ctx, span := tracing.Start(ctx, "GetHeatmapHandler.Handle")
+	defer span.End()
+
+	cells, err := h.cells.GetAllSurgeCells(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &GetHeatmapResponse{
+		Cells: []*HeatmapCell{},
+	}
+	for _, cell := range cells {
+		if req.OnlySurging && cell.Multiplier <= 1 {
+			continue
+		}
+
+		minLat, minLon, maxLat, maxLon, ok := Bounds(cell.ID)
+		if !ok {
+			continue
+		}
+		res.Cells = append(res.Cells, &HeatmapCell{
+			SurgeCell: cell,
+			MinLat:    minLat,
+			MinLon:    minLon,
+			MaxLat:    maxLat,
+			MaxLon:    maxLon,
+		})
+	}
+
+	return res, nil
+}
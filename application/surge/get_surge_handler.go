@@ -0,0 +1,68 @@
+package surge
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+type GetSurgeHandler struct {
+	engine *Engine
+	cells  Repository
+}
+
+type GetSurgeRequest struct {
+	Lat float64 `query:"lat" validate:"latitude"`
+	Lon float64 `query:"lon" validate:"longitude"`
+}
+
+type GetSurgeResponse struct {
+	Cell       string  `json:"cell"`
+	Multiplier float64 `json:"multiplier"`
+	Supply     int     `json:"supply"`
+	Demand     int     `json:"demand"`
+}
+
+func NewGetSurgeHandler(engine *Engine, cells Repository) *GetSurgeHandler {
+	return &GetSurgeHandler{
+		engine: engine,
+		cells:  cells,
+	}
+}
+
+// GetSurge godoc
+// @Summary      Get surge at a point
+// @Description  Returns the current surge multiplier of the cell containing the point.
+// @Tags         surge
+// @Produce      json
+// @Param        lat  query     float64  true  "Latitude"
+// @Param        lon  query     float64  true  "Longitude"
+// @Success      200  {object}  GetSurgeResponse
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 403 {object} ErrorResponse "Only drivers, dispatchers and admins may see surge"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /surge [get]
+func (h *GetSurgeHandler) Handle(ctx context.Context, req *GetSurgeRequest) (*GetSurgeResponse, error) {
+	ctx, span := tracing.Start(ctx, "GetSurgeHandler.Handle")
+	defer span.End()
+
+	id := h.engine.Cell(req.Lat, req.Lon)
+
+	cell, err := h.cells.GetSurgeCell(ctx, id)
+	if errors.Is(err, domain.ErrSurgeCellNotFound) {
+		// no surge in this cell, supply and demand are only kept for surging cells
+		return &GetSurgeResponse{Cell: id, Multiplier: 1}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &GetSurgeResponse{
+		Cell:       cell.ID,
+		Multiplier: cell.Multiplier,
+		Supply:     cell.Supply,
+		Demand:     cell.Demand,
+	}, nil
+}
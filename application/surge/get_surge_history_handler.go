@@ -0,0 +1,68 @@
+package surge
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/tracing"
+)
+
+const MaxHistoryLimit = 1000
+
+type GetSurgeHistoryHandler struct {
+	history HistoryRepository
+}
+
+type GetSurgeHistoryRequest struct {
+	Cell  string    `query:"cell"`
+	From  time.Time `query:"from"`
+	To    time.Time `query:"to" validate:"gtfield=From"`
+	Limit int       `query:"limit" validate:"min=0,max=1000"`
+}
+
+type GetSurgeHistoryResponse struct {
+	Changes []*domain.SurgeChange `json:"changes"`
+}
+
+func NewGetSurgeHistoryHandler(history HistoryRepository) *GetSurgeHistoryHandler {
+	return &GetSurgeHistoryHandler{
+		history: history,
+	}
+}
+
+// GetSurgeHistory godoc
+// @Summary      Surge change history
+// @Description  Lists multiplier changes, newest first, for auditing.
+// @Tags         surge
+// @Produce      json
+// @Param        cell   query     string  false  "Geohash cell, all cells when empty"
+// @Param        from   query     string  false  "Range start (RFC3339), defaults to 24 hours ago"
+// @Param        to     query     string  false  "Range end (RFC3339), defaults to now"
+// @Param        limit  query     int     false  "Maximum number of changes" default(100)
+// @Success      200  {object}  GetSurgeHistoryResponse
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 403 {object} ErrorResponse "Only dispatchers and admins may see the history"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router       /surge/history [get]
+func (h *GetSurgeHistoryHandler) Handle(ctx context.Context, req *GetSurgeHistoryRequest) (*GetSurgeHistoryResponse, error) {
+	ctx, span := tracing.Start(ctx, "GetSurgeHistoryHandler.Handle")
+	defer span.End()
+
+	limit := req.Limit
+	if limit <= 0 || limit > MaxHistoryLimit {
+		limit = MaxHistoryLimit
+	}
+
+	changes, err := h.history.GetSurgeHistory(ctx, req.Cell, req.From, req.To, limit)
+	if err != nil {
+		return nil, err
+	}
+	if changes == nil {
+		changes = []*domain.SurgeChange{}
+	}
+
+	return &GetSurgeHistoryResponse{
+		Changes: changes,
+	}, nil
+}
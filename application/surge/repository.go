@@ -0,0 +1,31 @@
+package surge
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+)
+
+// current multipliers, one document per surging cell, a cell back at 1 is deleted
+type Repository interface {
+	SaveSurgeCells(ctx context.Context, cells []*domain.SurgeCell) error
+	DeleteSurgeCells(ctx context.Context, ids []string) error
+	GetSurgeCell(ctx context.Context, id string) (*domain.SurgeCell, error)
+	GetAllSurgeCells(ctx context.Context) ([]*domain.SurgeCell, error)
+}
+
+// append only, changes are never updated or removed
+type HistoryRepository interface {
+	AppendSurgeChanges(ctx context.Context, changes []*domain.SurgeChange) error
+	// GetSurgeHistory returns changes in [from, to), newest first, an empty cellID means all cells
+	GetSurgeHistory(ctx context.Context, cellID string, from, to time.Time, limit int) ([]*domain.SurgeChange, error)
+}
+
+type DriverSource interface {
+	GetAvailableDrivers(ctx context.Context) ([]*domain.Driver, error)
+}
+
+type RideSource interface {
+	GetRidesRequestedSince(ctx context.Context, since time.Time) ([]*domain.Ride, error)
+}
@@ -0,0 +1,152 @@
+package validation
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/hekanemre/taxihub/domain"
+)
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+
+	// report fields the way clients send them
+	v.RegisterTagNameFunc(fieldName)
+
+	v.RegisterValidation("taxitype", func(fl validator.FieldLevel) bool {
+		return domain.IsTaxiType(fl.Field().String())
+	})
+	// GeoJSON points are [longitude, latitude]
+	v.RegisterValidation("lonlat", func(fl validator.FieldLevel) bool {
+		coordinates, ok := fl.Field().Interface().([]float64)
+		return ok && len(coordinates) == 2 &&
+			coordinates[0] >= -180 && coordinates[0] <= 180 &&
+			coordinates[1] >= -90 && coordinates[1] <= 90
+	})
+
+	return v
+}
+
+// fieldName takes the json, query or params name of a field, falling back to the Go name.
+// It must never return "-", the validator skips such fields.
+func fieldName(field reflect.StructField) string {
+	for _, tag := range []string{"json", "query", "params"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+
+	return lowerFirst(field.Name)
+}
+
+func lowerFirst(s string) string {
+	runes := []rune(s)
+	if len(runes) > 0 {
+		runes[0] = unicode.ToLower(runes[0])
+	}
+	return string(runes)
+}
+
+// FieldRules is implemented by requests with rules spanning several fields that tags can not
+// express, like comparing two optional fields only when both are given
+type FieldRules interface {
+	ValidateFields() []domain.FieldError
+}
+
+// Struct checks the validate tags of s and returns ErrValidationFailed listing every invalid field.
+// If s implements FieldRules, the fields it reports are listed too.
+func Struct(s any) error {
+	var fields []domain.FieldError
+
+	err := validate.Struct(s)
+	if err != nil {
+		var validationErrs validator.ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			return err
+		}
+
+		for _, fe := range validationErrs {
+			fields = append(fields, domain.FieldError{
+				Field:   fieldPath(fe),
+				Rule:    fe.Tag(),
+				Message: message(fe),
+			})
+		}
+	}
+
+	if rules, ok := s.(FieldRules); ok {
+		fields = append(fields, rules.ValidateFields()...)
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	if err != nil {
+		return domain.ErrValidationFailed.WithFields(fields).Wrap(err)
+	}
+	return domain.ErrValidationFailed.WithFields(fields)
+}
+
+// fieldPath drops the struct name, CreateDriverRequest.location.coordinates becomes location.coordinates
+func fieldPath(fe validator.FieldError) string {
+	_, path, found := strings.Cut(fe.Namespace(), ".")
+	if !found {
+		return fe.Field()
+	}
+	return path
+}
+
+func message(fe validator.FieldError) string {
+	kind := fe.Kind()
+	sized := kind == reflect.String || kind == reflect.Slice || kind == reflect.Map
+	unit := "characters"
+	if kind != reflect.String {
+		unit = "items"
+	}
+
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "taxitype":
+		return "must be one of " + strings.Join(domain.TaxiTypes, ", ")
+	case "oneof":
+		return "must be one of " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "eq":
+		return "must be " + fe.Param()
+	case "email":
+		return "must be a valid email address"
+	case "latitude":
+		return "must be a latitude between -90 and 90"
+	case "longitude":
+		return "must be a longitude between -180 and 180"
+	case "lonlat":
+		return "must be [longitude, latitude] with longitude between -180 and 180 and latitude between -90 and 90"
+	case "len":
+		if sized {
+			return fmt.Sprintf("must have exactly %s %s", fe.Param(), unit)
+		}
+		return "must be " + fe.Param()
+	case "min", "gte":
+		if sized {
+			return fmt.Sprintf("must have at least %s %s", fe.Param(), unit)
+		}
+		return "must be at least " + fe.Param()
+	case "max", "lte":
+		if sized {
+			return fmt.Sprintf("must have at most %s %s", fe.Param(), unit)
+		}
+		return "must be at most " + fe.Param()
+	case "gtfield":
+		return "must be after " + lowerFirst(fe.Param())
+	case "gtefield":
+		return "must not be before " + lowerFirst(fe.Param())
+	}
+	return "must satisfy " + fe.Tag()
+}
@@ -7,16 +7,148 @@ import (
 	"github.com/spf13/viper"
 )
 
+// TariffConfig is a default tariff, seeded into the tariffs collection when missing
+type TariffConfig struct {
+	TaxiType          string  `mapstructure:"taxiType"`
+	Currency          string  `mapstructure:"currency"`
+	BaseFare          float64 `mapstructure:"baseFare"`
+	PerKm             float64 `mapstructure:"perKm"`
+	PerMinute         float64 `mapstructure:"perMinute"`
+	NightMultiplier   float64 `mapstructure:"nightMultiplier"`
+	HolidayMultiplier float64 `mapstructure:"holidayMultiplier"`
+	MinimumFare       float64 `mapstructure:"minimumFare"`
+}
+
+// JWTKeyConfig is one signing or verification key.
+// HS* keys take a secret, RS*/ES* keys take PEM encoded keys, each either inline, from a file or from an env variable.
+// A key with only a public key can verify tokens but never sign them, that is how old keys are retired.
+type JWTKeyConfig struct {
+	Kid            string `mapstructure:"kid"`
+	Algorithm      string `mapstructure:"algorithm"`
+	Secret         string `mapstructure:"secret"`
+	SecretEnv      string `mapstructure:"secretEnv"`
+	PrivateKeyFile string `mapstructure:"privateKeyFile"`
+	PrivateKeyEnv  string `mapstructure:"privateKeyEnv"`
+	PublicKeyFile  string `mapstructure:"publicKeyFile"`
+	PublicKeyEnv   string `mapstructure:"publicKeyEnv"`
+}
+
+// MongoConfig configures the one client every repository shares
+type MongoConfig struct {
+	Host                   string        `mapstructure:"host"`
+	DBName                 string        `mapstructure:"dbname"`
+	MaxPoolSize            uint64        `mapstructure:"maxPoolSize"`
+	MinPoolSize            uint64        `mapstructure:"minPoolSize"`
+	MaxConnIdleTime        time.Duration `mapstructure:"maxConnIdleTime"`
+	ConnectTimeout         time.Duration `mapstructure:"connectTimeout"`
+	ServerSelectionTimeout time.Duration `mapstructure:"serverSelectionTimeout"`
+	ReadConcern            string        `mapstructure:"readConcern"`    // local, majority, ...; empty keeps the server default
+	WriteConcern           string        `mapstructure:"writeConcern"`   // majority or a node count; empty keeps the server default
+	Journal                bool          `mapstructure:"journal"`        // writes wait for the journal
+	ReadPreference         string        `mapstructure:"readPreference"` // primary, secondaryPreferred, ...
+}
+
+// LogConfig sets what the global logger writes and how
+type LogConfig struct {
+	Level  string `mapstructure:"level"`  // debug, info, warn or error
+	Format string `mapstructure:"format"` // json for log shippers, console for reading in a terminal
+}
+
+// TracingConfig selects where OpenTelemetry spans are exported
+type TracingConfig struct {
+	Enabled     bool    `mapstructure:"enabled"`
+	ServiceName string  `mapstructure:"serviceName"`
+	Exporter    string  `mapstructure:"exporter"`    // stdout, file or otlp
+	FilePath    string  `mapstructure:"filePath"`    // file exporter only
+	Endpoint    string  `mapstructure:"endpoint"`    // otlp exporter only, host:port of the collector's HTTP receiver
+	Insecure    bool    `mapstructure:"insecure"`    // otlp without TLS
+	SampleRatio float64 `mapstructure:"sampleRatio"` // share of new traces recorded, incoming sampled traces are always kept
+}
+
+// RateLimit is a token bucket: Requests per Per on average, with bursts of up to Burst.
+// Zero Requests disables the limit.
+type RateLimit struct {
+	Requests int           `mapstructure:"requests"`
+	Per      time.Duration `mapstructure:"per"`
+	Burst    int           `mapstructure:"burst"` // defaults to Requests
+}
+
+// RateLimitGroup limits one group of routes per client IP and per authenticated user
+type RateLimitGroup struct {
+	PerIP   RateLimit `mapstructure:"perIP"`
+	PerUser RateLimit `mapstructure:"perUser"`
+}
+
+type RateLimitConfig struct {
+	Enabled bool                      `mapstructure:"enabled"`
+	Store   string                    `mapstructure:"store"` // memory or mongo
+	Groups  map[string]RateLimitGroup `mapstructure:"groups"`
+}
+
 type AppConfig struct {
-	Port    string `mapstructure:"port"`
-	MongoDB struct {
-		Host   string `mapstructure:"host"`
-		DBName string `mapstructure:"dbname"`
-	} `mapstructure:"mongodb"`
-	IdleTimeout    time.Duration `mapstructure:"idleTimeout"`
-	ReadTimeout    time.Duration `mapstructure:"readTimeout"`
-	WriteTimeout   time.Duration `mapstructure:"writeTimeout"`
-	NearbyDistance int           `mapstructure:"nearbyDistance"`
+	Port            string          `mapstructure:"port"`
+	Repository      string          `mapstructure:"repository"` // mongo or memory
+	Log             LogConfig       `mapstructure:"log"`
+	MongoDB         MongoConfig     `mapstructure:"mongodb"`
+	Tracing         TracingConfig   `mapstructure:"tracing"`
+	RateLimit       RateLimitConfig `mapstructure:"rateLimit"`
+	IdleTimeout     time.Duration   `mapstructure:"idleTimeout"`
+	ReadTimeout     time.Duration   `mapstructure:"readTimeout"`
+	WriteTimeout    time.Duration   `mapstructure:"writeTimeout"`
+	ShutdownTimeout time.Duration   `mapstructure:"shutdownTimeout"` // how long in-flight requests get to finish on SIGTERM
+	NearbyDistance  int             `mapstructure:"nearbyDistance"`
+	Dispatch        struct {
+		OfferTimeout  time.Duration `mapstructure:"offerTimeout"`
+		SweepInterval time.Duration `mapstructure:"sweepInterval"`
+	} `mapstructure:"dispatch"`
+	LocationIngest struct {
+		FlushInterval time.Duration `mapstructure:"flushInterval"`
+		MaxBatchSize  int           `mapstructure:"maxBatchSize"`
+	} `mapstructure:"locationIngest"`
+	DriverPurge struct {
+		Retention time.Duration `mapstructure:"retention"` // how long deleted drivers can be restored before they are gone for good
+		Interval  time.Duration `mapstructure:"interval"`  // zero disables the purge
+	} `mapstructure:"driverPurge"`
+	Stream struct {
+		BufferSize        int           `mapstructure:"bufferSize"`
+		MaxDroppedEvents  int           `mapstructure:"maxDroppedEvents"`
+		HeartbeatInterval time.Duration `mapstructure:"heartbeatInterval"`
+	} `mapstructure:"stream"`
+	Pricing struct {
+		Timezone        string         `mapstructure:"timezone"`
+		NightStartHour  int            `mapstructure:"nightStartHour"`
+		NightEndHour    int            `mapstructure:"nightEndHour"`
+		Holidays        []string       `mapstructure:"holidays"`
+		RouteFactor     float64        `mapstructure:"routeFactor"`
+		AverageSpeedKmh float64        `mapstructure:"averageSpeedKmh"`
+		Tariffs         []TariffConfig `mapstructure:"tariffs"`
+	} `mapstructure:"pricing"`
+	Surge struct {
+		Precision         int           `mapstructure:"precision"`
+		Window            time.Duration `mapstructure:"window"`
+		RecomputeInterval time.Duration `mapstructure:"recomputeInterval"`
+		Threshold         float64       `mapstructure:"threshold"`
+		Sensitivity       float64       `mapstructure:"sensitivity"`
+		MaxMultiplier     float64       `mapstructure:"maxMultiplier"`
+		Step              float64       `mapstructure:"step"`
+	} `mapstructure:"surge"`
+	Health struct {
+		CheckTimeout time.Duration `mapstructure:"checkTimeout"` // a readiness check slower than this counts as down
+	} `mapstructure:"health"`
+	Metrics struct {
+		Enabled       bool          `mapstructure:"enabled"`
+		Path          string        `mapstructure:"path"`
+		ScrapeTimeout time.Duration `mapstructure:"scrapeTimeout"` // counting online drivers on a scrape gives up after this
+	} `mapstructure:"metrics"`
+	Migrations struct {
+		RunOnStartup bool          `mapstructure:"runOnStartup"` // otherwise run "taxihub migrate" before deploying
+		Timeout      time.Duration `mapstructure:"timeout"`
+	} `mapstructure:"migrations"`
+	JWT struct {
+		SigningKid string         `mapstructure:"signingKid"` // key new tokens are signed with
+		LegacyKid  string         `mapstructure:"legacyKid"`  // key for tokens issued before kid headers, empty rejects them
+		Keys       []JWTKeyConfig `mapstructure:"keys"`
+	} `mapstructure:"jwt"`
 }
 
 func Read() *AppConfig {
@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
+)
+
+type AuditAction string
+
+const (
+	AuditCreated       AuditAction = "created"
+	AuditUpdated       AuditAction = "updated"
+	AuditStatusChanged AuditAction = "status_changed"
+	AuditShiftChanged  AuditAction = "shift_changed"
+	AuditDeleted       AuditAction = "deleted"
+	AuditDeactivated   AuditAction = "deactivated"
+	AuditRestored      AuditAction = "restored"
+	AuditPurged        AuditAction = "purged"
+	AuditSignedUp      AuditAction = "signed_up"
+	AuditLoggedIn      AuditAction = "logged_in"
+	AuditLoginFailed   AuditAction = "login_failed"
+	AuditRoleChanged   AuditAction = "role_changed"
+)
+
+// audited resources, one per collection
+const (
+	AuditResourceDriver = "drivers"
+	AuditResourceUser   = "users"
+)
+
+// AuditActor is who made a change: a signed in user, or a background job of the service
+type AuditActor struct {
+	UserID string `bson:"uid,omitempty" json:"uid,omitempty"`
+	Email  string `bson:"email,omitempty" json:"email,omitempty"`
+	System string `bson:"system,omitempty" json:"system,omitempty"` // e.g. driver-purge, set when no user is involved
+}
+
+// FieldChange is one field before and after a change, a missing side means the field was not set
+type FieldChange struct {
+	Field  string `bson:"field" json:"field"`
+	Before any    `bson:"before,omitempty" json:"before,omitempty"`
+	After  any    `bson:"after,omitempty" json:"after,omitempty"`
+}
+
+// AuditRecord is an immutable record of one change, written once and never updated or removed
+type AuditRecord struct {
+	ID         string        `bson:"_id" json:"id"`
+	Resource   string        `bson:"resource" json:"resource"`
+	ResourceID string        `bson:"resourceId" json:"resourceId"`
+	Action     AuditAction   `bson:"action" json:"action"`
+	Actor      AuditActor    `bson:"actor" json:"actor"`
+	RequestID  string        `bson:"requestId,omitempty" json:"requestId,omitempty"`
+	Changes    []FieldChange `bson:"changes,omitempty" json:"changes,omitempty"`
+	Timestamp  time.Time     `bson:"timestamp" json:"timestamp"`
+}
+
+// DiffFields compares the JSON forms of before and after field by field, a nil before lists every
+// field of after. Fields named in ignore, such as timestamps every write touches, are left out.
+func DiffFields(before, after any, ignore ...string) []FieldChange {
+	beforeFields, afterFields := jsonFields(before), jsonFields(after)
+
+	var changes []FieldChange
+	for field, value := range afterFields {
+		if old, ok := beforeFields[field]; !ok || !reflect.DeepEqual(old, value) {
+			changes = append(changes, FieldChange{Field: field, Before: old, After: value})
+		}
+	}
+	for field, old := range beforeFields {
+		if _, ok := afterFields[field]; !ok {
+			changes = append(changes, FieldChange{Field: field, Before: old})
+		}
+	}
+
+	changes = slices.DeleteFunc(changes, func(change FieldChange) bool {
+		return slices.Contains(ignore, change.Field)
+	})
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+// jsonFields decodes the JSON form of v into its top level fields, nil for nil
+func jsonFields(v any) map[string]any {
+	if v == nil {
+		return nil
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && rv.IsNil() {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil
+	}
+	return fields
+}
@@ -1,18 +1,78 @@
 package domain
 
 import (
+	"slices"
 	"time"
 )
 
+type DriverStatus string
+
+const (
+	DriverOffline DriverStatus = "OFFLINE" // not taking rides, also the status outside of a shift
+	DriverOnline  DriverStatus = "ONLINE"  // available for new rides
+	DriverOnTrip  DriverStatus = "ON_TRIP"
+	DriverOnBreak DriverStatus = "ON_BREAK"
+)
+
+var (
+	ErrInvalidDriverTransition = NewConflictError("INVALID_DRIVER_TRANSITION", "invalid driver status transition")
+	ErrDriverOnShift           = NewConflictError("DRIVER_ON_SHIFT", "driver must end the open shift first")
+	ErrDriverOnTrip            = NewConflictError("DRIVER_ON_TRIP", "driver is on a ride, completing or cancelling it frees the driver")
+	// the driver changed since the client or the handler read it
+	ErrDriverVersionMismatch = NewPreconditionFailedError("VERSION_MISMATCH", "driver was changed in the meantime, read it again")
+)
+
+// allowed status changes, anything else is rejected
+var driverTransitions = map[DriverStatus][]DriverStatus{
+	DriverOffline: {DriverOnline},
+	DriverOnline:  {DriverOffline, DriverOnTrip, DriverOnBreak},
+	DriverOnTrip:  {DriverOnline},
+	DriverOnBreak: {DriverOnline, DriverOffline},
+}
+
 type Driver struct {
-	ID        string    `bson:"_id,omitempty" json:"id"`
-	FirstName string    `bson:"firstName" json:"firstName"`
-	LastName  string    `bson:"lastName" json:"lastName"`
-	Plate     string    `bson:"plate" json:"plate"`
-	TaxiType  string    `bson:"taxiType" json:"taxiType"`
-	CarBrand  string    `bson:"carBrand" json:"carBrand"`
-	CarModel  string    `bson:"carModel" json:"carModel"`
-	Location  Location  `bson:"location" json:"location"`
-	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
-	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
+	ID                string       `bson:"_id,omitempty" json:"id"`
+	UserID            string       `bson:"userId,omitempty" json:"userId,omitempty"` // account of the driver, owns this record
+	FirstName         string       `bson:"firstName" json:"firstName"`
+	LastName          string       `bson:"lastName" json:"lastName"`
+	Plate             string       `bson:"plate" json:"plate"`
+	TaxiType          string       `bson:"taxiType" json:"taxiType"`
+	CarBrand          string       `bson:"carBrand" json:"carBrand"`
+	CarModel          string       `bson:"carModel" json:"carModel"`
+	Location          Location     `bson:"location" json:"location"`
+	LocationUpdatedAt *time.Time   `bson:"locationUpdatedAt,omitempty" json:"locationUpdatedAt,omitempty"` // device time of the last accepted ping
+	Status            DriverStatus `bson:"status,omitempty" json:"status"`
+	CurrentShiftID    string       `bson:"currentShiftId,omitempty" json:"currentShiftId,omitempty"`
+	CreatedAt         time.Time    `bson:"createdAt" json:"createdAt"`
+	UpdatedAt         time.Time    `bson:"updatedAt" json:"updatedAt"`
+	SearchGrams       []string     `bson:"searchGrams,omitempty" json:"-"`                 // written by the repository, see DriverSearchGrams
+	ActivePlate       string       `bson:"activePlate,omitempty" json:"-"`                 // the plate while not soft deleted, written by the repository for the unique plate index
+	DeletedAt         *time.Time   `bson:"deletedAt,omitempty" json:"deletedAt,omitempty"` // set while soft deleted, every query skips the driver
+	DeletionReason    string       `bson:"deletionReason,omitempty" json:"deletionReason,omitempty"`
+	Deactivated       bool         `bson:"deactivated,omitempty" json:"deactivated,omitempty"` // soft deleted but never purged, waits for a restore
+	Version           int64        `bson:"version" json:"version"`                             // bumped by every edit of the record, status and location writes leave it alone
+}
+
+// CurrentStatus treats drivers stored before statuses existed as offline
+func (d *Driver) CurrentStatus() DriverStatus {
+	if d.Status == "" {
+		return DriverOffline
+	}
+	return d.Status
+}
+
+func (d *Driver) IsAvailable() bool {
+	return d.CurrentStatus() == DriverOnline
+}
+
+func (d *Driver) IsDeleted() bool {
+	return d.DeletedAt != nil
+}
+
+func (d *Driver) OnShift() bool {
+	return d.CurrentShiftID != ""
+}
+
+func CanTransitionDriver(from, to DriverStatus) bool {
+	return slices.Contains(driverTransitions[from], to)
 }
@@ -0,0 +1,123 @@
+package domain
+
+type ErrorKind string
+
+const (
+	KindNotFound           ErrorKind = "NOT_FOUND"
+	KindConflict           ErrorKind = "CONFLICT"
+	KindValidation         ErrorKind = "VALIDATION"
+	KindUnauthorized       ErrorKind = "UNAUTHORIZED"
+	KindForbidden          ErrorKind = "FORBIDDEN"
+	KindUnavailable        ErrorKind = "UNAVAILABLE"
+	KindInvalidField       ErrorKind = "INVALID_FIELD"
+	KindRateLimited        ErrorKind = "RATE_LIMITED"
+	KindPreconditionFailed ErrorKind = "PRECONDITION_FAILED"
+)
+
+// FieldError is one rule a request field broke
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error is an expected failure with a stable, machine readable code.
+// Message is safe to show to clients, the wrapped cause is only meant for logs.
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+	Fields  []FieldError // set on validation failures, one entry per invalid field
+	cause   error
+}
+
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return e.Message + ": " + e.cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is matches any error with the same code, so copies made by Wrap and WithMessage still match their sentinel
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of e carrying the underlying cause
+func (e *Error) Wrap(cause error) *Error {
+	cp := *e
+	cp.cause = cause
+	return &cp
+}
+
+// WithMessage returns a copy of e with a more specific client message
+func (e *Error) WithMessage(message string) *Error {
+	cp := *e
+	cp.Message = message
+	return &cp
+}
+
+// WithFields returns a copy of e listing the invalid fields
+func (e *Error) WithFields(fields []FieldError) *Error {
+	cp := *e
+	cp.Fields = fields
+	return &cp
+}
+
+func NewNotFoundError(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func NewConflictError(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func NewValidationError(code, message string) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message}
+}
+
+func NewInvalidFieldError(code, message string) *Error {
+	return &Error{Kind: KindInvalidField, Code: code, Message: message}
+}
+
+func NewUnauthorizedError(code, message string) *Error {
+	return &Error{Kind: KindUnauthorized, Code: code, Message: message}
+}
+
+func NewForbiddenError(code, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+func NewUnavailableError(code, message string) *Error {
+	return &Error{Kind: KindUnavailable, Code: code, Message: message}
+}
+
+func NewRateLimitedError(code, message string) *Error {
+	return &Error{Kind: KindRateLimited, Code: code, Message: message}
+}
+
+func NewPreconditionFailedError(code, message string) *Error {
+	return &Error{Kind: KindPreconditionFailed, Code: code, Message: message}
+}
+
+// errors shared by every part of the service, feature specific ones live next to their types
+var (
+	ErrInvalidRequest      = NewValidationError("INVALID_REQUEST", "invalid request")
+	ErrValidationFailed    = NewInvalidFieldError("VALIDATION_FAILED", "some fields are invalid")
+	ErrUnauthorized        = NewUnauthorizedError("UNAUTHORIZED", "authentication required")
+	ErrForbidden           = NewForbiddenError("FORBIDDEN", "unauthorized to access this resource")
+	ErrDatabaseUnavailable = NewUnavailableError("DATABASE_UNAVAILABLE", "database is unavailable, try again later")
+	ErrTimeout             = NewUnavailableError("TIMEOUT", "the request took too long, try again later")
+	ErrRateLimited         = NewRateLimitedError("RATE_LIMITED", "too many requests, try again later")
+
+	ErrDriverNotFound    = NewNotFoundError("DRIVER_NOT_FOUND", "driver not found")
+	ErrShiftNotFound     = NewNotFoundError("SHIFT_NOT_FOUND", "shift not found")
+	ErrRideNotFound      = NewNotFoundError("RIDE_NOT_FOUND", "ride not found")
+	ErrTariffNotFound    = NewNotFoundError("TARIFF_NOT_FOUND", "no tariff for this taxi type")
+	ErrSurgeCellNotFound = NewNotFoundError("SURGE_CELL_NOT_FOUND", "no surge data for this cell")
+)
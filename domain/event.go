@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+type DriverEventType string
+
+const (
+	DriverLocationChanged DriverEventType = "location"
+	DriverStatusChanged   DriverEventType = "status"
+	DriverRemoved         DriverEventType = "removed" // deleted or deactivated, subscribers drop the driver
+)
+
+// DriverEvent is pushed to live subscribers such as the dispatcher dashboard
+type DriverEvent struct {
+	Type      DriverEventType `json:"type"`
+	DriverID  string          `json:"driverId"`
+	TaxiType  string          `json:"taxiType,omitempty"`
+	Location  *Location       `json:"location,omitempty"`
+	Status    DriverStatus    `json:"status,omitempty"`
+	Timestamp time.Time       `json:"timestamp"`
+}
@@ -1,11 +1,22 @@
 package domain
 
+import "time"
+
 // type Location struct {
 // 	Lat float64 `bson:"lat" json:"lat"`
 // 	Lon float64 `bson:"lon" json:"lon"`
 // }
 
+// Location is a GeoJSON point, the keys are the GeoJSON ones in Mongo and JSON alike
 type Location struct {
-	Type        string    `bson:"type"`
-	Coordinates []float64 `bson:"coordinates"`
+	Type        string    `bson:"type" json:"type" validate:"eq=Point"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates" validate:"len=2,lonlat"` // longitude, latitude
+}
+
+// LocationUpdate is a position reported by a driver device at RecordedAt
+type LocationUpdate struct {
+	DriverID   string
+	Location   Location
+	RecordedAt time.Time
+	TaxiType   string // filled in by the repository on the updates it applied
 }
@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Turkish plates are a province code 01-81, one to three letters and two to four digits
+var turkishPlate = regexp.MustCompile(`^(0[1-9]|[1-7][0-9]|8[01])([A-Z]{1,3})([0-9]{2,4})$`)
+
+// NormalizePlate returns the form plates are stored and compared in.
+// Case, spaces, dashes and dots are ignored, so "34abc123" and "34-ABC 123" are the same plate.
+// Plain upper casing is used on purpose, plates have no dotted capital I and "ı" must become "I" too.
+// Turkish plates are written as "34 ABC 123", other plates are kept upper cased without spaces,
+// dashes and dots, any other character like "/" stays.
+func NormalizePlate(plate string) string {
+	compact := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) || r == '-' || r == '.' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(plate))
+
+	if m := turkishPlate.FindStringSubmatch(compact); m != nil {
+		return m[1] + " " + m[2] + " " + m[3]
+	}
+	return compact
+}
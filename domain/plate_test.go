@@ -0,0 +1,34 @@
+package domain
+
+import "testing"
+
+func TestNormalizePlate(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"34abc123", "34 ABC 123"},
+		{"34-ABC 123", "34 ABC 123"},
+		{"34 abc 1234", "34 ABC 1234"},
+		{" 34.abc.123 ", "34 ABC 123"},
+		{"06 a 42", "06 A 42"},
+		// the dotless ı upper cases to a plain I like every other i
+		{"34 kız 12", "34 KIZ 12"},
+		{"34 kiz 12", "34 KIZ 12"},
+		// out of range provinces and letter or digit counts are not Turkish plates
+		{"99 AB 12", "99AB12"},
+		{"00 AB 12", "00AB12"},
+		{"34 ABCD 12", "34ABCD12"},
+		{"34 AB 1", "34AB1"},
+		{"34 AB 12345", "34AB12345"},
+		// other plates only lose case, spaces, dashes and dots
+		{"B-MW 1234", "BMW1234"},
+		{"ab/12_3", "AB/12_3"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizePlate(tt.in); got != tt.want {
+			t.Errorf("NormalizePlate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package domain
+
+import (
+	"slices"
+	"time"
+)
+
+type RideStatus string
+
+const (
+	RideRequested  RideStatus = "REQUESTED"   // waiting for a driver, may have a pending offer
+	RideAccepted   RideStatus = "ACCEPTED"    // driver is on the way to the pickup point
+	RideAtPickup   RideStatus = "AT_PICKUP"   // driver is waiting at the pickup point
+	RideInProgress RideStatus = "IN_PROGRESS" // passenger is in the car
+	RideCompleted  RideStatus = "COMPLETED"
+	RideCancelled  RideStatus = "CANCELLED"
+)
+
+var (
+	ErrInvalidRideTransition = NewConflictError("INVALID_RIDE_TRANSITION", "invalid ride state transition")
+	ErrRideNotOfferedToYou   = NewConflictError("RIDE_NOT_OFFERED_TO_YOU", "ride is not offered to this driver")
+	ErrRideOfferExpired      = NewConflictError("RIDE_OFFER_EXPIRED", "ride offer has expired")
+	ErrRideNotAssignedToYou  = NewConflictError("RIDE_NOT_ASSIGNED_TO_YOU", "ride is not assigned to this driver")
+)
+
+type Ride struct {
+	ID                string     `bson:"_id,omitempty" json:"id"`
+	PassengerID       string     `bson:"passengerId" json:"passengerId"`
+	DriverID          string     `bson:"driverId,omitempty" json:"driverId,omitempty"`
+	TaxiType          string     `bson:"taxiType" json:"taxiType"`
+	Pickup            Location   `bson:"pickup" json:"pickup"`
+	Dropoff           Location   `bson:"dropoff" json:"dropoff"`
+	Status            RideStatus `bson:"status" json:"status"`
+	OfferedDriverID   string     `bson:"offeredDriverId,omitempty" json:"offeredDriverId,omitempty"`
+	OfferExpiresAt    *time.Time `bson:"offerExpiresAt,omitempty" json:"offerExpiresAt,omitempty"`
+	DeclinedDriverIDs []string   `bson:"declinedDriverIds" json:"declinedDriverIds"`
+	CancelReason      string     `bson:"cancelReason,omitempty" json:"cancelReason,omitempty"`
+	AcceptedAt        *time.Time `bson:"acceptedAt,omitempty" json:"acceptedAt,omitempty"`
+	ArrivedAt         *time.Time `bson:"arrivedAt,omitempty" json:"arrivedAt,omitempty"`
+	StartedAt         *time.Time `bson:"startedAt,omitempty" json:"startedAt,omitempty"`
+	CompletedAt       *time.Time `bson:"completedAt,omitempty" json:"completedAt,omitempty"`
+	CancelledAt       *time.Time `bson:"cancelledAt,omitempty" json:"cancelledAt,omitempty"`
+	Version           int64      `bson:"version" json:"version"` // bumped on every write, used to detect concurrent updates
+	CreatedAt         time.Time  `bson:"createdAt" json:"createdAt"`
+	UpdatedAt         time.Time  `bson:"updatedAt" json:"updatedAt"`
+}
+
+// IsActive reports whether the ride still occupies a driver or waits for one
+func (r *Ride) IsActive() bool {
+	return r.Status != RideCompleted && r.Status != RideCancelled
+}
+
+// Offer gives the ride to a driver until expiresAt
+func (r *Ride) Offer(driverID string, expiresAt time.Time) error {
+	if r.Status != RideRequested || r.OfferedDriverID != "" {
+		return ErrInvalidRideTransition
+	}
+	r.OfferedDriverID = driverID
+	r.OfferExpiresAt = &expiresAt
+	return nil
+}
+
+// Decline withdraws the pending offer, the driver will not be asked again for this ride
+func (r *Ride) Decline(driverID string) error {
+	if r.Status != RideRequested {
+		return ErrInvalidRideTransition
+	}
+	if r.OfferedDriverID != driverID {
+		return ErrRideNotOfferedToYou
+	}
+	if !slices.Contains(r.DeclinedDriverIDs, driverID) {
+		r.DeclinedDriverIDs = append(r.DeclinedDriverIDs, driverID)
+	}
+	r.OfferedDriverID = ""
+	r.OfferExpiresAt = nil
+	return nil
+}
+
+// OfferExpired reports whether the pending offer ran out at the given time
+func (r *Ride) OfferExpired(now time.Time) bool {
+	return r.OfferedDriverID != "" && r.OfferExpiresAt != nil && !now.Before(*r.OfferExpiresAt)
+}
+
+func (r *Ride) Accept(driverID string, now time.Time) error {
+	if r.Status != RideRequested {
+		return ErrInvalidRideTransition
+	}
+	if r.OfferedDriverID != driverID {
+		return ErrRideNotOfferedToYou
+	}
+	if r.OfferExpired(now) {
+		return ErrRideOfferExpired
+	}
+	r.DriverID = driverID
+	r.OfferedDriverID = ""
+	r.OfferExpiresAt = nil
+	r.Status = RideAccepted
+	r.AcceptedAt = &now
+	return nil
+}
+
+func (r *Ride) ArriveAtPickup(driverID string, now time.Time) error {
+	if err := r.advance(driverID, RideAccepted, RideAtPickup); err != nil {
+		return err
+	}
+	r.ArrivedAt = &now
+	return nil
+}
+
+func (r *Ride) Start(driverID string, now time.Time) error {
+	if err := r.advance(driverID, RideAtPickup, RideInProgress); err != nil {
+		return err
+	}
+	r.StartedAt = &now
+	return nil
+}
+
+func (r *Ride) Complete(driverID string, now time.Time) error {
+	if err := r.advance(driverID, RideInProgress, RideCompleted); err != nil {
+		return err
+	}
+	r.CompletedAt = &now
+	return nil
+}
+
+// Cancel is allowed until the passenger is picked up
+func (r *Ride) Cancel(reason string, now time.Time) error {
+	switch r.Status {
+	case RideRequested, RideAccepted, RideAtPickup:
+	default:
+		return ErrInvalidRideTransition
+	}
+	r.Status = RideCancelled
+	r.CancelReason = reason
+	r.OfferedDriverID = ""
+	r.OfferExpiresAt = nil
+	r.CancelledAt = &now
+	return nil
+}
+
+func (r *Ride) advance(driverID string, from, to RideStatus) error {
+	if r.Status != from {
+		return ErrInvalidRideTransition
+	}
+	if r.DriverID != driverID {
+		return ErrRideNotAssignedToYou
+	}
+	r.Status = to
+	return nil
+}
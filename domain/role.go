@@ -0,0 +1,32 @@
+package domain
+
+type Role string
+
+const (
+	RoleAdmin      Role = "ADMIN"
+	RoleDispatcher Role = "DISPATCHER"
+	RoleDriver     Role = "DRIVER"
+	RolePassenger  Role = "PASSENGER"
+)
+
+// accounts created before roles existed carry USER, they are passengers
+const legacyUserRole = "USER"
+
+// ParseRole maps a stored user type to a role, false for anything unknown
+func ParseRole(userType string) (Role, bool) {
+	switch Role(userType) {
+	case RoleAdmin, RoleDispatcher, RoleDriver, RolePassenger:
+		return Role(userType), true
+	}
+	if userType == legacyUserRole {
+		return RolePassenger, true
+	}
+	return "", false
+}
+
+// CanSignUpAs reports whether a new account may pick the user type on its own.
+// Privileged roles are only ever granted by an admin.
+func CanSignUpAs(userType string) bool {
+	role, ok := ParseRole(userType)
+	return ok && (role == RolePassenger || role == RoleDriver)
+}
@@ -0,0 +1,91 @@
+package domain
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Turkish letters and the ASCII letter people type instead of them
+var searchFolder = strings.NewReplacer(
+	"ç", "c", "ğ", "g", "ı", "i", "ö", "o", "ş", "s", "ü", "u",
+	"â", "a", "î", "i", "û", "u",
+)
+
+// FoldSearchText lowercases s the Turkish way and replaces Turkish letters with their ASCII
+// counterparts, so "Şükrü", "sukru" and "ŞÜKRÜ" all fold to "sukru". Anything but letters and
+// digits becomes a space.
+func FoldSearchText(s string) string {
+	folded := searchFolder.Replace(strings.ToLowerSpecial(unicode.TurkishCase, s))
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, folded)
+}
+
+// SearchTokens splits folded text into the words search matches on
+func SearchTokens(s string) []string {
+	return strings.Fields(FoldSearchText(s))
+}
+
+// SearchField is text a driver is found by, Weight is how much a match on it counts
+type SearchField struct {
+	Text   string
+	Weight float64
+}
+
+func DriverSearchFields(d *Driver) []SearchField {
+	return []SearchField{
+		{Text: d.FirstName, Weight: 1},
+		{Text: d.LastName, Weight: 1},
+		{Text: d.Plate, Weight: 1},
+		// the compact plate lets "34abc" match "34 ABC 123"
+		{Text: strings.ReplaceAll(d.Plate, " ", ""), Weight: 1},
+		{Text: d.CarBrand, Weight: 0.6},
+		{Text: d.CarModel, Weight: 0.6},
+	}
+}
+
+// DriverSearchGrams are the index keys a driver is looked up by: the trigrams of every word,
+// plus its first one and two letters marked with ^ so short prefixes find it too
+func DriverSearchGrams(d *Driver) []string {
+	var grams []string
+	for _, field := range DriverSearchFields(d) {
+		for _, token := range SearchTokens(field.Text) {
+			runes := []rune(token)
+			grams = append(grams, "^"+string(runes[:1]))
+			if len(runes) >= 2 {
+				grams = append(grams, "^"+string(runes[:2]))
+			}
+			grams = append(grams, trigrams(runes)...)
+		}
+	}
+	slices.Sort(grams)
+	return slices.Compact(grams)
+}
+
+// QueryGrams are the index keys a search query looks up, a driver sharing one is a candidate.
+// Words shorter than three letters can only be prefixes.
+func QueryGrams(query string) []string {
+	var grams []string
+	for _, token := range SearchTokens(query) {
+		runes := []rune(token)
+		if len(runes) < 3 {
+			grams = append(grams, "^"+token)
+			continue
+		}
+		grams = append(grams, trigrams(runes)...)
+	}
+	slices.Sort(grams)
+	return slices.Compact(grams)
+}
+
+func trigrams(runes []rune) []string {
+	var grams []string
+	for i := 0; i+3 <= len(runes); i++ {
+		grams = append(grams, string(runes[i:i+3]))
+	}
+	return grams
+}
@@ -0,0 +1,67 @@
+package domain
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestFoldSearchText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Şükrü", "sukru"},
+		{"ŞÜKRÜ", "sukru"},
+		{"sukru", "sukru"},
+		{"IŞIK", "isik"},
+		{"İstanbul", "istanbul"},
+		{"Çağlar Gök", "caglar gok"},
+		{"34-ABC.123", "34 abc 123"},
+	}
+
+	for _, tt := range tests {
+		if got := FoldSearchText(tt.in); got != tt.want {
+			t.Errorf("FoldSearchText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestQueryGrams(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"sukru", []string{"kru", "suk", "ukr"}},
+		{"Şükrü", []string{"kru", "suk", "ukr"}},
+		// words shorter than three letters are prefixes
+		{"ah", []string{"^ah"}},
+		{"a", []string{"^a"}},
+		{"34abc", []string{"34a", "4ab", "abc"}},
+		{"ali ali", []string{"ali"}},
+		{"  ", nil},
+	}
+
+	for _, tt := range tests {
+		if got := QueryGrams(tt.query); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("QueryGrams(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+// every gram of a query for a driver has to be among the driver's grams, or the lookup misses it
+func TestDriverSearchGramsCoverQueries(t *testing.T) {
+	driver := &Driver{FirstName: "Şükrü", LastName: "Yılmaz", Plate: "34 ABC 123", CarBrand: "Fiat", CarModel: "Egea"}
+	grams := DriverSearchGrams(driver)
+
+	if !slices.IsSorted(grams) || len(slices.Compact(slices.Clone(grams))) != len(grams) {
+		t.Fatalf("DriverSearchGrams = %q, want sorted and unique", grams)
+	}
+
+	for _, query := range []string{"sukru", "ŞÜKRÜ", "yilmaz", "34abc", "34 abc 123", "ABC", "s", "yı", "fi", "egea"} {
+		for _, gram := range QueryGrams(query) {
+			if !slices.Contains(grams, gram) {
+				t.Errorf("query %q looks up %q, which the driver is not indexed by", query, gram)
+			}
+		}
+	}
+}
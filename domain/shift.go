@@ -0,0 +1,80 @@
+package domain
+
+import "time"
+
+var (
+	ErrShiftAlreadyOpen = NewConflictError("SHIFT_ALREADY_OPEN", "driver already has an open shift")
+	ErrNoOpenShift      = NewConflictError("NO_OPEN_SHIFT", "driver has no open shift")
+	ErrShiftClosed      = NewConflictError("SHIFT_CLOSED", "shift is already closed")
+)
+
+type Break struct {
+	StartedAt time.Time  `bson:"startedAt" json:"startedAt"`
+	EndedAt   *time.Time `bson:"endedAt,omitempty" json:"endedAt,omitempty"`
+}
+
+// Shift is the period a driver is clocked in, used for hours worked
+type Shift struct {
+	ID        string     `bson:"_id,omitempty" json:"id"`
+	DriverID  string     `bson:"driverId" json:"driverId"`
+	StartedAt time.Time  `bson:"startedAt" json:"startedAt"`
+	EndedAt   *time.Time `bson:"endedAt,omitempty" json:"endedAt,omitempty"`
+	Breaks    []Break    `bson:"breaks" json:"breaks"`
+}
+
+func (s *Shift) IsOpen() bool {
+	return s.EndedAt == nil
+}
+
+func (s *Shift) StartBreak(now time.Time) error {
+	if !s.IsOpen() {
+		return ErrShiftClosed
+	}
+	if s.onBreak() {
+		return nil
+	}
+	s.Breaks = append(s.Breaks, Break{StartedAt: now})
+	return nil
+}
+
+func (s *Shift) EndBreak(now time.Time) {
+	if s.onBreak() {
+		s.Breaks[len(s.Breaks)-1].EndedAt = &now
+	}
+}
+
+// End closes the shift together with a break that is still running
+func (s *Shift) End(now time.Time) error {
+	if !s.IsOpen() {
+		return ErrShiftClosed
+	}
+	s.EndBreak(now)
+	s.EndedAt = &now
+	return nil
+}
+
+// BreakDuration sums up all breaks, open ones count until now
+func (s *Shift) BreakDuration(now time.Time) time.Duration {
+	var total time.Duration
+	for _, b := range s.Breaks {
+		end := now
+		if b.EndedAt != nil {
+			end = *b.EndedAt
+		}
+		total += end.Sub(b.StartedAt)
+	}
+	return total
+}
+
+// WorkedDuration is the shift length without breaks, open shifts count until now
+func (s *Shift) WorkedDuration(now time.Time) time.Duration {
+	end := now
+	if s.EndedAt != nil {
+		end = *s.EndedAt
+	}
+	return end.Sub(s.StartedAt) - s.BreakDuration(now)
+}
+
+func (s *Shift) onBreak() bool {
+	return len(s.Breaks) > 0 && s.Breaks[len(s.Breaks)-1].EndedAt == nil
+}
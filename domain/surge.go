@@ -0,0 +1,23 @@
+package domain
+
+import "time"
+
+// SurgeCell is the current surge of one geohash cell
+type SurgeCell struct {
+	ID         string    `bson:"_id" json:"cell"` // geohash
+	Multiplier float64   `bson:"multiplier" json:"multiplier"`
+	Supply     int       `bson:"supply" json:"supply"` // available drivers in the cell
+	Demand     int       `bson:"demand" json:"demand"` // unserved ride requests in the window
+	UpdatedAt  time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// SurgeChange is an immutable audit record, written every time a cell multiplier changes
+type SurgeChange struct {
+	ID            string    `bson:"_id,omitempty" json:"id"`
+	CellID        string    `bson:"cellId" json:"cell"`
+	OldMultiplier float64   `bson:"oldMultiplier" json:"oldMultiplier"`
+	NewMultiplier float64   `bson:"newMultiplier" json:"newMultiplier"`
+	Supply        int       `bson:"supply" json:"supply"`
+	Demand        int       `bson:"demand" json:"demand"`
+	ChangedAt     time.Time `bson:"changedAt" json:"changedAt"`
+}
@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// Tariff is the price list of one taxi type, amounts are in Currency
+type Tariff struct {
+	TaxiType          string    `bson:"_id" json:"taxiType" validate:"required,taxitype"`
+	Currency          string    `bson:"currency" json:"currency" validate:"required,len=3"`
+	BaseFare          float64   `bson:"baseFare" json:"baseFare" validate:"gte=0"`
+	PerKm             float64   `bson:"perKm" json:"perKm" validate:"gte=0"`
+	PerMinute         float64   `bson:"perMinute" json:"perMinute" validate:"gte=0"`
+	NightMultiplier   float64   `bson:"nightMultiplier" json:"nightMultiplier" validate:"omitempty,gte=1"` // 0 means 1
+	HolidayMultiplier float64   `bson:"holidayMultiplier" json:"holidayMultiplier" validate:"omitempty,gte=1"`
+	MinimumFare       float64   `bson:"minimumFare" json:"minimumFare" validate:"gte=0"`
+	UpdatedAt         time.Time `bson:"updatedAt" json:"updatedAt"`
+}
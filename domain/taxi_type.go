@@ -0,0 +1,16 @@
+package domain
+
+import "slices"
+
+// taxi types drivers, rides and tariffs may use
+const (
+	TaxiYellow    = "yellow"
+	TaxiTurquoise = "turquoise"
+	TaxiBlack     = "black"
+)
+
+var TaxiTypes = []string{TaxiYellow, TaxiTurquoise, TaxiBlack}
+
+func IsTaxiType(taxiType string) bool {
+	return slices.Contains(TaxiTypes, taxiType)
+}
@@ -6,6 +6,11 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+var (
+	ErrUserNotFound       = NewNotFoundError("USER_NOT_FOUND", "user not found")
+	ErrEmailOrPhoneExists = NewConflictError("EMAIL_OR_PHONE_EXISTS", "this email or phone number already exists")
+)
+
 type User struct {
 	ID            primitive.ObjectID `bson:"_id"`
 	First_name    *string            `json:"first_name" validate:"required,min=2,max=100"`
@@ -14,7 +19,7 @@ type User struct {
 	Email         *string            `json:"email" validate:"email,required"`
 	Phone         *string            `json:"phone" validate:"required"`
 	Token         *string            `json:"token"`
-	User_type     *string            `json:"user_type" validate:"required,eq=ADMIN|eq=USER"`
+	User_type     *string            `json:"user_type" validate:"required,oneof=ADMIN DISPATCHER DRIVER PASSENGER USER"`
 	Refresh_token *string            `json:"refresh_token"`
 	Created_at    time.Time          `json:"created_at"`
 	Updated_at    time.Time          `json:"updated_at"`
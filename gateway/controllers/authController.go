@@ -2,21 +2,47 @@ package controllers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/application/audit"
+	"github.com/hekanemre/taxihub/application/validation"
 	"github.com/hekanemre/taxihub/domain"
 	"github.com/hekanemre/taxihub/gateway/helpers"
-	"go.mongodb.org/mongo-driver/bson"
+	applog "github.com/hekanemre/taxihub/log"
+	"github.com/hekanemre/taxihub/metrics"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.uber.org/zap"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var validate = validator.New()
+var (
+	ErrInvalidCredentials = domain.NewUnauthorizedError("INVALID_CREDENTIALS", "email or password is incorrect")
+)
+
+// countAuth records the outcome of a signup, login or refresh. The outcome is the
+// error code, which keeps the label to a known set of values.
+func countAuth(action string, handler fiber.Handler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		err := handler(c)
+
+		outcome := "success"
+		var domainErr *domain.Error
+		switch {
+		case errors.As(err, &domainErr):
+			outcome = strings.ToLower(domainErr.Code)
+		case err != nil:
+			outcome = "error"
+		}
+		metrics.AuthAttempts.WithLabelValues(action, outcome).Inc()
+
+		return err
+	}
+}
 
 func HashPassword(password string) string {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
@@ -50,38 +76,34 @@ func VerifyPassword(userPassword string, providedPassword string) (bool, string)
 // @Failure 400 {object} map[string]string "Invalid request"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router       /signup [post]
-func Signup(userRepo *helpers.TokenHelper) fiber.Handler {
-	return func(c *fiber.Ctx) error {
+func Signup(userRepo *helpers.TokenHelper, auditRecorder *audit.Recorder) fiber.Handler {
+	return countAuth("signup", func(c *fiber.Ctx) error {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
 		var user domain.User
 
 		if err := c.BodyParser(&user); err != nil {
-			zap.L().Error("Failed to parse request body", zap.Error(err))
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+			return domain.ErrInvalidRequest.WithMessage("Invalid request body").Wrap(err)
 		}
 
-		if err := validate.Struct(user); err != nil {
-			zap.L().Error("Validation failed", zap.Error(err))
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+		if err := validation.Struct(&user); err != nil {
+			return err
 		}
-
-		count, err := userRepo.UserCollection.CountDocuments(ctx, bson.M{"email": user.Email})
-		if err != nil {
-			zap.L().Error("Error checking email", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "error occurred while checking for the email"})
+		if !domain.CanSignUpAs(*user.User_type) {
+			return domain.ErrValidationFailed.WithFields([]domain.FieldError{{
+				Field:   "user_type",
+				Rule:    "oneof",
+				Message: "must be one of PASSENGER, DRIVER, other roles are granted by an admin",
+			}})
 		}
 
-		countPhone, err := userRepo.UserCollection.CountDocuments(ctx, bson.M{"phone": user.Phone})
+		exists, err := userRepo.Users.UserExists(ctx, *user.Email, *user.Phone)
 		if err != nil {
-			zap.L().Error("Error checking phone", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "error occurred while checking for the phone"})
+			return fmt.Errorf("checking email and phone: %w", err)
 		}
-
-		if count > 0 || countPhone > 0 {
-			zap.L().Error("Email or phone already exists", zap.String("email", *user.Email), zap.String("phone", *user.Phone))
-			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "this email or phone number already exists"})
+		if exists {
+			return domain.ErrEmailOrPhoneExists
 		}
 
 		password := HashPassword(*user.Password)
@@ -97,22 +119,26 @@ func Signup(userRepo *helpers.TokenHelper) fiber.Handler {
 		// Generate tokens
 		token, refreshToken, err := userRepo.GenerateAllTokens(*user.Email, *user.First_name, *user.Last_name, *user.User_type, user.User_id)
 		if err != nil {
-			zap.L().Error("Failed to generate tokens", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate tokens"})
+			return fmt.Errorf("generating tokens: %w", err)
 		}
 		user.Token = &token
 		user.Refresh_token = &refreshToken
 
-		// Insert user into MongoDB
-		result, insertErr := userRepo.UserCollection.InsertOne(ctx, user)
-		if insertErr != nil {
-			msg := fmt.Sprintf("User item was not created: %v", insertErr)
-			zap.L().Error("Failed to insert user", zap.Error(insertErr))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": msg})
+		if err := userRepo.Users.CreateUser(ctx, &user); err != nil {
+			if errors.Is(err, domain.ErrEmailOrPhoneExists) {
+				return err
+			}
+			return fmt.Errorf("inserting user: %w", err)
 		}
 
-		return c.Status(fiber.StatusOK).JSON(result)
-	}
+		// the new user signs themself up
+		auditCtx := audit.WithActor(c.UserContext(), domain.AuditActor{UserID: user.User_id, Email: *user.Email})
+		auditRecorder.Record(auditCtx, domain.AuditResourceUser, user.User_id, domain.AuditSignedUp,
+			domain.DiffFields(nil, auditedUserFields(&user)))
+
+		// same body the Mongo insert result used to render
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{"InsertedID": user.ID})
+	})
 }
 
 // Login godoc
@@ -127,56 +153,225 @@ func Signup(userRepo *helpers.TokenHelper) fiber.Handler {
 // @Failure 401 {object} map[string]string "Unauthorized"
 // @Failure 500 {object} map[string]string "Internal server error"
 // @Router       /login [post]
-func Login(userRepo *helpers.TokenHelper) fiber.Handler {
-	return func(c *fiber.Ctx) error {
+func Login(userRepo *helpers.TokenHelper, auditRecorder *audit.Recorder) fiber.Handler {
+	return countAuth("login", func(c *fiber.Ctx) error {
 		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 		defer cancel()
 
 		var user domain.User
-		var foundUser domain.User
 
 		// Parse request body
 		if err := c.BodyParser(&user); err != nil {
-			zap.L().Error("Failed to parse request body", zap.Error(err))
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+			return domain.ErrInvalidRequest.WithMessage("Invalid request body").Wrap(err)
+		}
+		if user.Email == nil || user.Password == nil {
+			return domain.ErrInvalidRequest.WithMessage("Missing 'email' or 'password' field")
 		}
 
 		// Find user by email
-		err := userRepo.UserCollection.FindOne(ctx, bson.M{"email": user.Email}).Decode(&foundUser)
+		foundUser, err := userRepo.Users.GetUserByEmail(ctx, *user.Email)
+		// failed logins are recorded with the email that was tried, there may be no user behind it
+		auditCtx := audit.WithActor(c.UserContext(), domain.AuditActor{Email: *user.Email})
+		if errors.Is(err, domain.ErrUserNotFound) {
+			auditRecorder.Record(auditCtx, domain.AuditResourceUser, "", domain.AuditLoginFailed, nil)
+			return ErrInvalidCredentials
+		}
 		if err != nil {
-			zap.L().Error("User not found", zap.Error(err))
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "email or password is incorrect"})
+			return fmt.Errorf("finding user: %w", err)
 		}
 
 		// Verify password
 		passwordIsValid, msg := VerifyPassword(*user.Password, *foundUser.Password)
 		if !passwordIsValid {
-			zap.L().Error("Invalid password attempt", zap.String("email", *user.Email))
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": msg})
+			applog.FromContext(c.UserContext()).Warn("Invalid password attempt", zap.String("email", *user.Email))
+			auditRecorder.Record(auditCtx, domain.AuditResourceUser, foundUser.User_id, domain.AuditLoginFailed, nil)
+			return ErrInvalidCredentials.WithMessage(msg)
 		}
 
 		if foundUser.Email == nil {
-			zap.L().Error("Email not found for user", zap.String("user_id", foundUser.User_id))
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "user not found"})
+			return domain.ErrUserNotFound
 		}
 
 		// Generate tokens
 		token, refreshToken, err := userRepo.GenerateAllTokens(*foundUser.Email, *foundUser.First_name, *foundUser.Last_name, *foundUser.User_type, foundUser.User_id)
 		if err != nil {
-			zap.L().Error("Failed to generate tokens", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "failed to generate tokens"})
+			return fmt.Errorf("generating tokens: %w", err)
 		}
 
 		// Update tokens in database
 		userRepo.UpdateAllTokens(token, refreshToken, foundUser.User_id)
 
 		// Refresh user data after updating tokens
-		err = userRepo.UserCollection.FindOne(ctx, bson.M{"user_id": foundUser.User_id}).Decode(&foundUser)
+		foundUser, err = userRepo.Users.GetUserByID(ctx, foundUser.User_id)
 		if err != nil {
-			zap.L().Error("Failed to retrieve updated user", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			return err
 		}
 
+		auditCtx = audit.WithActor(c.UserContext(), domain.AuditActor{UserID: foundUser.User_id, Email: *foundUser.Email})
+		auditRecorder.Record(auditCtx, domain.AuditResourceUser, foundUser.User_id, domain.AuditLoggedIn, nil)
+
 		return c.Status(fiber.StatusOK).JSON(foundUser)
+	})
+}
+
+// auditedUserFields are the user fields an audit record may hold, never passwords or tokens
+func auditedUserFields(user *domain.User) map[string]any {
+	return map[string]any{
+		"email":      user.Email,
+		"first_name": user.First_name,
+		"last_name":  user.Last_name,
+		"phone":      user.Phone,
+		"user_type":  user.User_type,
+	}
+}
+
+type ChangeRoleRequest struct {
+	UserType string `json:"user_type" validate:"required,oneof=ADMIN DISPATCHER DRIVER PASSENGER"`
+}
+
+type ChangeRoleResponse struct {
+	UserID   string `json:"user_id"`
+	UserType string `json:"user_type"`
+}
+
+// ChangeRole godoc
+// @Summary      Change a user's role
+// @Description  Grants a role to a user, the only way to become ADMIN or DISPATCHER. The user's session is revoked, the new role applies from the next login.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string             true  "User ID"
+// @Param        role  body      ChangeRoleRequest  true  "New role"
+// @Success      200  {object}  ChangeRoleResponse
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Only admins may change roles"
+// @Failure 404 {object} map[string]string "User not found"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router       /user/{id}/role [put]
+func ChangeRole(userRepo *helpers.TokenHelper, auditRecorder *audit.Recorder) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(c.UserContext(), 10*time.Second)
+		defer cancel()
+
+		var req ChangeRoleRequest
+		if err := c.BodyParser(&req); err != nil {
+			return domain.ErrInvalidRequest.WithMessage("Invalid request body").Wrap(err)
+		}
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		uid := c.Params("id")
+		user, err := userRepo.Users.GetUserByID(ctx, uid)
+		if err != nil {
+			return err
+		}
+
+		if err := userRepo.Users.SetUserType(ctx, uid, req.UserType); err != nil {
+			return err
+		}
+		// tokens carry the role, the old ones must not outlive the change
+		if err := userRepo.RevokeSession(ctx, uid); err != nil {
+			return fmt.Errorf("revoking session: %w", err)
+		}
+
+		auditRecorder.Record(c.UserContext(), domain.AuditResourceUser, uid, domain.AuditRoleChanged, []domain.FieldChange{
+			{Field: "user_type", Before: user.User_type, After: req.UserType},
+		})
+
+		return c.Status(fiber.StatusOK).JSON(ChangeRoleResponse{UserID: uid, UserType: req.UserType})
+	}
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type TokenPairResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken godoc
+// @Summary      Refresh tokens
+// @Description  Exchanges a refresh token for a new token pair. Every refresh token works once, reusing one revokes the session.
+// @Tags         auth
+// @Accept       json
+// @Produce      json
+// @Param        body  body      RefreshTokenRequest  true  "Refresh token"
+// @Success      200  {object}  TokenPairResponse
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 401 {object} map[string]string "Invalid, revoked or reused refresh token"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router       /token/refresh [post]
+func RefreshToken(userRepo *helpers.TokenHelper) fiber.Handler {
+	return countAuth("refresh", func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		var req RefreshTokenRequest
+		if err := c.BodyParser(&req); err != nil {
+			return domain.ErrInvalidRequest.WithMessage("Invalid request body").Wrap(err)
+		}
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		token, refreshToken, err := userRepo.RotateTokens(ctx, req.RefreshToken)
+		if errors.Is(err, helpers.ErrRefreshTokenReuse) {
+			applog.FromContext(c.UserContext()).Warn("Refresh token reuse detected, session revoked", zap.String("ip", c.IP()))
+		}
+		if err != nil {
+			return err
+		}
+
+		return c.Status(fiber.StatusOK).JSON(TokenPairResponse{Token: token, RefreshToken: refreshToken})
+	})
+}
+
+// Logout godoc
+// @Summary      User logout
+// @Description  Revokes the presented access token and the user's refresh token.
+// @Tags         auth
+// @Produce      json
+// @Success      204
+// @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 500 {object} map[string]string "Internal server error"
+// @Router       /logout [post]
+func Logout(userRepo *helpers.TokenHelper) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
+		defer cancel()
+
+		claims, _ := c.Locals("claims").(*helpers.SignedDetails)
+		if claims == nil {
+			return domain.ErrUnauthorized.WithMessage("No Authorization token provided")
+		}
+
+		// the presented token may not be the stored one, e.g. after a login on another device
+		if err := userRepo.RevokeClaims(ctx, claims); err != nil {
+			return fmt.Errorf("revoking token: %w", err)
+		}
+		if err := userRepo.RevokeSession(ctx, claims.Uid); err != nil {
+			return fmt.Errorf("revoking session: %w", err)
+		}
+
+		applog.FromContext(c.UserContext()).Info("User logged out")
+		return c.SendStatus(fiber.StatusNoContent)
+	}
+}
+
+// JWKS godoc
+// @Summary      Token verification keys
+// @Description  Public keys TaxiHub tokens are signed with, in JWKS form. HMAC keys are not listed.
+// @Tags         auth
+// @Produce      json
+// @Success      200  {object}  helpers.JWKS
+// @Router       /.well-known/jwks.json [get]
+func JWKS(userRepo *helpers.TokenHelper) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// keys only change on restart, let verifiers cache them for a while
+		c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+		return c.Status(fiber.StatusOK).JSON(userRepo.Keys.JWKS())
 	}
 }
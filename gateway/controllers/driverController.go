@@ -1,155 +1,488 @@
 package controllers
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/hekanemre/taxihub/application/audit"
 	application "github.com/hekanemre/taxihub/application/driver"
-	"github.com/hekanemre/taxihub/infrastructure"
-	"go.uber.org/zap"
+	"github.com/hekanemre/taxihub/application/validation"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/metrics"
 )
 
-func CreateDriver(driverRepo *infrastructure.MongoRepository) fiber.Handler {
+func CreateDriver(driverRepo application.Repository) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 
 		createDriverHandler := application.NewCreateDriverHandler(driverRepo)
-		GetDriverByPlateHandler := application.NewGetDriverByPlateHandler(driverRepo)
-
-		var validationReq application.GetDriverByPlateRequest
-		if err := c.BodyParser(&validationReq); err != nil {
-			zap.L().Error("Failed to parse request body for validation", zap.Error(err))
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
-		}
-		validationRes, validationErr := GetDriverByPlateHandler.Handle(c.UserContext(), &validationReq)
-
-		if validationErr == nil && validationRes != nil {
-			zap.L().Error("Driver with the same plate already exists", zap.String("plate", validationReq.Plate))
-			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "Driver with the same plate already exists"})
-		}
 
 		var req application.CreateDriverRequest
 		if err := c.BodyParser(&req); err != nil {
-			zap.L().Error("Failed to parse request body", zap.Error(err))
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+			return domain.ErrInvalidRequest.WithMessage("Invalid request body").Wrap(err)
+		}
+		if err := validation.Struct(&req); err != nil {
+			return err
 		}
 
+		// duplicate plates are rejected by the unique plate index, a lookup first would race
 		res, err := createDriverHandler.Handle(c.UserContext(), &req)
 		if err != nil {
-			zap.L().Error("Failed to create driver", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			return err
 		}
 
 		return c.Status(fiber.StatusCreated).JSON(res)
 	}
 }
 
-func UpdateDriver(driverRepo *infrastructure.MongoRepository) fiber.Handler {
+func UpdateDriver(driverRepo application.Repository) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 
 		updateDriverHandler := application.NewUpdateDriverHandler(driverRepo)
 		var req application.UpdateDriverRequest
 		if err := c.BodyParser(&req); err != nil {
-			zap.L().Error("Failed to parse request body", zap.Error(err))
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+			return domain.ErrInvalidRequest.WithMessage("Invalid request body").Wrap(err)
 		}
+		ifMatch, err := ifMatchVersion(c)
+		if err != nil {
+			return err
+		}
+		req.IfMatch = ifMatch
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
 		res, err := updateDriverHandler.Handle(c.UserContext(), &req)
 		if err != nil {
-			zap.L().Error("Failed to update driver", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			return err
 		}
+		c.Set(fiber.HeaderETag, driverETag(res.Driver))
 		return c.Status(fiber.StatusOK).JSON(res)
 	}
 }
 
-func GetAllDrivers(driverRepo *infrastructure.MongoRepository) fiber.Handler {
+// PatchDriver takes an RFC 7396 merge patch, sent as application/merge-patch+json or application/json
+func PatchDriver(driverRepo application.Repository) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 
-		var req application.GetAllFilterRequest
+		patchDriverHandler := application.NewPatchDriverHandler(driverRepo)
+
+		contentType := utils.ToLower(utils.UnsafeString(c.Request().Header.ContentType()))
+		if mime, _, _ := strings.Cut(contentType, ";"); mime != mimeMergePatch && mime != fiber.MIMEApplicationJSON {
+			return fiber.ErrUnsupportedMediaType
+		}
+
+		ifMatch, err := ifMatchVersion(c)
+		if err != nil {
+			return err
+		}
 
-		page := c.QueryInt("page", 1)
-		pageSize := c.QueryInt("pageSize", 20)
+		req := application.PatchDriverRequest{
+			DriverID: c.Params("id"),
+			Patch:    bytes.Clone(c.Body()),
+			IfMatch:  ifMatch,
+		}
 
-		req.Page = page
-		req.PageSize = pageSize
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := patchDriverHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderETag, driverETag(res.Driver))
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+const mimeMergePatch = "application/merge-patch+json"
+
+// driverETag is the strong ETag of a driver, its version
+func driverETag(driver *domain.Driver) string {
+	return `"` + strconv.FormatInt(driver.Version, 10) + `"`
+}
+
+// ifMatchVersion reads the driver version an If-Match header requires, nil without the header or for "*".
+// Anything but one of our ETags, weak ones included, can never match and fails the precondition.
+func ifMatchVersion(c *fiber.Ctx) (*int64, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	unquoted, ok := strings.CutPrefix(header, `"`)
+	if !ok {
+		return nil, domain.ErrDriverVersionMismatch
+	}
+	unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	if !ok {
+		return nil, domain.ErrDriverVersionMismatch
+	}
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if err != nil {
+		return nil, domain.ErrDriverVersionMismatch
+	}
+	return &version, nil
+}
+
+func GetAllDrivers(driverRepo application.Repository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		req := application.GetAllFilterRequest{
+			Cursor:   c.Query("cursor"),
+			PageSize: c.QueryInt("pageSize", 20),
+			SortBy:   c.Query("sortBy", application.SortByCreatedAt),
+			Order:    c.Query("order", "asc"),
+			TaxiType: c.Query("taxiType"),
+			CarBrand: c.Query("carBrand"),
+			Status:   c.Query("status"),
+		}
+
+		if from := c.Query("createdFrom"); from != "" {
+			parsed, err := time.Parse(time.RFC3339, from)
+			if err != nil {
+				return domain.ErrInvalidRequest.WithMessage("Invalid 'createdFrom' query parameter, expected RFC3339").Wrap(err)
+			}
+			req.CreatedFrom = &parsed
+		}
+
+		if to := c.Query("createdTo"); to != "" {
+			parsed, err := time.Parse(time.RFC3339, to)
+			if err != nil {
+				return domain.ErrInvalidRequest.WithMessage("Invalid 'createdTo' query parameter, expected RFC3339").Wrap(err)
+			}
+			req.CreatedTo = &parsed
+		}
 
 		getAllDriversHandler := application.NewGetAllDriverHandler(driverRepo)
 
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
 		res, err := getAllDriversHandler.Handle(c.UserContext(), &req)
 		if err != nil {
-			zap.L().Error("Failed to get all drivers", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			return err
 		}
 		return c.Status(fiber.StatusOK).JSON(res)
 	}
 }
 
-func GetAllDriversNearby(driverRepo *infrastructure.MongoRepository) fiber.Handler {
+func SearchDrivers(driverRepo application.Repository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		searchDriversHandler := application.NewSearchDriversHandler(driverRepo)
+
+		req := application.SearchDriversRequest{
+			Query: c.Query("q"),
+			Limit: c.QueryInt("limit", 10),
+		}
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := searchDriversHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+func GetAllDriversNearby(driverRepo application.Repository) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 
 		getAllDriversNearbyHandler := application.NewGetAllDriverNearbyHandler(driverRepo)
 
 		latStr := c.Params("lat")
 		if latStr == "" {
-			zap.L().Error("Missing 'lat' query parameter")
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Missing 'lat' query parameter"})
+			return domain.ErrInvalidRequest.WithMessage("Missing 'lat' query parameter")
 		}
 		lat, err := strconv.ParseFloat(latStr, 64)
 		if err != nil {
-			zap.L().Error("Invalid 'lat' query parameter", zap.Error(err))
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid 'lat' query parameter"})
+			return domain.ErrInvalidRequest.WithMessage("Invalid 'lat' query parameter").Wrap(err)
 		}
 
 		lonStr := c.Params("lon")
 		if lonStr == "" {
-			zap.L().Error("Missing 'lon' query parameter")
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Missing 'lon' query parameter"})
+			return domain.ErrInvalidRequest.WithMessage("Missing 'lon' query parameter")
 		}
 		lon, err := strconv.ParseFloat(lonStr, 64)
 		if err != nil {
-			zap.L().Error("Invalid 'lon' query parameter", zap.Error(err))
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid 'lon' query parameter"})
+			return domain.ErrInvalidRequest.WithMessage("Invalid 'lon' query parameter").Wrap(err)
 		}
 
 		taxiType := c.Params("taxiType")
 		if taxiType == "" {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Missing 'taxiType' query parameter"})
+			return domain.ErrInvalidRequest.WithMessage("Missing 'taxiType' query parameter")
 		}
 
 		req := &application.GetAllDriverNearbyRequest{
-			Lat:      lat,
-			Lon:      lon,
-			TaxiType: taxiType,
+			Lat:                lat,
+			Lon:                lon,
+			TaxiType:           taxiType,
+			IncludeUnavailable: c.QueryBool("includeUnavailable", false),
+		}
+
+		if err := validation.Struct(req); err != nil {
+			return err
 		}
 
 		res, err := getAllDriversNearbyHandler.Handle(c.UserContext(), req)
 		if err != nil {
-			zap.L().Error("Failed to get all nearby drivers", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			return err
 		}
+		metrics.NearbySearchResults.WithLabelValues(req.TaxiType).Observe(float64(len(res)))
 
 		return c.Status(fiber.StatusOK).JSON(res)
 	}
 }
 
-func GetDriverByID(driverRepo *infrastructure.MongoRepository) fiber.Handler {
+func GetDriverByID(driverRepo application.Repository) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 
 		getDriverByIDHandler := application.NewGetDriverHandler(driverRepo)
 
 		var req application.GetDriverRequest
 
-		id := c.Params("id")
-		if id == "" {
-			zap.L().Error("Missing 'id' parameter")
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "missing id parameter"})
+		req.ID = c.Params("id")
+
+		if err := validation.Struct(&req); err != nil {
+			return err
 		}
-		req.ID = id
 
 		res, err := getDriverByIDHandler.Handle(c.UserContext(), &req)
 		if err != nil {
-			zap.L().Error("Failed to get driver by ID", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+			return err
+		}
+		etag := driverETag(res.Driver)
+		c.Set(fiber.HeaderETag, etag)
+		// the client already has this version
+		if c.Get(fiber.HeaderIfNoneMatch) == etag {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+// DeleteDriver serves the delete and deactivate routes, which one is fixed per route
+func DeleteDriver(driverRepo application.Repository, deactivate bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		deleteDriverHandler := application.NewDeleteDriverHandler(driverRepo)
+
+		var req application.DeleteDriverRequest
+		if err := c.BodyParser(&req); err != nil {
+			return domain.ErrInvalidRequest.WithMessage("Invalid request body").Wrap(err)
+		}
+		req.DriverID = c.Params("id")
+		req.Deactivate = deactivate
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := deleteDriverHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+func RestoreDriver(driverRepo application.Repository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		restoreDriverHandler := application.NewRestoreDriverHandler(driverRepo)
+
+		req := application.RestoreDriverRequest{DriverID: c.Params("id")}
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := restoreDriverHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
 		}
 		return c.Status(fiber.StatusOK).JSON(res)
 	}
 }
+
+func GetDriverHistory(auditRepo audit.Repository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		getHistoryHandler := audit.NewGetHistoryHandler(auditRepo)
+
+		req := audit.GetHistoryRequest{
+			Resource:   domain.AuditResourceDriver,
+			ResourceID: c.Params("id"),
+			Cursor:     c.Query("cursor"),
+			PageSize:   c.QueryInt("pageSize", 20),
+		}
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := getHistoryHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+func StartShift(driverRepo application.Repository, shiftRepo application.ShiftRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		startShiftHandler := application.NewStartShiftHandler(driverRepo, shiftRepo)
+
+		req := application.StartShiftRequest{DriverID: c.Params("id")}
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := startShiftHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+func EndShift(driverRepo application.Repository, shiftRepo application.ShiftRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		endShiftHandler := application.NewEndShiftHandler(driverRepo, shiftRepo)
+
+		req := application.EndShiftRequest{DriverID: c.Params("id")}
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := endShiftHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+// ChangeDriverStatus serves the online, offline and break routes, the target status is fixed per route
+func ChangeDriverStatus(driverRepo application.Repository, shiftRepo application.ShiftRepository, status domain.DriverStatus) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		changeDriverStatusHandler := application.NewChangeDriverStatusHandler(driverRepo, shiftRepo)
+
+		req := application.ChangeDriverStatusRequest{
+			DriverID: c.Params("id"),
+			Status:   status,
+		}
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := changeDriverStatusHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+func GetDriverShifts(shiftRepo application.ShiftRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		getDriverShiftsHandler := application.NewGetDriverShiftsHandler(shiftRepo)
+
+		now := time.Now()
+		req := application.GetDriverShiftsRequest{
+			DriverID: c.Params("id"),
+			From:     now.AddDate(0, 0, -7),
+			To:       now,
+		}
+
+		if from := c.Query("from"); from != "" {
+			parsed, err := time.Parse(time.RFC3339, from)
+			if err != nil {
+				return domain.ErrInvalidRequest.WithMessage("Invalid 'from' query parameter, expected RFC3339").Wrap(err)
+			}
+			req.From = parsed
+		}
+
+		if to := c.Query("to"); to != "" {
+			parsed, err := time.Parse(time.RFC3339, to)
+			if err != nil {
+				return domain.ErrInvalidRequest.WithMessage("Invalid 'to' query parameter, expected RFC3339").Wrap(err)
+			}
+			req.To = parsed
+		}
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := getDriverShiftsHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+// IngestLocation accepts either a single ping object or an array of pings
+func IngestLocation(batcher *application.LocationBatcher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		ingestLocationHandler := application.NewIngestLocationHandler(batcher)
+
+		req := application.IngestLocationRequest{DriverID: c.Params("id")}
+
+		body := bytes.TrimSpace(c.Body())
+		if len(body) > 0 && body[0] == '[' {
+			if err := json.Unmarshal(body, &req.Pings); err != nil {
+				return domain.ErrInvalidRequest.WithMessage("Invalid request body").Wrap(err)
+			}
+		} else {
+			var ping application.LocationPing
+			if err := json.Unmarshal(body, &ping); err != nil {
+				return domain.ErrInvalidRequest.WithMessage("Invalid request body").Wrap(err)
+			}
+			req.Pings = []application.LocationPing{ping}
+		}
+
+		if len(req.Pings) == 0 {
+			return domain.ErrInvalidRequest.WithMessage("No location pings provided")
+		}
+		if len(req.Pings) > application.MaxPingsPerRequest {
+			return domain.ErrInvalidRequest.WithMessage(fmt.Sprintf("At most %d pings per request", application.MaxPingsPerRequest))
+		}
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := ingestLocationHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+
+		// nothing usable in the request
+		if len(res.Rejected) == len(req.Pings) {
+			return c.Status(fiber.StatusBadRequest).JSON(res)
+		}
+		return c.Status(fiber.StatusAccepted).JSON(res)
+	}
+}
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/application/healthcheck"
+)
+
+func Liveness() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		livenessHandler := healthcheck.NewHealthCheckHandler()
+
+		res, err := livenessHandler.Handle(c.UserContext(), &healthcheck.HealthCheckRequest{})
+		if err != nil {
+			return err
+		}
+		if c.Query("format") == "plain" {
+			return c.Status(fiber.StatusOK).SendString(res.Status)
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+// Readiness answers 503 when a check is down so orchestrators stop routing traffic here,
+// format=plain drops the details for probes that only look at the status code
+func Readiness(registry *healthcheck.Registry) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		readinessHandler := healthcheck.NewReadinessHandler(registry)
+
+		res, err := readinessHandler.Handle(c.UserContext(), &healthcheck.ReadinessRequest{})
+		if err != nil {
+			return err
+		}
+
+		status := fiber.StatusOK
+		if res.Status != healthcheck.StatusUp {
+			status = fiber.StatusServiceUnavailable
+		}
+		// probes hit this every few seconds, never serve a cached answer
+		c.Set(fiber.HeaderCacheControl, "no-store")
+
+		if c.Query("format") == "plain" {
+			return c.Status(status).SendString(res.Status)
+		}
+		return c.Status(status).JSON(res)
+	}
+}
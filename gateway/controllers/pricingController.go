@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/application/pricing"
+	"github.com/hekanemre/taxihub/application/validation"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/log"
+	"go.uber.org/zap"
+)
+
+func EstimateFare(tariffRepo pricing.Repository, surge pricing.SurgeProvider, settings pricing.Settings) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		estimateFareHandler := pricing.NewEstimateFareHandler(tariffRepo, surge, settings)
+
+		var req pricing.EstimateFareRequest
+		if err := c.BodyParser(&req); err != nil {
+			return domain.ErrInvalidRequest.WithMessage("Invalid request body").Wrap(err)
+		}
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := estimateFareHandler.Handle(c.UserContext(), &req)
+		if errors.Is(err, domain.ErrTariffNotFound) {
+			return domain.ErrTariffNotFound.WithMessage("No tariff for taxi type " + req.TaxiType).Wrap(err)
+		}
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+func GetTariffs(tariffRepo pricing.Repository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		getTariffsHandler := pricing.NewGetTariffsHandler(tariffRepo)
+
+		res, err := getTariffsHandler.Handle(c.UserContext(), &pricing.GetTariffsRequest{})
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+func UpdateTariff(tariffRepo pricing.Repository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		updateTariffHandler := pricing.NewUpdateTariffHandler(tariffRepo)
+
+		var tariff domain.Tariff
+		if err := c.BodyParser(&tariff); err != nil {
+			return domain.ErrInvalidRequest.WithMessage("Invalid request body").Wrap(err)
+		}
+		tariff.TaxiType = c.Params("taxiType")
+		// the body is the tariff itself, so field errors are reported without a prefix
+		if err := validation.Struct(&tariff); err != nil {
+			return err
+		}
+
+		res, err := updateTariffHandler.Handle(c.UserContext(), &pricing.UpdateTariffRequest{Tariff: tariff})
+		if err != nil {
+			return err
+		}
+
+		log.FromContext(c.UserContext()).Info("Tariff updated", zap.String("taxi_type", tariff.TaxiType))
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
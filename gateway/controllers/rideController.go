@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/application/ride"
+	"github.com/hekanemre/taxihub/application/validation"
+	"github.com/hekanemre/taxihub/domain"
+)
+
+func RequestRide(rideRepo ride.Repository, dispatcher *ride.Dispatcher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		requestRideHandler := ride.NewRequestRideHandler(rideRepo, dispatcher)
+
+		var req ride.RequestRideRequest
+		if err := c.BodyParser(&req); err != nil {
+			return domain.ErrInvalidRequest.WithMessage("Invalid request body").Wrap(err)
+		}
+		req.PassengerID, _ = c.Locals("uid").(string)
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := requestRideHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+
+		return c.Status(fiber.StatusCreated).JSON(res)
+	}
+}
+
+func GetRide(rideRepo ride.Repository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		getRideHandler := ride.NewGetRideHandler(rideRepo)
+
+		req := ride.GetRideRequest{ID: c.Params("id")}
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := getRideHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+func AcceptRide(rideRepo ride.Repository, driverRepo ride.DriverRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		acceptRideHandler := ride.NewAcceptRideHandler(rideRepo, driverRepo)
+
+		// the driver is the caller, a driver id in the body is not trusted
+		req := ride.AcceptRideRequest{ID: c.Params("id")}
+		req.UserID, _ = c.Locals("uid").(string)
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := acceptRideHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+func DeclineRide(rideRepo ride.Repository, driverRepo ride.DriverRepository, dispatcher *ride.Dispatcher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		declineRideHandler := ride.NewDeclineRideHandler(rideRepo, driverRepo, dispatcher)
+
+		req := ride.DeclineRideRequest{ID: c.Params("id")}
+		req.UserID, _ = c.Locals("uid").(string)
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := declineRideHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+func UpdateRideStatus(rideRepo ride.Repository, driverRepo ride.DriverRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		updateRideStatusHandler := ride.NewUpdateRideStatusHandler(rideRepo, driverRepo)
+
+		var req ride.UpdateRideStatusRequest
+		if err := c.BodyParser(&req); err != nil {
+			return domain.ErrInvalidRequest.WithMessage("Invalid request body").Wrap(err)
+		}
+		req.ID = c.Params("id")
+		req.UserID, _ = c.Locals("uid").(string)
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := updateRideStatusHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+func CancelRide(rideRepo ride.Repository, driverRepo ride.DriverRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		cancelRideHandler := ride.NewCancelRideHandler(rideRepo, driverRepo)
+
+		var req ride.CancelRideRequest
+		if err := c.BodyParser(&req); err != nil && !errors.Is(err, fiber.ErrUnprocessableEntity) {
+			return domain.ErrInvalidRequest.WithMessage("Invalid request body").Wrap(err)
+		}
+		req.ID = c.Params("id")
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := cancelRideHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
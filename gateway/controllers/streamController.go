@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/application/stream"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/log"
+	"go.uber.org/zap"
+)
+
+// StreamDrivers pushes driver location and status changes and driver removals as server-sent events.
+// Optional filters: taxiType and a bounding box given as minLat, minLon, maxLat, maxLon.
+//
+// StreamDrivers godoc
+// @Summary      Stream driver changes
+// @Description  Server-sent events with driver location and status changes, filterable by taxi type and bounding box. A removed event tells that the driver was deleted or deactivated.
+// @Tags         drivers
+// @Produce      text/event-stream
+// @Param        taxiType  query     string   false  "Type of taxi"
+// @Param        minLat    query     float64  false  "Bounding box south edge"
+// @Param        minLon    query     float64  false  "Bounding box west edge"
+// @Param        maxLat    query     float64  false  "Bounding box north edge"
+// @Param        maxLon    query     float64  false  "Bounding box east edge"
+// @Success      200
+// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 403 {object} map[string]string "Only dispatchers and admins may watch drivers"
+// @Router       /driver/stream [get]
+func StreamDrivers(hub *stream.Hub, heartbeat, writeTimeout time.Duration) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		filter := stream.Filter{TaxiType: c.Query("taxiType")}
+
+		bboxKeys := []string{"minLat", "minLon", "maxLat", "maxLon"}
+		given := 0
+		for _, key := range bboxKeys {
+			if c.Query(key) != "" {
+				given++
+			}
+		}
+		if given != 0 && given != len(bboxKeys) {
+			return domain.ErrInvalidRequest.WithMessage("Bounding box needs minLat, minLon, maxLat and maxLon")
+		}
+		if given == len(bboxKeys) {
+			bbox := &stream.BoundingBox{
+				MinLat: c.QueryFloat("minLat"),
+				MinLon: c.QueryFloat("minLon"),
+				MaxLat: c.QueryFloat("maxLat"),
+				MaxLon: c.QueryFloat("maxLon"),
+			}
+			if bbox.MinLat > bbox.MaxLat || bbox.MinLon > bbox.MaxLon {
+				return domain.ErrInvalidRequest.WithMessage("Invalid bounding box")
+			}
+			filter.BBox = bbox
+		}
+
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Set(fiber.HeaderConnection, "keep-alive")
+		c.Set("X-Accel-Buffering", "no") // keep proxies from buffering the stream
+
+		sub := hub.Subscribe(filter)
+		// the stream outlives the handler, the request logger goes with it
+		logger := log.FromContext(c.UserContext())
+		logger.Info("Driver stream opened", zap.String("taxi_type", filter.TaxiType))
+
+		// the server write timeout covers the whole response, for a stream it is renewed per write
+		conn := c.Context().Conn()
+
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer hub.Unsubscribe(sub)
+			defer logger.Info("Driver stream closed")
+
+			ticker := time.NewTicker(heartbeat)
+			defer ticker.Stop()
+
+			for {
+				select {
+				case event, ok := <-sub.Events:
+					if !ok {
+						// too slow or shutting down, the client is expected to reconnect
+						fmt.Fprint(w, "event: close\ndata: {}\n\n")
+						_ = w.Flush()
+						return
+					}
+
+					data, err := json.Marshal(event)
+					if err != nil {
+						logger.Error("Failed to encode driver event", zap.Error(err))
+						continue
+					}
+					fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+				case <-ticker.C:
+					fmt.Fprint(w, ": heartbeat\n\n")
+				}
+
+				_ = conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+				if err := w.Flush(); err != nil {
+					// client went away
+					return
+				}
+			}
+		})
+
+		return nil
+	}
+}
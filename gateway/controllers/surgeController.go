@@ -0,0 +1,93 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/application/surge"
+	"github.com/hekanemre/taxihub/application/validation"
+	"github.com/hekanemre/taxihub/domain"
+)
+
+func GetSurge(engine *surge.Engine, cells surge.Repository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		getSurgeHandler := surge.NewGetSurgeHandler(engine, cells)
+
+		var req surge.GetSurgeRequest
+		if err := c.QueryParser(&req); err != nil {
+			return domain.ErrInvalidRequest.WithMessage("Invalid query parameters").Wrap(err)
+		}
+		if c.Query("lat") == "" || c.Query("lon") == "" {
+			return domain.ErrInvalidRequest.WithMessage("Missing 'lat' or 'lon' query parameter")
+		}
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := getSurgeHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+func GetSurgeHeatmap(cells surge.Repository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		getHeatmapHandler := surge.NewGetHeatmapHandler(cells)
+
+		req := surge.GetHeatmapRequest{OnlySurging: c.QueryBool("onlySurging")}
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := getHeatmapHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
+
+func GetSurgeHistory(history surge.HistoryRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+
+		getSurgeHistoryHandler := surge.NewGetSurgeHistoryHandler(history)
+
+		req := surge.GetSurgeHistoryRequest{
+			Cell:  c.Query("cell"),
+			To:    time.Now(),
+			Limit: c.QueryInt("limit", 100),
+		}
+		req.From = req.To.Add(-24 * time.Hour)
+
+		if from := c.Query("from"); from != "" {
+			t, err := time.Parse(time.RFC3339, from)
+			if err != nil {
+				return domain.ErrInvalidRequest.WithMessage("Invalid 'from', expected RFC3339")
+			}
+			req.From = t
+		}
+		if to := c.Query("to"); to != "" {
+			t, err := time.Parse(time.RFC3339, to)
+			if err != nil {
+				return domain.ErrInvalidRequest.WithMessage("Invalid 'to', expected RFC3339")
+			}
+			req.To = t
+		}
+
+		if err := validation.Struct(&req); err != nil {
+			return err
+		}
+
+		res, err := getSurgeHistoryHandler.Handle(c.UserContext(), &req)
+		if err != nil {
+			return err
+		}
+		return c.Status(fiber.StatusOK).JSON(res)
+	}
+}
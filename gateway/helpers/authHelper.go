@@ -1,30 +1,100 @@
 package helpers
 
 import (
-	"errors"
+	"github.com/hekanemre/taxihub/domain"
+)
+
+type Permission string
 
-	"github.com/gofiber/fiber/v2"
+const (
+	PermDriverCreate   Permission = "driver:create"
+	PermDriverUpdate   Permission = "driver:update"
+	PermDriverList     Permission = "driver:list"
+	PermDriverRead     Permission = "driver:read"
+	PermDriverNearby   Permission = "driver:nearby"
+	PermDriverShift    Permission = "driver:shift" // shift start/end and status changes
+	PermDriverLocation Permission = "driver:location"
+	PermDriverDelete   Permission = "driver:delete" // soft delete and deactivation
+	PermDriverRestore  Permission = "driver:restore"
+	PermDriverHistory  Permission = "driver:history" // audit trail, who changed what
+	PermDriverStream   Permission = "driver:stream"  // live positions and statuses of every driver
+	PermTariffManage   Permission = "tariff:manage"
+	PermSurgeRead      Permission = "surge:read" // multiplier at a point and the heatmap
+	PermSurgeHistory   Permission = "surge:history"
+	PermRideRead       Permission = "ride:read"
+	PermRideCancel     Permission = "ride:cancel"
+	PermRideDrive      Permission = "ride:drive" // accept, decline and move a ride on, always as the caller's own driver
+	PermUserRole       Permission = "user:role"  // the only way to ADMIN and DISPATCHER
 )
 
-// CheckUserType ensures the user has the required role
-func CheckUserType(c *fiber.Ctx, role string) error {
-	userType, _ := c.Locals("user_type").(string)
+// Scope tells how far a granted permission reaches
+type Scope int
 
-	if userType != role {
-		return errors.New("unauthorized to access this resource")
-	}
+const (
+	ScopeNone Scope = iota
+	ScopeOwn        // only resources owned by the caller
+	ScopeAny
+)
 
-	return nil
+// rolePermissions is the whole access policy, a permission missing for a role is denied
+var rolePermissions = map[domain.Role]map[Permission]Scope{
+	domain.RoleAdmin: {
+		PermDriverCreate:   ScopeAny,
+		PermDriverUpdate:   ScopeAny,
+		PermDriverList:     ScopeAny,
+		PermDriverRead:     ScopeAny,
+		PermDriverNearby:   ScopeAny,
+		PermDriverShift:    ScopeAny,
+		PermDriverLocation: ScopeAny,
+		PermDriverDelete:   ScopeAny,
+		PermDriverRestore:  ScopeAny,
+		PermDriverHistory:  ScopeAny,
+		PermDriverStream:   ScopeAny,
+		PermTariffManage:   ScopeAny,
+		PermSurgeRead:      ScopeAny,
+		PermSurgeHistory:   ScopeAny,
+		PermRideRead:       ScopeAny,
+		PermRideCancel:     ScopeAny,
+		PermUserRole:       ScopeAny,
+	},
+	domain.RoleDispatcher: {
+		PermDriverCreate:  ScopeAny,
+		PermDriverUpdate:  ScopeAny,
+		PermDriverList:    ScopeAny,
+		PermDriverRead:    ScopeAny,
+		PermDriverNearby:  ScopeAny,
+		PermDriverShift:   ScopeAny,
+		PermDriverDelete:  ScopeAny,
+		PermDriverHistory: ScopeAny,
+		PermDriverStream:  ScopeAny,
+		PermSurgeRead:     ScopeAny,
+		PermSurgeHistory:  ScopeAny,
+		PermRideRead:      ScopeAny,
+		PermRideCancel:    ScopeAny,
+	},
+	domain.RoleDriver: {
+		PermDriverUpdate:   ScopeOwn,
+		PermDriverRead:     ScopeOwn,
+		PermDriverShift:    ScopeOwn,
+		PermDriverLocation: ScopeOwn,
+		PermSurgeRead:      ScopeAny, // drivers head to where demand is
+		PermRideRead:       ScopeOwn,
+		PermRideCancel:     ScopeOwn,
+		// the ride decides whether it is offered or assigned to the caller's driver
+		PermRideDrive: ScopeAny,
+	},
+	domain.RolePassenger: {
+		PermDriverNearby: ScopeAny,
+		PermRideRead:     ScopeOwn,
+		PermRideCancel:   ScopeOwn,
+	},
 }
 
-// MatchUserTypeToUid checks both role + ownership
-func MatchUserTypeToUid(c *fiber.Ctx, userId string) error {
-	userType, _ := c.Locals("user_type").(string)
-	uid, _ := c.Locals("uid").(string)
-
-	if userType == "USER" && uid != userId {
-		return errors.New("unauthorized to access this resource")
+// PermissionScope returns how far the user type may use the permission
+func PermissionScope(userType string, perm Permission) Scope {
+	role, ok := domain.ParseRole(userType)
+	if !ok {
+		return ScopeNone
 	}
-
-	return CheckUserType(c, userType)
+	return rolePermissions[role][perm]
 }
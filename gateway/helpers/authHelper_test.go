@@ -0,0 +1,59 @@
+package helpers
+
+import (
+	"testing"
+
+	"github.com/hekanemre/taxihub/domain"
+)
+
+func TestPermissionScope(t *testing.T) {
+	const (
+		none = ScopeNone
+		own  = ScopeOwn
+		all  = ScopeAny
+	)
+	roles := []domain.Role{domain.RoleAdmin, domain.RoleDispatcher, domain.RoleDriver, domain.RolePassenger}
+
+	// the expected scope for admin, dispatcher, driver and passenger
+	tests := []struct {
+		perm Permission
+		want [4]Scope
+	}{
+		{PermDriverCreate, [4]Scope{all, all, none, none}},
+		{PermDriverUpdate, [4]Scope{all, all, own, none}},
+		{PermDriverList, [4]Scope{all, all, none, none}},
+		{PermDriverRead, [4]Scope{all, all, own, none}},
+		{PermDriverNearby, [4]Scope{all, all, none, all}},
+		{PermDriverShift, [4]Scope{all, all, own, none}},
+		{PermDriverLocation, [4]Scope{all, none, own, none}},
+		{PermDriverDelete, [4]Scope{all, all, none, none}},
+		{PermDriverRestore, [4]Scope{all, none, none, none}},
+		{PermDriverHistory, [4]Scope{all, all, none, none}},
+		{PermDriverStream, [4]Scope{all, all, none, none}},
+		{PermTariffManage, [4]Scope{all, none, none, none}},
+		{PermSurgeRead, [4]Scope{all, all, all, none}},
+		{PermSurgeHistory, [4]Scope{all, all, none, none}},
+		{PermRideRead, [4]Scope{all, all, own, own}},
+		{PermRideCancel, [4]Scope{all, all, own, own}},
+		{PermRideDrive, [4]Scope{none, none, all, none}},
+		{PermUserRole, [4]Scope{all, none, none, none}},
+	}
+
+	for _, tt := range tests {
+		for i, role := range roles {
+			if got := PermissionScope(string(role), tt.perm); got != tt.want[i] {
+				t.Errorf("PermissionScope(%s, %s) = %d, want %d", role, tt.perm, got, tt.want[i])
+			}
+		}
+
+		// accounts from before roles existed are passengers, unknown user types get nothing
+		if got := PermissionScope("USER", tt.perm); got != tt.want[3] {
+			t.Errorf("PermissionScope(USER, %s) = %d, want the passenger scope %d", tt.perm, got, tt.want[3])
+		}
+		for _, userType := range []string{"", "admin", "ROOT"} {
+			if got := PermissionScope(userType, tt.perm); got != none {
+				t.Errorf("PermissionScope(%q, %s) = %d, want none", userType, tt.perm, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package helpers
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/hekanemre/taxihub/config"
+)
+
+// SigningKey is one configured key. signKey is nil for keys that only verify.
+type SigningKey struct {
+	Kid       string
+	Method    jwt.SigningMethod
+	signKey   any
+	verifyKey any
+}
+
+// KeySet holds every key tokens may be verified with and the one new tokens are signed with
+type KeySet struct {
+	signing *SigningKey
+	legacy  *SigningKey
+	keys    map[string]*SigningKey
+}
+
+// JWK is a public key in RFC 7517 form
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+func NewKeySet(signingKid, legacyKid string, keys []config.JWTKeyConfig) (*KeySet, error) {
+	set := &KeySet{
+		keys: make(map[string]*SigningKey, len(keys)),
+	}
+
+	for _, cfg := range keys {
+		if cfg.Kid == "" {
+			return nil, errors.New("jwt key without kid")
+		}
+		if _, exists := set.keys[cfg.Kid]; exists {
+			return nil, fmt.Errorf("duplicate jwt key %q", cfg.Kid)
+		}
+
+		key, err := loadKey(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("jwt key %q: %w", cfg.Kid, err)
+		}
+		set.keys[cfg.Kid] = key
+	}
+
+	set.signing = set.keys[signingKid]
+	if set.signing == nil {
+		return nil, fmt.Errorf("signing jwt key %q is not configured", signingKid)
+	}
+	if set.signing.signKey == nil {
+		return nil, fmt.Errorf("signing jwt key %q has no private key", signingKid)
+	}
+
+	if legacyKid != "" {
+		set.legacy = set.keys[legacyKid]
+		if set.legacy == nil {
+			return nil, fmt.Errorf("legacy jwt key %q is not configured", legacyKid)
+		}
+	}
+
+	return set, nil
+}
+
+func loadKey(cfg config.JWTKeyConfig) (*SigningKey, error) {
+	method := jwt.GetSigningMethod(cfg.Algorithm)
+	if method == nil {
+		return nil, fmt.Errorf("unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	key := &SigningKey{Kid: cfg.Kid, Method: method}
+
+	switch method.(type) {
+	case *jwt.SigningMethodHMAC:
+		secret, err := readKeyMaterial(cfg.Secret, "", cfg.SecretEnv)
+		if err != nil {
+			return nil, err
+		}
+		if len(secret) == 0 {
+			return nil, errors.New("missing secret")
+		}
+		key.signKey, key.verifyKey = secret, secret
+
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		private, err := readKeyMaterial("", cfg.PrivateKeyFile, cfg.PrivateKeyEnv)
+		if err != nil {
+			return nil, err
+		}
+		if len(private) > 0 {
+			privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(private)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey, key.verifyKey = privateKey, &privateKey.PublicKey
+		} else {
+			public, err := readKeyMaterial("", cfg.PublicKeyFile, cfg.PublicKeyEnv)
+			if err != nil {
+				return nil, err
+			}
+			if len(public) == 0 {
+				return nil, errors.New("missing private or public key")
+			}
+			publicKey, err := jwt.ParseRSAPublicKeyFromPEM(public)
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = publicKey
+		}
+
+	case *jwt.SigningMethodECDSA:
+		private, err := readKeyMaterial("", cfg.PrivateKeyFile, cfg.PrivateKeyEnv)
+		if err != nil {
+			return nil, err
+		}
+		if len(private) > 0 {
+			privateKey, err := jwt.ParseECPrivateKeyFromPEM(private)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey, key.verifyKey = privateKey, &privateKey.PublicKey
+		} else {
+			public, err := readKeyMaterial("", cfg.PublicKeyFile, cfg.PublicKeyEnv)
+			if err != nil {
+				return nil, err
+			}
+			if len(public) == 0 {
+				return nil, errors.New("missing private or public key")
+			}
+			publicKey, err := jwt.ParseECPublicKeyFromPEM(public)
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = publicKey
+		}
+
+		// ES256 is only defined for P-256 and so on, a mismatch would fail on every token
+		if curve := key.verifyKey.(*ecdsa.PublicKey).Curve.Params().BitSize; curve != method.(*jwt.SigningMethodECDSA).CurveBits {
+			return nil, fmt.Errorf("%s needs a %d bit curve, key has %d", cfg.Algorithm, method.(*jwt.SigningMethodECDSA).CurveBits, curve)
+		}
+
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", cfg.Algorithm)
+	}
+
+	return key, nil
+}
+
+// readKeyMaterial takes the first of inline value, file and env variable that is set
+func readKeyMaterial(inline, file, env string) ([]byte, error) {
+	switch {
+	case inline != "":
+		return []byte(inline), nil
+	case file != "":
+		return os.ReadFile(file)
+	case env != "":
+		value := os.Getenv(env)
+		if value == "" {
+			return nil, fmt.Errorf("env variable %s is empty", env)
+		}
+		return []byte(value), nil
+	}
+	return nil, nil
+}
+
+// Sign signs the claims with the current signing key and puts its kid in the header
+func (k *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(k.signing.Method, claims)
+	token.Header["kid"] = k.signing.Kid
+	return token.SignedString(k.signing.signKey)
+}
+
+// Keyfunc picks the verification key by kid. The token algorithm must match the key,
+// otherwise a public RSA key could be abused as an HMAC secret.
+func (k *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	key := k.legacy
+	if kid, ok := token.Header["kid"].(string); ok {
+		key = k.keys[kid]
+	}
+	if key == nil {
+		return nil, errors.New("unknown signing key")
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %s", token.Method.Alg())
+	}
+
+	return key.verifyKey, nil
+}
+
+// JWKS lists the public keys other services can verify tokens with. HMAC secrets are never published.
+func (k *KeySet) JWKS() JWKS {
+	jwks := JWKS{Keys: []JWK{}}
+
+	for _, key := range k.keys {
+		jwk := JWK{Kid: key.Kid, Use: "sig", Alg: key.Method.Alg()}
+
+		switch public := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (public.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = public.Curve.Params().Name
+			jwk.X = base64.RawURLEncoding.EncodeToString(public.X.FillBytes(make([]byte, size)))
+			jwk.Y = base64.RawURLEncoding.EncodeToString(public.Y.FillBytes(make([]byte, size)))
+		default:
+			continue
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+
+	return jwks
+}
@@ -2,15 +2,31 @@ package helpers
 
 import (
 	"context"
+	"errors"
 	"log"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
-	"github.com/hekanemre/taxihub/infrastructure"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
-	"go.mongodb.org/mongo-driver/mongo"
-	"go.mongodb.org/mongo-driver/mongo/options"
+	"github.com/google/uuid"
+	"github.com/hekanemre/taxihub/domain"
+)
+
+const (
+	AccessTokenType  = "access"
+	RefreshTokenType = "refresh"
+
+	accessTokenTTL  = 24 * time.Hour
+	refreshTokenTTL = 168 * time.Hour
+
+	// how many rotated refresh token ids are kept per user to detect reuse
+	rotatedRefreshTokensKept = 20
+)
+
+var (
+	ErrInvalidRefreshToken = domain.NewUnauthorizedError("INVALID_REFRESH_TOKEN", "the refresh token is invalid")
+	ErrRefreshTokenRevoked = domain.NewUnauthorizedError("REFRESH_TOKEN_REVOKED", "the refresh token is revoked")
+	// ErrRefreshTokenReuse means an already rotated refresh token came back, the session is revoked
+	ErrRefreshTokenReuse = domain.NewUnauthorizedError("REFRESH_TOKEN_REUSED", "refresh token reuse detected, please log in again")
 )
 
 type SignedDetails struct {
@@ -19,18 +35,41 @@ type SignedDetails struct {
 	Last_name  string `json:"last_name"`
 	Uid        string `json:"uid"`
 	User_type  string `json:"user_type"`
+	Token_type string `json:"token_type"` // empty on tokens issued before refresh existed, treated as access
 	jwt.RegisteredClaims
 }
 
-var SECRET_KEY = "your_secret_key_here"
+// RevocationList remembers revoked access tokens by id until they expire
+type RevocationList interface {
+	RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error
+	IsTokenRevoked(ctx context.Context, tokenID string) (bool, error)
+}
+
+// UserStore keeps the registered users together with their current token pair.
+// Lookups of a missing user return domain.ErrUserNotFound.
+type UserStore interface {
+	UserExists(ctx context.Context, email, phone string) (bool, error)
+	CreateUser(ctx context.Context, user *domain.User) error
+	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
+	GetUserByID(ctx context.Context, uid string) (*domain.User, error)
+	SetUserType(ctx context.Context, uid, userType string) error
+	SetUserTokens(ctx context.Context, uid, token, refreshToken string) error
+	// RotateUserTokens replaces the pair only if presentedRefreshToken is still the current one
+	RotateUserTokens(ctx context.Context, uid, presentedRefreshToken, token, refreshToken, usedTokenID string, keep int) (bool, error)
+	IsRefreshTokenRotated(ctx context.Context, uid, tokenID string) (bool, error)
+}
 
 type TokenHelper struct {
-	UserCollection *mongo.Collection
+	Users   UserStore
+	Revoked RevocationList
+	Keys    *KeySet
 }
 
-func NewTokenHelper(repo *infrastructure.MongoRepository) *TokenHelper {
+func NewTokenHelper(users UserStore, revoked RevocationList, keys *KeySet) *TokenHelper {
 	return &TokenHelper{
-		UserCollection: repo.DB.Collection(repo.Collection),
+		Users:   users,
+		Revoked: revoked,
+		Keys:    keys,
 	}
 }
 
@@ -38,30 +77,40 @@ func (t *TokenHelper) GenerateAllTokens(
 	email, firstName, lastName, userType, uid string,
 ) (string, string, error) {
 
+	now := time.Now()
+
 	claims := &SignedDetails{
 		Email:      email,
 		First_name: firstName,
 		Last_name:  lastName,
 		Uid:        uid,
 		User_type:  userType,
+		Token_type: AccessTokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ID:        uuid.New().String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(accessTokenTTL)),
 		},
 	}
 
+	// the refresh token only says who it belongs to, fresh user data is read on refresh
 	refreshClaims := &SignedDetails{
+		Uid:        uid,
+		Token_type: RefreshTokenType,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(168 * time.Hour)),
+			ID:        uuid.New().String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(refreshTokenTTL)),
 		},
 	}
 
-	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(SECRET_KEY))
+	token, err := t.Keys.Sign(claims)
 	if err != nil {
 		log.Println("JWT token generation error:", err)
 		return "", "", err
 	}
 
-	refreshToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims).SignedString([]byte(SECRET_KEY))
+	refreshToken, err := t.Keys.Sign(refreshClaims)
 	if err != nil {
 		log.Println("JWT refresh token generation error:", err)
 		return "", "", err
@@ -74,9 +123,7 @@ func (t *TokenHelper) ValidateToken(signedToken string) (*SignedDetails, string)
 	token, err := jwt.ParseWithClaims(
 		signedToken,
 		&SignedDetails{},
-		func(token *jwt.Token) (interface{}, error) {
-			return []byte(SECRET_KEY), nil
-		},
+		t.Keys.Keyfunc,
 	)
 
 	if err != nil {
@@ -95,24 +142,118 @@ func (t *TokenHelper) ValidateToken(signedToken string) (*SignedDetails, string)
 	return claims, ""
 }
 
+// ValidateAccessToken validates the token and makes sure it is a live access token.
+// Refresh tokens and revoked tokens are rejected.
+func (t *TokenHelper) ValidateAccessToken(ctx context.Context, signedToken string) (*SignedDetails, string) {
+	claims, msg := t.ValidateToken(signedToken)
+	if msg != "" {
+		return nil, msg
+	}
+
+	// refresh tokens issued before token types existed carry no uid at all
+	if claims.Token_type == RefreshTokenType || claims.Uid == "" {
+		return nil, "the token is not an access token"
+	}
+
+	if claims.ID != "" {
+		revoked, err := t.Revoked.IsTokenRevoked(ctx, claims.ID)
+		if err != nil {
+			log.Println("Token revocation check error:", err)
+			return nil, "could not check the token"
+		}
+		if revoked {
+			return nil, "the token is revoked"
+		}
+	}
+
+	return claims, ""
+}
+
 func (t *TokenHelper) UpdateAllTokens(signedToken, signedRefreshToken, userId string) {
 	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Second)
 	defer cancel()
 
-	updateObj := primitive.D{
-		{Key: "token", Value: signedToken},
-		{Key: "refresh_token", Value: signedRefreshToken},
-		{Key: "updated_at", Value: time.Now()},
+	if err := t.Users.SetUserTokens(ctx, userId, signedToken, signedRefreshToken); err != nil {
+		log.Println("User token update error:", err)
+	}
+}
+
+// RotateTokens exchanges a refresh token for a new token pair.
+// The presented refresh token is used up, presenting it again revokes the whole session.
+func (t *TokenHelper) RotateTokens(ctx context.Context, signedRefreshToken string) (string, string, error) {
+	claims, msg := t.ValidateToken(signedRefreshToken)
+	if msg != "" || claims.Token_type != RefreshTokenType || claims.Uid == "" || claims.ID == "" {
+		return "", "", ErrInvalidRefreshToken
+	}
+
+	user, err := t.Users.GetUserByID(ctx, claims.Uid)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return "", "", ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return "", "", err
+	}
+
+	token, refreshToken, err := t.GenerateAllTokens(*user.Email, *user.First_name, *user.Last_name, *user.User_type, user.User_id)
+	if err != nil {
+		return "", "", err
 	}
 
-	filter := bson.M{"user_id": userId}
-	upsert := true
-	opts := options.UpdateOptions{
-		Upsert: &upsert,
+	// only the current refresh token matches, two concurrent refreshes can not both win
+	rotated, err := t.Users.RotateUserTokens(ctx, claims.Uid, signedRefreshToken, token, refreshToken, claims.ID, rotatedRefreshTokensKept)
+	if err != nil {
+		return "", "", err
+	}
+	if rotated {
+		return token, refreshToken, nil
 	}
 
-	_, err := t.UserCollection.UpdateOne(ctx, filter, bson.D{{Key: "$set", Value: updateObj}}, &opts)
+	// not the current one, find out whether it was rotated before
+	reused, err := t.Users.IsRefreshTokenRotated(ctx, claims.Uid, claims.ID)
 	if err != nil {
-		log.Println("MongoDB token update error:", err)
+		return "", "", err
+	}
+	if !reused {
+		return "", "", ErrRefreshTokenRevoked
+	}
+
+	// somebody kept a copy of a used token, assume the session is stolen
+	if err := t.RevokeSession(ctx, claims.Uid); err != nil {
+		return "", "", err
+	}
+	return "", "", ErrRefreshTokenReuse
+}
+
+// RevokeSession logs the user out: the stored access token is denylisted and the refresh token dropped
+func (t *TokenHelper) RevokeSession(ctx context.Context, uid string) error {
+	user, err := t.Users.GetUserByID(ctx, uid)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if user.Token != nil && *user.Token != "" {
+		if claims, msg := t.ValidateToken(*user.Token); msg == "" {
+			if err := t.RevokeClaims(ctx, claims); err != nil {
+				return err
+			}
+		}
+	}
+
+	return t.Users.SetUserTokens(ctx, uid, "", "")
+}
+
+// RevokeClaims denylists a single token until it would have expired anyway
+func (t *TokenHelper) RevokeClaims(ctx context.Context, claims *SignedDetails) error {
+	if claims.ID == "" {
+		return nil
+	}
+
+	expiresAt := time.Now().Add(accessTokenTTL)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
 	}
+	return t.Revoked.RevokeToken(ctx, claims.ID, expiresAt)
 }
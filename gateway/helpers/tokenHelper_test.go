@@ -0,0 +1,131 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hekanemre/taxihub/config"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/infrastructure"
+)
+
+func newTestTokenHelper(t *testing.T) (*TokenHelper, *infrastructure.MemoryRepository) {
+	t.Helper()
+
+	keys, err := NewKeySet("test", "", []config.JWTKeyConfig{
+		{Kid: "test", Algorithm: "HS256", Secret: "test-secret"},
+	})
+	if err != nil {
+		t.Fatalf("NewKeySet: %v", err)
+	}
+
+	repo := infrastructure.NewMemoryRepository(1000)
+	return NewTokenHelper(repo, repo, keys), repo
+}
+
+// signup stores a user with a fresh token pair the way the signup handler does
+func signup(t *testing.T, tokens *TokenHelper, repo *infrastructure.MemoryRepository, uid string) (string, string) {
+	t.Helper()
+
+	email, firstName, lastName, phone, userType := uid+"@example.com", "Ada", "Lovelace", "+"+uid, "PASSENGER"
+	token, refreshToken, err := tokens.GenerateAllTokens(email, firstName, lastName, userType, uid)
+	if err != nil {
+		t.Fatalf("GenerateAllTokens: %v", err)
+	}
+
+	err = repo.CreateUser(context.Background(), &domain.User{
+		First_name:    &firstName,
+		Last_name:     &lastName,
+		Email:         &email,
+		Phone:         &phone,
+		User_type:     &userType,
+		User_id:       uid,
+		Token:         &token,
+		Refresh_token: &refreshToken,
+	})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return token, refreshToken
+}
+
+func TestTokenHelper_RotateTokens(t *testing.T) {
+	ctx := context.Background()
+	tokens, repo := newTestTokenHelper(t)
+	_, refreshToken := signup(t, tokens, repo, "u1")
+
+	token, nextRefreshToken, err := tokens.RotateTokens(ctx, refreshToken)
+	if err != nil {
+		t.Fatalf("RotateTokens: %v", err)
+	}
+
+	claims, msg := tokens.ValidateAccessToken(ctx, token)
+	if msg != "" {
+		t.Fatalf("ValidateAccessToken of the rotated token: %s", msg)
+	}
+	if claims.Uid != "u1" || claims.User_type != "PASSENGER" {
+		t.Fatalf("rotated claims = %s %s, want u1 PASSENGER", claims.Uid, claims.User_type)
+	}
+
+	// a refresh token is not an access token
+	if _, msg := tokens.ValidateAccessToken(ctx, nextRefreshToken); msg == "" {
+		t.Fatal("ValidateAccessToken accepted a refresh token")
+	}
+}
+
+func TestTokenHelper_RotateTokensReuseRevokesSession(t *testing.T) {
+	ctx := context.Background()
+	tokens, repo := newTestTokenHelper(t)
+	_, refreshToken := signup(t, tokens, repo, "u1")
+
+	token, nextRefreshToken, err := tokens.RotateTokens(ctx, refreshToken)
+	if err != nil {
+		t.Fatalf("RotateTokens: %v", err)
+	}
+
+	if _, _, err := tokens.RotateTokens(ctx, refreshToken); !errors.Is(err, ErrRefreshTokenReuse) {
+		t.Fatalf("RotateTokens with a used token = %v, want ErrRefreshTokenReuse", err)
+	}
+
+	// the whole session is gone: the current access token is denylisted and the refresh token dropped
+	if _, msg := tokens.ValidateAccessToken(ctx, token); msg == "" {
+		t.Fatal("access token still valid after refresh token reuse")
+	}
+	if _, _, err := tokens.RotateTokens(ctx, nextRefreshToken); !errors.Is(err, ErrRefreshTokenRevoked) {
+		t.Fatalf("RotateTokens after the session was revoked = %v, want ErrRefreshTokenRevoked", err)
+	}
+}
+
+func TestTokenHelper_RotateTokensUnknownUser(t *testing.T) {
+	tokens, _ := newTestTokenHelper(t)
+
+	_, refreshToken, err := tokens.GenerateAllTokens("", "", "", "", "missing")
+	if err != nil {
+		t.Fatalf("GenerateAllTokens: %v", err)
+	}
+	if _, _, err := tokens.RotateTokens(context.Background(), refreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("RotateTokens of an unknown user = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestTokenHelper_RevokeSession(t *testing.T) {
+	ctx := context.Background()
+	tokens, repo := newTestTokenHelper(t)
+	token, refreshToken := signup(t, tokens, repo, "u1")
+
+	if err := tokens.RevokeSession(ctx, "u1"); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if _, msg := tokens.ValidateAccessToken(ctx, token); msg == "" {
+		t.Fatal("access token still valid after logout")
+	}
+	if _, _, err := tokens.RotateTokens(ctx, refreshToken); !errors.Is(err, ErrRefreshTokenRevoked) {
+		t.Fatalf("RotateTokens after logout = %v, want ErrRefreshTokenRevoked", err)
+	}
+
+	// logging out somebody unknown is not an error
+	if err := tokens.RevokeSession(ctx, "missing"); err != nil {
+		t.Fatalf("RevokeSession of an unknown user: %v", err)
+	}
+}
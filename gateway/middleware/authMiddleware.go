@@ -1,10 +1,11 @@
 package middleware
 
 import (
-	"net/http"
-
 	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/application/audit"
+	"github.com/hekanemre/taxihub/domain"
 	"github.com/hekanemre/taxihub/gateway/helpers"
+	"go.uber.org/zap"
 )
 
 // Authenticate returns a Fiber middleware that checks JWT tokens
@@ -12,16 +13,12 @@ func Authenticate(tokenHelper *helpers.TokenHelper) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		clientToken := c.Get("token")
 		if clientToken == "" {
-			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-				"error": "No Authorization token provided",
-			})
+			return domain.ErrUnauthorized.WithMessage("No Authorization token provided")
 		}
 
-		claims, errStr := tokenHelper.ValidateToken(clientToken)
+		claims, errStr := tokenHelper.ValidateAccessToken(c.UserContext(), clientToken)
 		if errStr != "" {
-			return c.Status(http.StatusUnauthorized).JSON(fiber.Map{
-				"error": errStr,
-			})
+			return domain.ErrUnauthorized.WithMessage(errStr)
 		}
 
 		// store user info in request context
@@ -30,6 +27,9 @@ func Authenticate(tokenHelper *helpers.TokenHelper) fiber.Handler {
 		c.Locals("last_name", claims.Last_name)
 		c.Locals("uid", claims.Uid)
 		c.Locals("user_type", claims.User_type)
+		c.Locals("claims", claims)
+		withLogFields(c, zap.String("uid", claims.Uid))
+		c.SetUserContext(audit.WithActor(c.UserContext(), domain.AuditActor{UserID: claims.Uid, Email: claims.Email}))
 
 		return c.Next()
 	}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/hekanemre/taxihub/application"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/log"
+	"go.uber.org/zap"
+)
+
+var kindStatus = map[domain.ErrorKind]int{
+	domain.KindNotFound:           fiber.StatusNotFound,
+	domain.KindConflict:           fiber.StatusConflict,
+	domain.KindValidation:         fiber.StatusBadRequest,
+	domain.KindUnauthorized:       fiber.StatusUnauthorized,
+	domain.KindForbidden:          fiber.StatusForbidden,
+	domain.KindUnavailable:        fiber.StatusServiceUnavailable,
+	domain.KindInvalidField:       fiber.StatusUnprocessableEntity,
+	domain.KindRateLimited:        fiber.StatusTooManyRequests,
+	domain.KindPreconditionFailed: fiber.StatusPreconditionFailed,
+}
+
+// ErrorHandler is the single place errors returned by handlers become responses.
+// Domain errors keep their code and message, anything unexpected is logged and hidden behind INTERNAL_ERROR.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	// the request logger already carries request id, method, path and uid
+	logger := log.FromContext(c.UserContext())
+	fields := []zap.Field{zap.Error(err)}
+
+	if errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, domain.ErrDatabaseUnavailable) {
+		err = domain.ErrTimeout.Wrap(err)
+	}
+
+	var domainErr *domain.Error
+	if errors.As(err, &domainErr) {
+		status, ok := kindStatus[domainErr.Kind]
+		if !ok {
+			status = fiber.StatusInternalServerError
+		}
+
+		if status >= fiber.StatusInternalServerError {
+			logger.Error("Request failed", fields...)
+		} else {
+			logger.Info("Request rejected", append(fields, zap.String("code", domainErr.Code))...)
+		}
+		if status == fiber.StatusServiceUnavailable {
+			c.Set(fiber.HeaderRetryAfter, "1")
+		}
+
+		return c.Status(status).JSON(application.ErrorResponse{Code: domainErr.Code, Error: domainErr.Message, Fields: domainErr.Fields})
+	}
+
+	// routing errors, body limits and friends
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		logger.Info("Request rejected", fields...)
+		return c.Status(fiberErr.Code).JSON(application.ErrorResponse{Code: statusCode(fiberErr.Code), Error: fiberErr.Message})
+	}
+
+	logger.Error("Request failed", fields...)
+	return c.Status(fiber.StatusInternalServerError).JSON(application.ErrorResponse{Code: "INTERNAL_ERROR", Error: "Internal server error"})
+}
+
+// handleError runs the error handler for middleware that needs the final status, so the
+// error is answered once and middleware further out sees no error
+func handleError(c *fiber.Ctx, err error) {
+	if err == nil {
+		return
+	}
+	if handlerErr := c.App().Config().ErrorHandler(c, err); handlerErr != nil {
+		_ = c.SendStatus(fiber.StatusInternalServerError)
+	}
+}
+
+// statusCode derives a code from the status text, 404 becomes NOT_FOUND
+func statusCode(status int) string {
+	return strings.ToUpper(strings.ReplaceAll(utils.StatusMessage(status), " ", "_"))
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/metrics"
+)
+
+// Metrics records count and latency of every request by route pattern, /driver/:id instead of the raw path
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		started := time.Now()
+		metrics.HTTPRequestsInFlight.Inc()
+		defer metrics.HTTPRequestsInFlight.Dec()
+
+		handleError(c, c.Next())
+
+		route := c.Route().Path
+		status := c.Response().StatusCode()
+		// no endpoint matched, labelling the raw path would give every scanner probe its own series
+		if route == "/" && c.Path() != "/" {
+			route = "unmatched"
+		}
+
+		// fiber reuses the method buffer for the next request, the label must own its copy
+		labels := []string{strings.Clone(c.Method()), route, strconv.Itoa(status)}
+		metrics.HTTPRequests.WithLabelValues(labels...).Inc()
+		metrics.HTTPRequestDuration.WithLabelValues(labels...).Observe(time.Since(started).Seconds())
+		return nil
+	}
+}
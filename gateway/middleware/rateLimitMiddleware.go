@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/config"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/log"
+	"go.uber.org/zap"
+)
+
+// RateLimitStore keeps token buckets by key. TakeToken refills the bucket of key for the time since
+// it was last used, at rate tokens per second up to burst, then takes a token if one is left.
+// It returns the tokens left afterwards.
+type RateLimitStore interface {
+	TakeToken(ctx context.Context, key string, rate float64, burst int) (tokens float64, allowed bool, err error)
+}
+
+type RateLimiter struct {
+	store  RateLimitStore
+	groups map[string]config.RateLimitGroup
+}
+
+// NewRateLimiter checks the limits of every group, zero requests disables a limit
+// but a limit with requests needs a positive per, the refill rate would be infinite otherwise
+func NewRateLimiter(store RateLimitStore, groups map[string]config.RateLimitGroup) (*RateLimiter, error) {
+	for name, group := range groups {
+		for key, limit := range map[string]config.RateLimit{"perIP": group.PerIP, "perUser": group.PerUser} {
+			if limit.Requests > 0 && limit.Per <= 0 {
+				return nil, fmt.Errorf("rate limit group %s: %s.per must be positive when %s.requests is set", name, key, key)
+			}
+		}
+	}
+
+	return &RateLimiter{
+		store:  store,
+		groups: groups,
+	}, nil
+}
+
+// bucketState is what the X-RateLimit headers report about one bucket
+type bucketState struct {
+	burst   int
+	rate    float64
+	tokens  float64
+	allowed bool
+}
+
+// RateLimit limits the routes of group per client IP and, after Authenticate, per uid.
+// A nil limiter or a group missing from config does not limit. When the store fails
+// requests are let through, an outage of the store must not take the API down with it.
+func RateLimit(limiter *RateLimiter, group string) fiber.Handler {
+	var limits config.RateLimitGroup
+	ok := false
+	if limiter != nil {
+		limits, ok = limiter.groups[group]
+	}
+	if !ok {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		type check struct {
+			key   string
+			limit config.RateLimit
+		}
+		var checks []check
+		if limits.PerIP.Requests > 0 {
+			checks = append(checks, check{key: group + ":ip:" + c.IP(), limit: limits.PerIP})
+		}
+		if uid, _ := c.Locals("uid").(string); uid != "" && limits.PerUser.Requests > 0 {
+			checks = append(checks, check{key: group + ":uid:" + uid, limit: limits.PerUser})
+		}
+
+		// the headers describe the bucket closest to running out, or the one that did
+		var reported *bucketState
+		for _, ch := range checks {
+			burst := ch.limit.Burst
+			if burst <= 0 {
+				burst = ch.limit.Requests
+			}
+			rate := float64(ch.limit.Requests) / ch.limit.Per.Seconds()
+
+			tokens, allowed, err := limiter.store.TakeToken(c.UserContext(), ch.key, rate, burst)
+			if err != nil {
+				log.FromContext(c.UserContext()).Warn("Rate limit store failed, request let through", zap.String("group", group), zap.Error(err))
+				continue
+			}
+
+			state := &bucketState{burst: burst, rate: rate, tokens: tokens, allowed: allowed}
+			if reported == nil || !allowed || tokens < reported.tokens {
+				reported = state
+			}
+			if !allowed {
+				break
+			}
+		}
+		if reported == nil {
+			return c.Next()
+		}
+
+		c.Set("X-RateLimit-Limit", strconv.Itoa(reported.burst))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(int(math.Floor(reported.tokens))))
+		// seconds until the bucket is full again
+		c.Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil((float64(reported.burst)-reported.tokens)/reported.rate))))
+
+		if !reported.allowed {
+			retryAfter := int(math.Ceil((1 - reported.tokens) / reported.rate))
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(max(retryAfter, 1)))
+			log.FromContext(c.UserContext()).Info("Rate limited", zap.String("group", group))
+			return domain.ErrRateLimited
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hekanemre/taxihub/config"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	tests := []struct {
+		name    string
+		group   config.RateLimitGroup
+		invalid bool
+	}{
+		{name: "both limits", group: config.RateLimitGroup{
+			PerIP:   config.RateLimit{Requests: 10, Per: time.Second},
+			PerUser: config.RateLimit{Requests: 100, Per: time.Minute, Burst: 20},
+		}},
+		{name: "disabled limit without per", group: config.RateLimitGroup{
+			PerIP: config.RateLimit{Requests: 10, Per: time.Second},
+		}},
+		{name: "per ip without per", group: config.RateLimitGroup{
+			PerIP: config.RateLimit{Requests: 10},
+		}, invalid: true},
+		{name: "per user with a negative per", group: config.RateLimitGroup{
+			PerUser: config.RateLimit{Requests: 10, Per: -time.Second},
+		}, invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRateLimiter(nil, map[string]config.RateLimitGroup{"api": tt.group})
+			if tt.invalid && err == nil {
+				t.Fatal("NewRateLimiter accepted a limit without a per")
+			}
+			if !tt.invalid && err != nil {
+				t.Fatalf("NewRateLimiter: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// full buckets are dropped this often, a full bucket behaves exactly like a missing one
+const rateLimitSweepInterval = time.Minute
+
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	rate      float64
+	burst     float64
+}
+
+// MemoryRateLimitStore keeps buckets in process, every instance limits on its own.
+// Use the Mongo store to share limits between instances.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+var _ RateLimitStore = (*MemoryRateLimitStore)(nil)
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryRateLimitStore) TakeToken(ctx context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sweep(now)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(burst), updatedAt: now}
+		s.buckets[key] = bucket
+	}
+	bucket.rate = rate
+	bucket.burst = float64(burst)
+	bucket.tokens = min(bucket.burst, bucket.tokens+now.Sub(bucket.updatedAt).Seconds()*rate)
+	bucket.updatedAt = now
+
+	if bucket.tokens < 1 {
+		return bucket.tokens, false, nil
+	}
+	bucket.tokens--
+	return bucket.tokens, true, nil
+}
+
+// sweep drops the buckets that refilled completely, without it every client ever seen stays in memory
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, bucket := range s.buckets {
+		if bucket.tokens+now.Sub(bucket.updatedAt).Seconds()*bucket.rate >= bucket.burst {
+			delete(s.buckets, key)
+		}
+	}
+}
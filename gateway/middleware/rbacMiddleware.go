@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	application "github.com/hekanemre/taxihub/application/driver"
+	"github.com/hekanemre/taxihub/application/ride"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/gateway/helpers"
+	"github.com/hekanemre/taxihub/log"
+	"go.uber.org/zap"
+)
+
+// OwnerResolver returns the uid of the user owning the resource a request targets.
+// An empty uid means the resource has no owner, or does not exist.
+type OwnerResolver func(c *fiber.Ctx) (string, error)
+
+// Authorize lets the request through only if the caller's role grants perm.
+// Permissions granted for own resources only are checked against owner, nil owner denies them.
+// Must run after Authenticate.
+func Authorize(perm helpers.Permission, owner OwnerResolver) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		uid, _ := c.Locals("uid").(string)
+		userType, _ := c.Locals("user_type").(string)
+
+		// Authorize runs as a route handler, the pattern is known from here on
+		withLogFields(c, zap.String("route", c.Route().Path))
+		logger := log.FromContext(c.UserContext())
+		fields := []zap.Field{
+			zap.String("user_type", userType),
+			zap.String("permission", string(perm)),
+		}
+
+		scope := helpers.PermissionScope(userType, perm)
+		if scope == helpers.ScopeOwn {
+			if owner == nil {
+				scope = helpers.ScopeNone
+			} else {
+				ownerUID, err := owner(c)
+				if err != nil {
+					return fmt.Errorf("resolving resource owner: %w", err)
+				}
+				if uid == "" || ownerUID != uid {
+					scope = helpers.ScopeNone
+				}
+			}
+		}
+
+		if scope == helpers.ScopeNone {
+			logger.Warn("Access denied", fields...)
+			return domain.ErrForbidden
+		}
+
+		logger.Info("Access granted", fields...)
+		return c.Next()
+	}
+}
+
+// DriverOwnerByParam resolves the owner of the driver in the :id route param
+func DriverOwnerByParam(driverRepo application.Repository) OwnerResolver {
+	return func(c *fiber.Ctx) (string, error) {
+		return driverOwner(c, driverRepo, c.Params("id"))
+	}
+}
+
+// DriverOwnerByBody resolves the owner of the driver whose id is in the JSON body
+func DriverOwnerByBody(driverRepo application.Repository) OwnerResolver {
+	return func(c *fiber.Ctx) (string, error) {
+		var body struct {
+			ID string `json:"id"`
+		}
+		if err := c.BodyParser(&body); err != nil {
+			// the controller answers malformed bodies, nobody owns them
+			return "", nil
+		}
+		return driverOwner(c, driverRepo, body.ID)
+	}
+}
+
+func driverOwner(c *fiber.Ctx, driverRepo application.Repository, id string) (string, error) {
+	if id == "" {
+		return "", nil
+	}
+
+	driver, err := driverRepo.GetDriverByID(c.UserContext(), id)
+	if errors.Is(err, domain.ErrDriverNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return driver.UserID, nil
+}
+
+// RideParticipantByParam resolves the ride in the :id route param. A ride is owned by its passenger
+// and its assigned driver, withOffered adds the driver it is offered to. As there is more than one
+// owner, the caller's own uid is returned when they are one of them.
+func RideParticipantByParam(rideRepo ride.Repository, driverRepo ride.DriverRepository, withOffered bool) OwnerResolver {
+	return func(c *fiber.Ctx) (string, error) {
+		uid, _ := c.Locals("uid").(string)
+		if uid == "" {
+			return "", nil
+		}
+
+		r, err := rideRepo.GetRideByID(c.UserContext(), c.Params("id"))
+		if errors.Is(err, domain.ErrRideNotFound) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if r.PassengerID == uid {
+			return uid, nil
+		}
+		if r.DriverID == "" && (!withOffered || r.OfferedDriverID == "") {
+			return "", nil
+		}
+
+		driver, err := driverRepo.GetDriverByUserID(c.UserContext(), uid)
+		if errors.Is(err, domain.ErrDriverNotFound) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if driver.ID == r.DriverID || (withOffered && driver.ID == r.OfferedDriverID) {
+			return uid, nil
+		}
+		return "", nil
+	}
+}
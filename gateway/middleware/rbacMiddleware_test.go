@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/hekanemre/taxihub/gateway/helpers"
+	"github.com/hekanemre/taxihub/infrastructure"
+)
+
+// newRBACTestApp serves a few driver routes behind Authorize. The caller's uid and user type come from
+// headers in place of a token. Driver d1 belongs to u1, d2 to u2 and d3 to no account.
+func newRBACTestApp(t *testing.T) *fiber.App {
+	t.Helper()
+	ctx := context.Background()
+
+	repo := infrastructure.NewMemoryRepository(1000)
+	for _, driver := range []*domain.Driver{
+		{ID: "d1", UserID: "u1", Plate: "34 AB 1", TaxiType: "yellow"},
+		{ID: "d2", UserID: "u2", Plate: "34 AB 2", TaxiType: "yellow"},
+		{ID: "d3", Plate: "34 AB 3", TaxiType: "yellow"},
+	} {
+		if err := repo.CreateDriver(ctx, driver); err != nil {
+			t.Fatalf("CreateDriver: %v", err)
+		}
+	}
+
+	app := fiber.New(fiber.Config{ErrorHandler: ErrorHandler})
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("uid", c.Get("X-Test-Uid"))
+		c.Locals("user_type", c.Get("X-Test-User-Type"))
+		return c.Next()
+	})
+
+	ok := func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) }
+	app.Post("/driver/create", Authorize(helpers.PermDriverCreate, nil), ok)
+	app.Put("/driver/update", Authorize(helpers.PermDriverUpdate, DriverOwnerByBody(repo)), ok)
+	app.Get("/driver/:id", Authorize(helpers.PermDriverRead, DriverOwnerByParam(repo)), ok)
+	// a permission granted for own drivers on a route without an owner resolver
+	app.Get("/driver/:id/history", Authorize(helpers.PermDriverRead, nil), ok)
+	return app
+}
+
+func TestAuthorize(t *testing.T) {
+	app := newRBACTestApp(t)
+
+	type request struct {
+		method, path, body string
+	}
+	var (
+		create      = request{"POST", "/driver/create", `{}`}
+		readOwn     = request{"GET", "/driver/d1", ""}
+		readOther   = request{"GET", "/driver/d2", ""}
+		readNobody  = request{"GET", "/driver/d3", ""}
+		readGone    = request{"GET", "/driver/nope", ""}
+		updateOwn   = request{"PUT", "/driver/update", `{"id":"d1"}`}
+		updateOther = request{"PUT", "/driver/update", `{"id":"d2"}`}
+		updateBad   = request{"PUT", "/driver/update", `{"id":`}
+		noResolver  = request{"GET", "/driver/d1/history", ""}
+	)
+
+	const allowed, denied = fiber.StatusOK, fiber.StatusForbidden
+	tests := []struct {
+		userType string
+		uid      string
+		req      request
+		want     int
+	}{
+		{"ADMIN", "a1", create, allowed},
+		{"ADMIN", "a1", readOther, allowed},
+		{"ADMIN", "a1", readGone, allowed},
+		{"ADMIN", "a1", updateOther, allowed},
+		{"ADMIN", "a1", noResolver, allowed},
+
+		{"DISPATCHER", "s1", create, allowed},
+		{"DISPATCHER", "s1", readOther, allowed},
+		{"DISPATCHER", "s1", updateOther, allowed},
+
+		// u1 drives d1
+		{"DRIVER", "u1", create, denied},
+		{"DRIVER", "u1", readOwn, allowed},
+		{"DRIVER", "u1", readOther, denied},
+		{"DRIVER", "u1", readNobody, denied},
+		{"DRIVER", "u1", readGone, denied},
+		{"DRIVER", "u1", updateOwn, allowed},
+		{"DRIVER", "u1", updateOther, denied},
+		{"DRIVER", "u1", updateBad, denied},
+		{"DRIVER", "u1", noResolver, denied},
+		{"DRIVER", "u2", readOther, allowed},
+		{"DRIVER", "u2", readOwn, denied},
+		// no uid never owns the driver without an account
+		{"DRIVER", "", readNobody, denied},
+
+		{"PASSENGER", "u1", create, denied},
+		{"PASSENGER", "u1", readOwn, denied},
+		{"PASSENGER", "u1", updateOwn, denied},
+
+		{"", "u1", readOwn, denied},
+		{"ROOT", "u1", readOwn, denied},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.userType+" "+tt.uid+" "+tt.req.method+" "+tt.req.path+" "+tt.req.body, func(t *testing.T) {
+			req := httptest.NewRequest(tt.req.method, tt.req.path, strings.NewReader(tt.req.body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Test-Uid", tt.uid)
+			req.Header.Set("X-Test-User-Type", tt.userType)
+
+			res, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Test: %v", err)
+			}
+			if res.StatusCode != tt.want {
+				t.Fatalf("status = %d, want %d", res.StatusCode, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/hekanemre/taxihub/application/audit"
+	"github.com/hekanemre/taxihub/log"
+	"github.com/hekanemre/taxihub/tracing"
+	"go.uber.org/zap"
+)
+
+// a caller supplied id ends up in every log line, anything else is replaced
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID takes X-Request-ID from the caller or generates one, echoes it in the response and
+// puts a logger carrying it into c.UserContext(). Handlers log through log.FromContext(c.UserContext()).
+// Authenticate adds the uid and Authorize the route pattern once they are known.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		started := time.Now()
+
+		requestID := strings.Clone(c.Get(fiber.HeaderXRequestID))
+		if !requestIDPattern.MatchString(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Locals("request_id", requestID)
+		c.Set(fiber.HeaderXRequestID, requestID)
+
+		logger := zap.L().With(append([]zap.Field{
+			zap.String("request_id", requestID),
+			zap.String("method", strings.Clone(c.Method())),
+			zap.String("path", strings.Clone(c.Path())),
+		}, tracing.LogFields(c.UserContext())...)...)
+		// audit records written for the request carry the id too
+		c.SetUserContext(audit.WithRequestID(log.NewContext(c.UserContext(), logger), requestID))
+
+		handleError(c, c.Next())
+
+		fields := []zap.Field{
+			zap.String("route", c.Route().Path),
+			zap.Int("status", c.Response().StatusCode()),
+			zap.Duration("latency", time.Since(started)),
+		}
+		// reading a streamed body would block until the stream ends
+		if !c.Response().IsBodyStream() {
+			fields = append(fields, zap.Int("size", len(c.Response().Body())))
+		}
+		if uid, ok := c.Locals("uid").(string); ok {
+			fields = append(fields, zap.String("uid", uid))
+		}
+		logger.Info("Request completed", fields...)
+		return nil
+	}
+}
+
+// withLogFields adds fields to the request logger for everything that runs after the caller
+func withLogFields(c *fiber.Ctx, fields ...zap.Field) {
+	ctx := c.UserContext()
+	c.SetUserContext(log.NewContext(ctx, log.FromContext(ctx).With(fields...)))
+}
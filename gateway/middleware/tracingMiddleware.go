@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing opens the server span of every request, continuing the caller's trace when it sent a traceparent header.
+// Handlers get the span through c.UserContext().
+func Tracing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// fiber reuses method and path buffers for the next request, spans are exported later so they keep copies
+		method := strings.Clone(c.Method())
+
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{c})
+		ctx, span := tracing.Start(ctx, method,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(method),
+				semconv.URLPath(strings.Clone(c.Path())),
+			),
+		)
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+		if err != nil {
+			span.RecordError(err)
+		}
+		handleError(c, err)
+
+		route := c.Route().Path
+		status := c.Response().StatusCode()
+		span.SetName(method + " " + route)
+		span.SetAttributes(semconv.HTTPRoute(route), semconv.HTTPResponseStatusCode(status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return nil
+	}
+}
+
+// headerCarrier lets the propagator read trace context from request headers
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	keys := make([]string, 0)
+	for key := range h.c.GetReqHeaders() {
+		keys = append(keys, key)
+	}
+	return keys
+}
@@ -2,11 +2,20 @@ package routes
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/application/audit"
 	"github.com/hekanemre/taxihub/gateway/controllers"
 	"github.com/hekanemre/taxihub/gateway/helpers"
+	"github.com/hekanemre/taxihub/gateway/middleware"
 )
 
-func AuthRoutes(app *fiber.App, tokenHelper *helpers.TokenHelper) {
-	app.Post("/login", controllers.Login(tokenHelper))
-	app.Post("/signup", controllers.Signup(tokenHelper))
+func AuthRoutes(app *fiber.App, tokenHelper *helpers.TokenHelper, auditRecorder *audit.Recorder, rateLimiter *middleware.RateLimiter) {
+	// every attempt costs a bcrypt hash, these are limited per client IP before any work is done
+	limit := middleware.RateLimit(rateLimiter, "auth")
+
+	app.Post("/login", limit, controllers.Login(tokenHelper, auditRecorder))
+	app.Post("/signup", limit, controllers.Signup(tokenHelper, auditRecorder))
+	app.Post("/token/refresh", limit, controllers.RefreshToken(tokenHelper))
+	app.Post("/logout", middleware.Authenticate(tokenHelper), controllers.Logout(tokenHelper))
+	app.Put("/user/:id/role", middleware.Authenticate(tokenHelper), middleware.Authorize(helpers.PermUserRole, nil), controllers.ChangeRole(tokenHelper, auditRecorder))
+	app.Get("/.well-known/jwks.json", controllers.JWKS(tokenHelper))
 }
@@ -2,17 +2,38 @@ package routes
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/application/audit"
+	application "github.com/hekanemre/taxihub/application/driver"
+	"github.com/hekanemre/taxihub/domain"
 	"github.com/hekanemre/taxihub/gateway/controllers"
 	"github.com/hekanemre/taxihub/gateway/helpers"
 	"github.com/hekanemre/taxihub/gateway/middleware"
-	"github.com/hekanemre/taxihub/infrastructure"
 )
 
-func DriverRoutes(app *fiber.App, driverRepo *infrastructure.MongoRepository, tokenHelper *helpers.TokenHelper) {
-	app.Use(middleware.Authenticate(tokenHelper))
-	app.Post("/driver/create", controllers.CreateDriver(driverRepo))
-	app.Put("/driver/update", controllers.UpdateDriver(driverRepo))
-	app.Get("/driver/getall", controllers.GetAllDrivers(driverRepo))
-	app.Get("/driver/:id", controllers.GetDriverByID(driverRepo))
-	app.Get("driver/getallnearby/:lat/:lon/:taxiType", controllers.GetAllDriversNearby(driverRepo))
+func DriverRoutes(app *fiber.App, driverRepo application.Repository, shiftRepo application.ShiftRepository, auditRepo audit.Repository, locationBatcher *application.LocationBatcher) {
+	// which roles may use a permission lives in helpers.rolePermissions, the owner resolvers decide "own" driver
+	ownerByParam := middleware.DriverOwnerByParam(driverRepo)
+	ownerByBody := middleware.DriverOwnerByBody(driverRepo)
+
+	app.Post("/driver/create", middleware.Authorize(helpers.PermDriverCreate, nil), controllers.CreateDriver(driverRepo))
+	app.Put("/driver/update", middleware.Authorize(helpers.PermDriverUpdate, ownerByBody), controllers.UpdateDriver(driverRepo))
+	app.Get("/driver/getall", middleware.Authorize(helpers.PermDriverList, nil), controllers.GetAllDrivers(driverRepo))
+	// before /driver/:id, which would take "search" for an id
+	app.Get("/driver/search", middleware.Authorize(helpers.PermDriverList, nil), controllers.SearchDrivers(driverRepo))
+	app.Get("/driver/:id", middleware.Authorize(helpers.PermDriverRead, ownerByParam), controllers.GetDriverByID(driverRepo))
+	app.Patch("/driver/:id", middleware.Authorize(helpers.PermDriverUpdate, ownerByParam), controllers.PatchDriver(driverRepo))
+	app.Delete("/driver/:id", middleware.Authorize(helpers.PermDriverDelete, nil), controllers.DeleteDriver(driverRepo, false))
+	app.Post("/driver/:id/deactivate", middleware.Authorize(helpers.PermDriverDelete, nil), controllers.DeleteDriver(driverRepo, true))
+	// no owner resolver, it could not find the deleted driver
+	app.Post("/driver/:id/restore", middleware.Authorize(helpers.PermDriverRestore, nil), controllers.RestoreDriver(driverRepo))
+	// deleted and purged drivers keep their history, so no owner resolver either
+	app.Get("/driver/:id/history", middleware.Authorize(helpers.PermDriverHistory, nil), controllers.GetDriverHistory(auditRepo))
+	app.Get("driver/getallnearby/:lat/:lon/:taxiType", middleware.Authorize(helpers.PermDriverNearby, nil), controllers.GetAllDriversNearby(driverRepo))
+	app.Post("/driver/:id/shift/start", middleware.Authorize(helpers.PermDriverShift, ownerByParam), controllers.StartShift(driverRepo, shiftRepo))
+	app.Post("/driver/:id/shift/end", middleware.Authorize(helpers.PermDriverShift, ownerByParam), controllers.EndShift(driverRepo, shiftRepo))
+	app.Post("/driver/:id/online", middleware.Authorize(helpers.PermDriverShift, ownerByParam), controllers.ChangeDriverStatus(driverRepo, shiftRepo, domain.DriverOnline))
+	app.Post("/driver/:id/offline", middleware.Authorize(helpers.PermDriverShift, ownerByParam), controllers.ChangeDriverStatus(driverRepo, shiftRepo, domain.DriverOffline))
+	app.Post("/driver/:id/break", middleware.Authorize(helpers.PermDriverShift, ownerByParam), controllers.ChangeDriverStatus(driverRepo, shiftRepo, domain.DriverOnBreak))
+	app.Get("/driver/:id/shifts", middleware.Authorize(helpers.PermDriverRead, ownerByParam), controllers.GetDriverShifts(shiftRepo))
+	app.Post("/driver/:id/location", middleware.Authorize(helpers.PermDriverLocation, ownerByParam), controllers.IngestLocation(locationBatcher))
 }
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/application/healthcheck"
+	"github.com/hekanemre/taxihub/gateway/controllers"
+)
+
+func HealthRoutes(app *fiber.App, registry *healthcheck.Registry) {
+	app.Get("/health/live", controllers.Liveness())
+	app.Get("/health/ready", controllers.Readiness(registry))
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/application/pricing"
+	"github.com/hekanemre/taxihub/gateway/controllers"
+	"github.com/hekanemre/taxihub/gateway/helpers"
+	"github.com/hekanemre/taxihub/gateway/middleware"
+)
+
+func PricingRoutes(app *fiber.App, tariffRepo pricing.Repository, surge pricing.SurgeProvider, settings pricing.Settings) {
+	prices := app.Group("/pricing")
+	prices.Post("/estimate", controllers.EstimateFare(tariffRepo, surge, settings))
+	prices.Get("/tariffs", controllers.GetTariffs(tariffRepo))
+	prices.Put("/tariffs/:taxiType", middleware.Authorize(helpers.PermTariffManage, nil), controllers.UpdateTariff(tariffRepo))
+}
@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/application/ride"
+	"github.com/hekanemre/taxihub/gateway/controllers"
+	"github.com/hekanemre/taxihub/gateway/helpers"
+	"github.com/hekanemre/taxihub/gateway/middleware"
+)
+
+func RideRoutes(app *fiber.App, rideRepo ride.Repository, driverRepo ride.DriverRepository, dispatcher *ride.Dispatcher) {
+	// the offered driver has to see the ride to decide, only the assigned one may cancel it
+	participant := middleware.RideParticipantByParam(rideRepo, driverRepo, true)
+	owner := middleware.RideParticipantByParam(rideRepo, driverRepo, false)
+
+	rides := app.Group("/ride")
+	rides.Post("/request", controllers.RequestRide(rideRepo, dispatcher))
+	rides.Get("/:id", middleware.Authorize(helpers.PermRideRead, participant), controllers.GetRide(rideRepo))
+	rides.Post("/:id/accept", middleware.Authorize(helpers.PermRideDrive, nil), controllers.AcceptRide(rideRepo, driverRepo))
+	rides.Post("/:id/decline", middleware.Authorize(helpers.PermRideDrive, nil), controllers.DeclineRide(rideRepo, driverRepo, dispatcher))
+	rides.Put("/:id/status", middleware.Authorize(helpers.PermRideDrive, nil), controllers.UpdateRideStatus(rideRepo, driverRepo))
+	rides.Post("/:id/cancel", middleware.Authorize(helpers.PermRideCancel, owner), controllers.CancelRide(rideRepo, driverRepo))
+}
@@ -0,0 +1,16 @@
+package routes
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/application/stream"
+	"github.com/hekanemre/taxihub/gateway/controllers"
+	"github.com/hekanemre/taxihub/gateway/helpers"
+	"github.com/hekanemre/taxihub/gateway/middleware"
+)
+
+// StreamRoutes must be registered before DriverRoutes, otherwise /driver/:id catches /driver/stream
+func StreamRoutes(app *fiber.App, hub *stream.Hub, heartbeat, writeTimeout time.Duration, tokenHelper *helpers.TokenHelper) {
+	app.Get("/driver/stream", middleware.Authenticate(tokenHelper), middleware.Authorize(helpers.PermDriverStream, nil), controllers.StreamDrivers(hub, heartbeat, writeTimeout))
+}
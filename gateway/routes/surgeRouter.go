@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/application/surge"
+	"github.com/hekanemre/taxihub/gateway/controllers"
+	"github.com/hekanemre/taxihub/gateway/helpers"
+	"github.com/hekanemre/taxihub/gateway/middleware"
+)
+
+func SurgeRoutes(app *fiber.App, engine *surge.Engine, cells surge.Repository, history surge.HistoryRepository) {
+	surges := app.Group("/surge")
+	// passengers see surge through their fare estimate only
+	surges.Get("/", middleware.Authorize(helpers.PermSurgeRead, nil), controllers.GetSurge(engine, cells))
+	surges.Get("/heatmap", middleware.Authorize(helpers.PermSurgeRead, nil), controllers.GetSurgeHeatmap(cells))
+	surges.Get("/history", middleware.Authorize(helpers.PermSurgeHistory, nil), controllers.GetSurgeHistory(history))
+}
@@ -0,0 +1,50 @@
+package infrastructure
+
+import (
+	"context"
+
+	"github.com/hekanemre/taxihub/application/audit"
+	"github.com/hekanemre/taxihub/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *MongoRepository) AppendAuditRecord(ctx context.Context, record *domain.AuditRecord) error {
+	ctx, done := r.observe(ctx, "AppendAuditRecord")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	_, err := collection.InsertOne(ctx, record)
+	return mongoError(err)
+}
+
+func (r *MongoRepository) GetAuditHistory(ctx context.Context, query audit.HistoryQuery) ([]*domain.AuditRecord, error) {
+	ctx, done := r.observe(ctx, "GetAuditHistory")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	// served by the resource_resourceId_timestamp_id index of migration 12
+	filter := bson.M{"resource": query.Resource, "resourceId": query.ResourceID}
+	if query.Before != nil {
+		filter["$or"] = bson.A{
+			bson.M{"timestamp": bson.M{"$lt": query.Before.Timestamp}},
+			bson.M{"timestamp": query.Before.Timestamp, "_id": bson.M{"$lt": query.Before.ID}},
+		}
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "timestamp", Value: -1}, {Key: "_id", Value: -1}}).
+		SetLimit(int64(query.Limit))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []*domain.AuditRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, mongoError(err)
+	}
+	return records, nil
+}
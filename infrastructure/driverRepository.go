@@ -2,9 +2,10 @@ package infrastructure
 
 import (
 	"context"
-	"log"
+	"regexp"
+	"time"
 
-	"github.com/hekanemre/taxihub/config"
+	application "github.com/hekanemre/taxihub/application/driver"
 	"github.com/hekanemre/taxihub/domain"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -13,43 +14,97 @@ import (
 )
 
 func (r *MongoRepository) CreateDriver(ctx context.Context, driver *domain.Driver) error {
+	ctx, done := r.observe(ctx, "CreateDriver")
+	defer done()
 	collection := r.DB.Collection(r.Collection)
+	driver.Plate = domain.NormalizePlate(driver.Plate)
+	driver.ActivePlate = driver.Plate
+	driver.SearchGrams = domain.DriverSearchGrams(driver)
 	_, err := collection.InsertOne(ctx, driver)
-	return err
+	return writeError(err, application.ErrDuplicatePlate)
 }
 
 func (r *MongoRepository) UpdateDriver(ctx context.Context, driver *domain.Driver) error {
+	ctx, done := r.observe(ctx, "UpdateDriver")
+	defer done()
 	collection := r.DB.Collection(r.Collection)
 
-	if objID, err := primitive.ObjectIDFromHex(driver.ID); err == nil {
-		var driver domain.Driver
-		if err := collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&driver); err == nil {
-			return err
-		}
+	driver.Plate = domain.NormalizePlate(driver.Plate)
+	driver.ActivePlate = driver.Plate
+	driver.SearchGrams = domain.DriverSearchGrams(driver)
+
+	// only the editable fields, status, shift and location pings have their own writes
+	filter := bson.M{"_id": driverIDFilter(driver.ID), "deletedAt": nil, "version": driver.Version}
+	if driver.Version == 0 {
+		// drivers stored before versions existed have no version field, $inc starts them at 1
+		filter["version"] = bson.M{"$in": bson.A{0, nil}}
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"firstName":   driver.FirstName,
+			"lastName":    driver.LastName,
+			"plate":       driver.Plate,
+			"activePlate": driver.ActivePlate,
+			"taxiType":    driver.TaxiType,
+			"carBrand":    driver.CarBrand,
+			"carModel":    driver.CarModel,
+			"location":    driver.Location,
+			"updatedAt":   driver.UpdatedAt,
+			"searchGrams": driver.SearchGrams,
+		},
+		"$inc": bson.M{"version": 1},
+	}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return writeError(err, application.ErrDuplicatePlate)
 	}
 
-	filter := bson.M{"_id": driver.ID}
-	update := bson.M{"$set": driver}
+	if result.MatchedCount == 0 {
+		return r.updateMissError(ctx, collection, driver.ID)
+	}
 
-	_, err := collection.UpdateOne(ctx, filter, update)
-	return err
+	driver.Version++
+	return nil
 }
 
-func (r *MongoRepository) GetAllDrivers(ctx context.Context, page, pageSize int) ([]*domain.Driver, error) {
+// updateMissError tells why a versioned write matched nothing: a driver deleted since the caller
+// read it is not found, one that is still there was changed in the meantime
+func (r *MongoRepository) updateMissError(ctx context.Context, collection *mongo.Collection, id string) error {
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": driverIDFilter(id), "deletedAt": nil}, options.Count().SetLimit(1))
+	if err != nil {
+		return mongoError(err)
+	}
+	if count == 0 {
+		return domain.ErrDriverNotFound
+	}
+	return domain.ErrDriverVersionMismatch
+}
+
+func (r *MongoRepository) GetAllDrivers(ctx context.Context, query application.DriverQuery) ([]*domain.Driver, error) {
+	ctx, done := r.observe(ctx, "GetAllDrivers")
+	defer done()
 	collection := r.DB.Collection(r.Collection)
 
-	// Calculate skip
-	skip := (page - 1) * pageSize
+	keys := driverSortKeys(query.SortBy)
+	direction := 1
+	if query.Desc {
+		direction = -1
+	}
+	sort := bson.D{}
+	for _, key := range keys {
+		sort = append(sort, bson.E{Key: key, Value: direction})
+	}
 
-	// Set options for pagination
-	findOptions := options.Find()
-	findOptions.SetSkip(int64(skip))
-	findOptions.SetLimit(int64(pageSize))
+	filter := driverFilter(query.Filter)
+	if query.After != nil {
+		filter = bson.M{"$and": bson.A{filter, keysetFilter(keys, cursorValues(query.After), query.Desc)}}
+	}
 
-	// Execute query
-	cursor, err := collection.Find(ctx, bson.M{}, findOptions)
+	findOptions := options.Find().SetSort(sort).SetLimit(int64(query.Limit))
+	cursor, err := collection.Find(ctx, filter, findOptions)
 	if err != nil {
-		return nil, err
+		return nil, mongoError(err)
 	}
 	defer cursor.Close(ctx)
 
@@ -57,59 +112,120 @@ func (r *MongoRepository) GetAllDrivers(ctx context.Context, page, pageSize int)
 	for cursor.Next(ctx) {
 		var driver domain.Driver
 		if err := cursor.Decode(&driver); err != nil {
-			return nil, err
+			return nil, mongoError(err)
 		}
 		drivers = append(drivers, &driver)
 	}
 
-	return drivers, nil
+	return drivers, mongoError(cursor.Err())
 }
 
-func (r *MongoRepository) GetAllDriversNearby(ctx context.Context, lat, lon float64, taxiType string) ([]*domain.Driver, error) {
-	appConfig := config.Read()
-	maxDistance := appConfig.NearbyDistance // maxDistance in meters
+func (r *MongoRepository) CountDrivers(ctx context.Context, filter application.DriverFilter) (int64, error) {
+	ctx, done := r.observe(ctx, "CountDrivers")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	count, err := collection.CountDocuments(ctx, driverFilter(filter))
+	return count, mongoError(err)
+}
 
+func (r *MongoRepository) SearchDrivers(ctx context.Context, grams []string, limit int) ([]*domain.Driver, error) {
+	ctx, done := r.observe(ctx, "SearchDrivers")
+	defer done()
 	collection := r.DB.Collection(r.Collection)
 
-	// Check if the 2dsphere index on the location field exists
-	indexes, err := collection.Indexes().List(ctx)
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"searchGrams": bson.M{"$in": grams}, "deletedAt": nil}}},
+		{{Key: "$addFields", Value: bson.M{"shared": bson.M{"$size": bson.M{"$setIntersection": bson.A{"$searchGrams", grams}}}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "shared", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+	cursor, err := collection.Aggregate(ctx, pipeline)
 	if err != nil {
-		return nil, err
+		return nil, mongoError(err)
 	}
+	defer cursor.Close(ctx)
 
-	indexExists := false
-	for indexes.Next(ctx) {
-		var index bson.M
-		if err := indexes.Decode(&index); err != nil {
-			return nil, err
-		}
+	var drivers []*domain.Driver
+	if err := cursor.All(ctx, &drivers); err != nil {
+		return nil, mongoError(err)
+	}
+	return drivers, nil
+}
 
-		// Check if the index is for the 'location' field and of type 2dsphere
-		if idxType, ok := index["key"].(bson.M); ok {
-			if _, exists := idxType["location"]; exists && index["name"] == "location_2dsphere" {
-				indexExists = true
-				break
-			}
-		}
+// driverFilter matches the drivers a listing is narrowed to
+func driverFilter(filter application.DriverFilter) bson.M {
+	query := bson.M{"deletedAt": nil}
+	if filter.TaxiType != "" {
+		query["taxiType"] = filter.TaxiType
+	}
+	if filter.CarBrand != "" {
+		query["carBrand"] = bson.M{"$regex": "^" + regexp.QuoteMeta(filter.CarBrand) + "$", "$options": "i"}
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	created := bson.M{}
+	if filter.CreatedFrom != nil {
+		created["$gte"] = *filter.CreatedFrom
+	}
+	if filter.CreatedTo != nil {
+		created["$lt"] = *filter.CreatedTo
+	}
+	if len(created) > 0 {
+		query["createdAt"] = created
 	}
+	return query
+}
 
-	// If the 2dsphere index doesn't exist, create it
-	if !indexExists {
-		indexModel := mongo.IndexModel{
-			Keys: bson.D{
-				{Key: "location", Value: "2dsphere"},
-			},
-			Options: options.Index().SetName("location_2dsphere"),
-		}
+// driverSortKeys are the fields a listing is ordered by, the id comes last so no two drivers tie
+func driverSortKeys(sortBy string) []string {
+	switch sortBy {
+	case application.SortByName:
+		return []string{"firstName", "lastName", "_id"}
+	case application.SortByUpdatedAt:
+		return []string{"updatedAt", "_id"}
+	default:
+		return []string{"createdAt", "_id"}
+	}
+}
 
-		_, err := collection.Indexes().CreateOne(ctx, indexModel)
-		if err != nil {
-			return nil, err
-		}
+// cursorValues lines the cursor up with driverSortKeys
+func cursorValues(cursor *application.DriverCursor) []any {
+	if cursor.SortBy == application.SortByName {
+		return []any{cursor.FirstName, cursor.LastName, cursor.ID}
+	}
+	return []any{*cursor.Time, cursor.ID}
+}
 
-		log.Println("2dsphere index created successfully on location field")
+// keysetFilter matches what comes after values in the listing order:
+// a greater first key, or an equal first key and a greater second one, and so on
+func keysetFilter(keys []string, values []any, desc bool) bson.M {
+	op := "$gt"
+	if desc {
+		op = "$lt"
 	}
 
+	or := bson.A{}
+	for i := range keys {
+		clause := bson.M{}
+		for j := 0; j < i; j++ {
+			clause[keys[j]] = values[j]
+		}
+		clause[keys[i]] = bson.M{op: values[i]}
+		or = append(or, clause)
+	}
+	return bson.M{"$or": or}
+}
+
+func (r *MongoRepository) GetAllDriversNearby(ctx context.Context, lat, lon float64, taxiType string, onlyAvailable bool) ([]*domain.Driver, error) {
+	ctx, done := r.observe(ctx, "GetAllDriversNearby")
+	defer done()
+	maxDistance := r.NearbyDistance // maxDistance in meters
+
+	collection := r.DB.Collection(r.Collection)
+
+	// the location_2dsphere index $near needs is created by migration 1
 	filter := bson.M{
 		"location": bson.M{
 			"$near": bson.M{
@@ -120,19 +236,23 @@ func (r *MongoRepository) GetAllDriversNearby(ctx context.Context, lat, lon floa
 				"$maxDistance": maxDistance,
 			},
 		},
-		"taxiType": taxiType,
+		"taxiType":  taxiType,
+		"deletedAt": nil,
+	}
+	if onlyAvailable {
+		filter["status"] = domain.DriverOnline
 	}
 
 	cursor, err := collection.Find(ctx, filter)
 	if err != nil {
-		return nil, err
+		return nil, mongoError(err)
 	}
 	defer cursor.Close(ctx)
 	var drivers []*domain.Driver
 	for cursor.Next(ctx) {
 		var driver domain.Driver
 		if err := cursor.Decode(&driver); err != nil {
-			return nil, err
+			return nil, mongoError(err)
 		}
 		drivers = append(drivers, &driver)
 	}
@@ -140,32 +260,328 @@ func (r *MongoRepository) GetAllDriversNearby(ctx context.Context, lat, lon floa
 }
 
 func (r *MongoRepository) GetDriverByID(ctx context.Context, id string) (*domain.Driver, error) {
+	ctx, done := r.observe(ctx, "GetDriverByID")
+	defer done()
 	collection := r.DB.Collection(r.Collection)
 
 	if objID, err := primitive.ObjectIDFromHex(id); err == nil {
 		var driver domain.Driver
-		if err := collection.FindOne(ctx, bson.M{"_id": objID}).Decode(&driver); err == nil {
+		if err := collection.FindOne(ctx, bson.M{"_id": objID, "deletedAt": nil}).Decode(&driver); err == nil {
 			return &driver, nil
 		}
 	}
 
 	var driver domain.Driver
-	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&driver)
+	err := collection.FindOne(ctx, bson.M{"_id": id, "deletedAt": nil}).Decode(&driver)
 	if err != nil {
-		return nil, err
+		return nil, findError(err, domain.ErrDriverNotFound)
 	}
 
 	return &driver, nil
 }
 
 func (r *MongoRepository) GetDriverByPlate(ctx context.Context, plate string) (*domain.Driver, error) {
+	ctx, done := r.observe(ctx, "GetDriverByPlate")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	var driver domain.Driver
+	// only drivers that are not deleted have an active plate, the lookup uses the unique plate index
+	err := collection.FindOne(ctx, bson.M{"activePlate": domain.NormalizePlate(plate)}).Decode(&driver)
+	if err != nil {
+		return nil, findError(err, domain.ErrDriverNotFound)
+	}
+
+	return &driver, nil
+}
+
+func (r *MongoRepository) GetDriverByUserID(ctx context.Context, userID string) (*domain.Driver, error) {
+	ctx, done := r.observe(ctx, "GetDriverByUserID")
+	defer done()
 	collection := r.DB.Collection(r.Collection)
 
 	var driver domain.Driver
-	err := collection.FindOne(ctx, bson.M{"plate": plate}).Decode(&driver)
+	err := collection.FindOne(ctx, bson.M{"userId": userID, "deletedAt": nil}).Decode(&driver)
 	if err != nil {
-		return nil, err
+		return nil, findError(err, domain.ErrDriverNotFound)
 	}
 
 	return &driver, nil
 }
+
+func (r *MongoRepository) UpdateDriverStatus(ctx context.Context, id string, from, to domain.DriverStatus) error {
+	ctx, done := r.observe(ctx, "UpdateDriverStatus")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	filter := bson.M{"_id": driverIDFilter(id), "status": from, "deletedAt": nil}
+	if from == domain.DriverOffline {
+		// drivers stored before statuses existed have no status field
+		filter["status"] = bson.M{"$in": bson.A{from, nil}}
+	}
+	update := bson.M{"$set": bson.M{"status": to, "updatedAt": time.Now()}}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return mongoError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return application.ErrDriverStateConflict
+	}
+
+	return nil
+}
+
+func (r *MongoRepository) SetCurrentShift(ctx context.Context, id, from, to string) error {
+	ctx, done := r.observe(ctx, "SetCurrentShift")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	filter := bson.M{"_id": driverIDFilter(id), "currentShiftId": from, "deletedAt": nil}
+	if from == "" {
+		filter["currentShiftId"] = nil // matches a missing field too
+	}
+
+	update := bson.M{"$set": bson.M{"currentShiftId": to}}
+	if to == "" {
+		update = bson.M{"$unset": bson.M{"currentShiftId": ""}}
+	}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return mongoError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return application.ErrDriverStateConflict
+	}
+
+	return nil
+}
+
+// driverIDFilter matches both ObjectID and string ids, drivers were stored with both
+func driverIDFilter(id string) any {
+	if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+		return bson.M{"$in": bson.A{objID, id}}
+	}
+	return id
+}
+
+func (r *MongoRepository) UpdateDriverLocations(ctx context.Context, updates []domain.LocationUpdate) ([]domain.LocationUpdate, error) {
+	ctx, done := r.observe(ctx, "UpdateDriverLocations")
+	defer done()
+	if len(updates) == 0 {
+		return nil, nil
+	}
+
+	collection := r.DB.Collection(r.Collection)
+
+	models := make([]mongo.WriteModel, 0, len(updates))
+	for _, u := range updates {
+		// the timestamp condition keeps late pings from moving the driver back
+		filter := bson.M{
+			"_id":       driverIDFilter(u.DriverID),
+			"deletedAt": nil,
+			"$or": bson.A{
+				bson.M{"locationUpdatedAt": bson.M{"$lt": u.RecordedAt}},
+				bson.M{"locationUpdatedAt": nil},
+			},
+		}
+		update := bson.M{"$set": bson.M{"location": u.Location, "locationUpdatedAt": u.RecordedAt}}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update))
+	}
+
+	// unordered lets the server apply the batch in parallel and not stop at the first failure
+	result, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	if result.ModifiedCount == 0 {
+		return nil, nil
+	}
+	return r.appliedLocationUpdates(ctx, updates)
+}
+
+// appliedLocationUpdates finds out which updates of a bulk write moved their driver, the bulk
+// result only counts them. An applied update left its timestamp on the driver. The batch holds
+// one update per driver, so the timestamp identifies it.
+func (r *MongoRepository) appliedLocationUpdates(ctx context.Context, updates []domain.LocationUpdate) ([]domain.LocationUpdate, error) {
+	collection := r.DB.Collection(r.Collection)
+
+	ids := bson.A{}
+	for _, u := range updates {
+		ids = append(ids, u.DriverID)
+		if objID, err := primitive.ObjectIDFromHex(u.DriverID); err == nil {
+			ids = append(ids, objID)
+		}
+	}
+
+	cursor, err := collection.Find(ctx,
+		bson.M{"_id": bson.M{"$in": ids}, "deletedAt": nil},
+		options.Find().SetProjection(bson.M{"taxiType": 1, "locationUpdatedAt": 1}))
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	type storedLocation struct {
+		ID                any        `bson:"_id"`
+		TaxiType          string     `bson:"taxiType"`
+		LocationUpdatedAt *time.Time `bson:"locationUpdatedAt"`
+	}
+	stored := make(map[string]storedLocation)
+	for cursor.Next(ctx) {
+		var s storedLocation
+		if err := cursor.Decode(&s); err != nil {
+			return nil, mongoError(err)
+		}
+		stored[idString(s.ID)] = s
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, mongoError(err)
+	}
+
+	var applied []domain.LocationUpdate
+	for _, u := range updates {
+		s, ok := stored[u.DriverID]
+		// Mongo keeps milliseconds only
+		if !ok || s.LocationUpdatedAt == nil || !s.LocationUpdatedAt.Equal(u.RecordedAt.Truncate(time.Millisecond)) {
+			continue
+		}
+		u.TaxiType = s.TaxiType
+		applied = append(applied, u)
+	}
+	return applied, nil
+}
+
+func (r *MongoRepository) GetAvailableDrivers(ctx context.Context) ([]*domain.Driver, error) {
+	ctx, done := r.observe(ctx, "GetAvailableDrivers")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	cursor, err := collection.Find(ctx, bson.M{"status": domain.DriverOnline, "deletedAt": nil})
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var drivers []*domain.Driver
+	for cursor.Next(ctx) {
+		var driver domain.Driver
+		if err := cursor.Decode(&driver); err != nil {
+			return nil, mongoError(err)
+		}
+		drivers = append(drivers, &driver)
+	}
+
+	return drivers, nil
+}
+
+func (r *MongoRepository) DeleteDriver(ctx context.Context, id, reason string) error {
+	ctx, done := r.observe(ctx, "DeleteDriver")
+	defer done()
+	return r.softDeleteDriver(ctx, id, reason, false)
+}
+
+func (r *MongoRepository) DeactivateDriver(ctx context.Context, id, reason string) error {
+	ctx, done := r.observe(ctx, "DeactivateDriver")
+	defer done()
+	return r.softDeleteDriver(ctx, id, reason, true)
+}
+
+// softDeleteDriver only matches a driver that is not deleted yet and has no open shift,
+// a shift started since the caller checked turns into a conflict
+func (r *MongoRepository) softDeleteDriver(ctx context.Context, id, reason string, deactivate bool) error {
+	collection := r.DB.Collection(r.Collection)
+
+	now := time.Now()
+	filter := bson.M{"_id": driverIDFilter(id), "deletedAt": nil, "currentShiftId": nil}
+	// the plate leaves the unique plate index, other drivers may take it while this one is deleted
+	update := bson.M{"$set": bson.M{
+		"deletedAt":      now,
+		"deletionReason": reason,
+		"deactivated":    deactivate,
+		"updatedAt":      now,
+	}, "$unset": bson.M{"activePlate": ""}, "$inc": bson.M{"version": 1}}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return mongoError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		return application.ErrDriverStateConflict
+	}
+
+	return nil
+}
+
+func (r *MongoRepository) RestoreDriver(ctx context.Context, id string) error {
+	ctx, done := r.observe(ctx, "RestoreDriver")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	filter := bson.M{"_id": driverIDFilter(id), "deletedAt": bson.M{"$ne": nil}}
+	// a pipeline so the plate can be copied back into the unique plate index, where the
+	// index rejects it if another driver took the plate meanwhile
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"activePlate": bson.M{"$cond": bson.A{bson.M{"$gt": bson.A{"$plate", ""}}, "$plate", "$$REMOVE"}},
+			"updatedAt":   time.Now(),
+			"version":     bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$version", 0}}, 1}},
+		}}},
+		{{Key: "$unset", Value: bson.A{"deletedAt", "deletionReason", "deactivated"}}},
+	}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return writeError(err, application.ErrPlateTakenOnRestore)
+	}
+
+	if result.MatchedCount == 0 {
+		return application.ErrDriverNotDeleted
+	}
+
+	return nil
+}
+
+func (r *MongoRepository) PurgeDeletedDrivers(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	ctx, done := r.observe(ctx, "PurgeDeletedDrivers")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	// uses the partial deletedAt index of migration 11
+	filter := bson.M{"deletedAt": bson.M{"$lt": deletedBefore}, "deactivated": bson.M{"$ne": true}}
+
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	var expired []bson.M
+	if err := cursor.All(ctx, &expired); err != nil {
+		return nil, mongoError(err)
+	}
+
+	// one by one with the filter again, a driver restored since the find is kept and not reported
+	var purged []string
+	for _, doc := range expired {
+		result, err := collection.DeleteOne(ctx, bson.M{"$and": bson.A{bson.M{"_id": doc["_id"]}, filter}})
+		if err != nil {
+			return purged, mongoError(err)
+		}
+		if result.DeletedCount == 1 {
+			purged = append(purged, idString(doc["_id"]))
+		}
+	}
+	return purged, nil
+}
+
+// idString is a driver id as the API shows it, for both ObjectID and string ids
+func idString(id any) string {
+	if objID, ok := id.(primitive.ObjectID); ok {
+		return objID.Hex()
+	}
+	s, _ := id.(string)
+	return s
+}
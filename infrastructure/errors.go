@@ -0,0 +1,36 @@
+package infrastructure
+
+import (
+	"errors"
+
+	"github.com/hekanemre/taxihub/domain"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// mongoError turns driver errors the caller can not do anything about into domain errors.
+// Anything else is returned as is.
+func mongoError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if mongo.IsTimeout(err) || mongo.IsNetworkError(err) {
+		return domain.ErrDatabaseUnavailable.Wrap(err)
+	}
+	return err
+}
+
+// writeError is mongoError for inserts and updates, a unique index violation becomes conflict
+func writeError(err error, conflict *domain.Error) error {
+	if mongo.IsDuplicateKeyError(err) {
+		return conflict.Wrap(err)
+	}
+	return mongoError(err)
+}
+
+// findError is mongoError for single document lookups, a missing document becomes notFound
+func findError(err error, notFound *domain.Error) error {
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return notFound.Wrap(err)
+	}
+	return mongoError(err)
+}
@@ -0,0 +1,52 @@
+package infrastructure
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/hekanemre/taxihub/application/audit"
+	"github.com/hekanemre/taxihub/domain"
+)
+
+func (r *MemoryRepository) AppendAuditRecord(ctx context.Context, record *domain.AuditRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cp := *record
+	cp.Changes = slices.Clone(record.Changes)
+	r.auditLog = append(r.auditLog, &cp)
+	return nil
+}
+
+func (r *MemoryRepository) GetAuditHistory(ctx context.Context, query audit.HistoryQuery) ([]*domain.AuditRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var records []*domain.AuditRecord
+	for _, record := range r.auditLog {
+		if record.Resource != query.Resource || record.ResourceID != query.ResourceID {
+			continue
+		}
+		if query.Before != nil && compareAuditRecords(record, query.Before) >= 0 {
+			continue
+		}
+		cp := *record
+		cp.Changes = slices.Clone(record.Changes)
+		records = append(records, &cp)
+	}
+
+	// newest first, the id breaking ties like the Mongo sort
+	slices.SortFunc(records, func(a, b *domain.AuditRecord) int {
+		return compareAuditRecords(b, audit.CursorBefore(a))
+	})
+
+	if query.Limit > 0 && len(records) > query.Limit {
+		records = records[:query.Limit]
+	}
+	return records, nil
+}
+
+func compareAuditRecords(record *domain.AuditRecord, cursor *audit.HistoryCursor) int {
+	return cmp.Or(record.Timestamp.Compare(cursor.Timestamp), cmp.Compare(record.ID, cursor.ID))
+}
@@ -0,0 +1,421 @@
+package infrastructure
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	application "github.com/hekanemre/taxihub/application/driver"
+	"github.com/hekanemre/taxihub/domain"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (r *MemoryRepository) CreateDriver(ctx context.Context, driver *domain.Driver) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.drivers[driver.ID]; exists {
+		return errors.New("driver with the same id already exists")
+	}
+
+	driver.Plate = domain.NormalizePlate(driver.Plate)
+	if r.plateTaken(driver.Plate, driver.ID) {
+		return application.ErrDuplicatePlate
+	}
+
+	r.drivers[driver.ID] = copyDriver(driver)
+	r.order = append(r.order, driver.ID)
+	return nil
+}
+
+func (r *MemoryRepository) UpdateDriver(ctx context.Context, driver *domain.Driver) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.drivers[driver.ID]
+	if !exists || stored.IsDeleted() {
+		return domain.ErrDriverNotFound
+	}
+	if stored.Version != driver.Version {
+		return domain.ErrDriverVersionMismatch
+	}
+
+	driver.Plate = domain.NormalizePlate(driver.Plate)
+	if r.plateTaken(driver.Plate, driver.ID) {
+		return application.ErrDuplicatePlate
+	}
+
+	// the same editable fields the Mongo $set writes
+	stored.FirstName = driver.FirstName
+	stored.LastName = driver.LastName
+	stored.Plate = driver.Plate
+	stored.TaxiType = driver.TaxiType
+	stored.CarBrand = driver.CarBrand
+	stored.CarModel = driver.CarModel
+	stored.Location = copyDriver(driver).Location
+	stored.UpdatedAt = driver.UpdatedAt
+	stored.Version++
+	driver.Version = stored.Version
+	return nil
+}
+
+func (r *MemoryRepository) GetAllDrivers(ctx context.Context, query application.DriverQuery) ([]*domain.Driver, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matching []*domain.Driver
+	for _, id := range r.order {
+		driver := r.drivers[id]
+		if !matchesDriverFilter(driver, query.Filter) {
+			continue
+		}
+		if query.After != nil {
+			after := compareDriverCursors(application.CursorAfter(driver, query.SortBy, query.Desc), query.After)
+			if query.Desc {
+				after = -after
+			}
+			if after <= 0 {
+				continue
+			}
+		}
+		matching = append(matching, driver)
+	}
+
+	sort.Slice(matching, func(i, j int) bool {
+		c := compareDriverCursors(
+			application.CursorAfter(matching[i], query.SortBy, query.Desc),
+			application.CursorAfter(matching[j], query.SortBy, query.Desc),
+		)
+		if query.Desc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	// limit 0 means no limit, like Mongo
+	if query.Limit > 0 && len(matching) > query.Limit {
+		matching = matching[:query.Limit]
+	}
+
+	drivers := make([]*domain.Driver, 0, len(matching))
+	for _, driver := range matching {
+		drivers = append(drivers, copyDriver(driver))
+	}
+	return drivers, nil
+}
+
+func (r *MemoryRepository) CountDrivers(ctx context.Context, filter application.DriverFilter) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var count int64
+	for _, driver := range r.drivers {
+		if matchesDriverFilter(driver, filter) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *MemoryRepository) SearchDrivers(ctx context.Context, grams []string, limit int) ([]*domain.Driver, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	type candidate struct {
+		driver *domain.Driver
+		shared int
+	}
+
+	var candidates []candidate
+	for _, id := range r.order {
+		driver := r.drivers[id]
+		if driver.IsDeleted() {
+			continue
+		}
+		shared := 0
+		for _, gram := range domain.DriverSearchGrams(driver) {
+			if slices.Contains(grams, gram) {
+				shared++
+			}
+		}
+		if shared > 0 {
+			candidates = append(candidates, candidate{driver: driver, shared: shared})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].shared > candidates[j].shared
+	})
+	if len(candidates) > limit {
+		candidates = candidates[:limit]
+	}
+
+	drivers := make([]*domain.Driver, 0, len(candidates))
+	for _, c := range candidates {
+		drivers = append(drivers, copyDriver(c.driver))
+	}
+	return drivers, nil
+}
+
+func matchesDriverFilter(driver *domain.Driver, filter application.DriverFilter) bool {
+	if driver.IsDeleted() {
+		return false
+	}
+	if filter.TaxiType != "" && driver.TaxiType != filter.TaxiType {
+		return false
+	}
+	if filter.CarBrand != "" && !strings.EqualFold(driver.CarBrand, filter.CarBrand) {
+		return false
+	}
+	if filter.Status != "" && driver.Status != filter.Status {
+		return false
+	}
+	if filter.CreatedFrom != nil && driver.CreatedAt.Before(*filter.CreatedFrom) {
+		return false
+	}
+	if filter.CreatedTo != nil && !driver.CreatedAt.Before(*filter.CreatedTo) {
+		return false
+	}
+	return true
+}
+
+// compareDriverCursors orders two sort keys of the same listing, the id breaks ties
+func compareDriverCursors(a, b *application.DriverCursor) int {
+	var c int
+	if a.SortBy == application.SortByName {
+		c = cmp.Or(cmp.Compare(a.FirstName, b.FirstName), cmp.Compare(a.LastName, b.LastName))
+	} else {
+		c = a.Time.Compare(*b.Time)
+	}
+	return cmp.Or(c, cmp.Compare(a.ID, b.ID))
+}
+
+func (r *MemoryRepository) GetAllDriversNearby(ctx context.Context, lat, lon float64, taxiType string, onlyAvailable bool) ([]*domain.Driver, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	maxDistanceKm := float64(r.nearbyDistance) / 1000
+
+	type candidate struct {
+		driver     *domain.Driver
+		distanceKm float64
+	}
+
+	var candidates []candidate
+	for _, id := range r.order {
+		driver := r.drivers[id]
+		if driver.IsDeleted() || driver.TaxiType != taxiType || len(driver.Location.Coordinates) != 2 {
+			continue
+		}
+		if onlyAvailable && !driver.IsAvailable() {
+			continue
+		}
+
+		// GeoJSON keeps coordinates as [lon, lat]
+		distanceKm := application.HaversineKm(lat, lon, driver.Location.Coordinates[1], driver.Location.Coordinates[0])
+		if distanceKm > maxDistanceKm {
+			continue
+		}
+		candidates = append(candidates, candidate{driver: driver, distanceKm: distanceKm})
+	}
+
+	// $near returns documents sorted from nearest to farthest
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].distanceKm < candidates[j].distanceKm
+	})
+
+	var drivers []*domain.Driver
+	for _, c := range candidates {
+		drivers = append(drivers, copyDriver(c.driver))
+	}
+	return drivers, nil
+}
+
+func (r *MemoryRepository) GetDriverByID(ctx context.Context, id string) (*domain.Driver, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	driver, exists := r.drivers[id]
+	if !exists || driver.IsDeleted() {
+		return nil, domain.ErrDriverNotFound.Wrap(mongo.ErrNoDocuments)
+	}
+
+	return copyDriver(driver), nil
+}
+
+// plateTaken mirrors the unique plate index, empty plates and soft deleted drivers are not indexed.
+// Callers hold the lock.
+func (r *MemoryRepository) plateTaken(plate, exceptID string) bool {
+	if plate == "" {
+		return false
+	}
+	for id, driver := range r.drivers {
+		if id != exceptID && driver.Plate == plate && !driver.IsDeleted() {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *MemoryRepository) GetDriverByPlate(ctx context.Context, plate string) (*domain.Driver, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	plate = domain.NormalizePlate(plate)
+	for _, id := range r.order {
+		if driver := r.drivers[id]; driver.Plate == plate && !driver.IsDeleted() {
+			return copyDriver(driver), nil
+		}
+	}
+
+	return nil, domain.ErrDriverNotFound.Wrap(mongo.ErrNoDocuments)
+}
+
+func (r *MemoryRepository) GetDriverByUserID(ctx context.Context, userID string) (*domain.Driver, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if userID != "" {
+		for _, id := range r.order {
+			if driver := r.drivers[id]; driver.UserID == userID && !driver.IsDeleted() {
+				return copyDriver(driver), nil
+			}
+		}
+	}
+
+	return nil, domain.ErrDriverNotFound.Wrap(mongo.ErrNoDocuments)
+}
+
+func (r *MemoryRepository) UpdateDriverStatus(ctx context.Context, id string, from, to domain.DriverStatus) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	driver, exists := r.drivers[id]
+	if !exists || driver.IsDeleted() || driver.CurrentStatus() != from {
+		return application.ErrDriverStateConflict
+	}
+
+	driver.Status = to
+	driver.UpdatedAt = time.Now()
+	return nil
+}
+
+func (r *MemoryRepository) SetCurrentShift(ctx context.Context, id, from, to string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	driver, exists := r.drivers[id]
+	if !exists || driver.IsDeleted() || driver.CurrentShiftID != from {
+		return application.ErrDriverStateConflict
+	}
+
+	driver.CurrentShiftID = to
+	return nil
+}
+
+func (r *MemoryRepository) UpdateDriverLocations(ctx context.Context, updates []domain.LocationUpdate) ([]domain.LocationUpdate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var applied []domain.LocationUpdate
+	for _, u := range updates {
+		driver, exists := r.drivers[u.DriverID]
+		if !exists || driver.IsDeleted() || (driver.LocationUpdatedAt != nil && !driver.LocationUpdatedAt.Before(u.RecordedAt)) {
+			continue
+		}
+
+		recordedAt := u.RecordedAt
+		driver.Location = domain.Location{
+			Type:        u.Location.Type,
+			Coordinates: append([]float64(nil), u.Location.Coordinates...),
+		}
+		driver.LocationUpdatedAt = &recordedAt
+
+		u.TaxiType = driver.TaxiType
+		applied = append(applied, u)
+	}
+
+	return applied, nil
+}
+
+func (r *MemoryRepository) GetAvailableDrivers(ctx context.Context) ([]*domain.Driver, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var drivers []*domain.Driver
+	for _, id := range r.order {
+		if driver := r.drivers[id]; driver.IsAvailable() && !driver.IsDeleted() {
+			drivers = append(drivers, copyDriver(driver))
+		}
+	}
+
+	return drivers, nil
+}
+
+func (r *MemoryRepository) DeleteDriver(ctx context.Context, id, reason string) error {
+	return r.softDeleteDriver(id, reason, false)
+}
+
+func (r *MemoryRepository) DeactivateDriver(ctx context.Context, id, reason string) error {
+	return r.softDeleteDriver(id, reason, true)
+}
+
+func (r *MemoryRepository) softDeleteDriver(id, reason string, deactivate bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	driver, exists := r.drivers[id]
+	if !exists || driver.IsDeleted() || driver.OnShift() {
+		return application.ErrDriverStateConflict
+	}
+
+	now := time.Now()
+	driver.DeletedAt = &now
+	driver.DeletionReason = reason
+	driver.Deactivated = deactivate
+	driver.UpdatedAt = now
+	driver.Version++
+	return nil
+}
+
+func (r *MemoryRepository) RestoreDriver(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	driver, exists := r.drivers[id]
+	if !exists || !driver.IsDeleted() {
+		return application.ErrDriverNotDeleted
+	}
+	if r.plateTaken(driver.Plate, id) {
+		return application.ErrPlateTakenOnRestore
+	}
+
+	driver.DeletedAt = nil
+	driver.DeletionReason = ""
+	driver.Deactivated = false
+	driver.UpdatedAt = time.Now()
+	driver.Version++
+	return nil
+}
+
+func (r *MemoryRepository) PurgeDeletedDrivers(ctx context.Context, deletedBefore time.Time) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged []string
+	r.order = slices.DeleteFunc(r.order, func(id string) bool {
+		driver := r.drivers[id]
+		if !driver.IsDeleted() || driver.Deactivated || !driver.DeletedAt.Before(deletedBefore) {
+			return false
+		}
+		delete(r.drivers, id)
+		purged = append(purged, id)
+		return true
+	})
+
+	return purged, nil
+}
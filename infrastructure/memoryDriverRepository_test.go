@@ -0,0 +1,137 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	application "github.com/hekanemre/taxihub/application/driver"
+	"github.com/hekanemre/taxihub/domain"
+)
+
+func newTestDriver(id, plate string) *domain.Driver {
+	return &domain.Driver{ID: id, FirstName: "Ada", LastName: "Lovelace", Plate: plate, TaxiType: "yellow", Version: 1}
+}
+
+func TestMemoryDriverRepository_CreateAndGet(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository(1000)
+
+	if err := repo.CreateDriver(ctx, newTestDriver("d1", "34ABC123")); err != nil {
+		t.Fatalf("CreateDriver: %v", err)
+	}
+	if err := repo.CreateDriver(ctx, newTestDriver("d1", "06XY42")); err == nil {
+		t.Fatal("CreateDriver with a taken id succeeded")
+	}
+
+	// the plate is stored normalized
+	driver, err := repo.GetDriverByID(ctx, "d1")
+	if err != nil || driver.Plate != "34 ABC 123" {
+		t.Fatalf("GetDriverByID = %+v, %v, want d1 with plate 34 ABC 123", driver, err)
+	}
+	if _, err := repo.GetDriverByID(ctx, "d2"); !errors.Is(err, domain.ErrDriverNotFound) {
+		t.Fatalf("GetDriverByID of an unknown id = %v, want ErrDriverNotFound", err)
+	}
+
+	// a returned driver is a copy, changing it must not change the stored one
+	driver.Plate = "changed"
+	if driver, err := repo.GetDriverByPlate(ctx, "34ABC123"); err != nil || driver.ID != "d1" {
+		t.Fatalf("GetDriverByPlate after changing the copy = %+v, %v, want d1", driver, err)
+	}
+}
+
+func TestMemoryDriverRepository_GetAllDriversNearby(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository(1000)
+
+	// GeoJSON keeps [lon, lat]
+	at := func(id, taxiType string, lon, lat float64) *domain.Driver {
+		driver := newTestDriver(id, id)
+		driver.TaxiType = taxiType
+		driver.Location = domain.Location{Type: "Point", Coordinates: []float64{lon, lat}}
+		return driver
+	}
+	for _, driver := range []*domain.Driver{
+		at("far", "yellow", 29.0080, 41.0000),
+		at("near", "yellow", 29.0010, 41.0000),
+		at("black", "black", 29.0000, 41.0000),
+		at("out", "yellow", 29.1000, 41.0000),
+	} {
+		if err := repo.CreateDriver(ctx, driver); err != nil {
+			t.Fatalf("CreateDriver: %v", err)
+		}
+	}
+
+	drivers, err := repo.GetAllDriversNearby(ctx, 41.0000, 29.0000, "yellow", false)
+	if err != nil {
+		t.Fatalf("GetAllDriversNearby: %v", err)
+	}
+	var ids []string
+	for _, driver := range drivers {
+		ids = append(ids, driver.ID)
+	}
+	// nearest first, other taxi types and drivers past nearbyDistance are left out
+	if len(ids) != 2 || ids[0] != "near" || ids[1] != "far" {
+		t.Fatalf("GetAllDriversNearby = %v, want [near far]", ids)
+	}
+}
+
+func TestMemoryDriverRepository_DeletedDriverFreesPlate(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository(1000)
+
+	if err := repo.CreateDriver(ctx, newTestDriver("d1", "34 ABC 123")); err != nil {
+		t.Fatalf("CreateDriver: %v", err)
+	}
+	if err := repo.CreateDriver(ctx, newTestDriver("d2", "34abc123")); !errors.Is(err, application.ErrDuplicatePlate) {
+		t.Fatalf("CreateDriver with a taken plate = %v, want ErrDuplicatePlate", err)
+	}
+
+	if err := repo.DeactivateDriver(ctx, "d1", "license expired"); err != nil {
+		t.Fatalf("DeactivateDriver: %v", err)
+	}
+	if err := repo.CreateDriver(ctx, newTestDriver("d2", "34abc123")); err != nil {
+		t.Fatalf("CreateDriver with the plate of a deactivated driver: %v", err)
+	}
+
+	driver, err := repo.GetDriverByPlate(ctx, "34 ABC 123")
+	if err != nil || driver.ID != "d2" {
+		t.Fatalf("GetDriverByPlate = %+v, %v, want d2", driver, err)
+	}
+
+	if err := repo.RestoreDriver(ctx, "d1"); !errors.Is(err, application.ErrPlateTakenOnRestore) {
+		t.Fatalf("RestoreDriver with a taken plate = %v, want ErrPlateTakenOnRestore", err)
+	}
+
+	if err := repo.DeleteDriver(ctx, "d2", "left the fleet"); err != nil {
+		t.Fatalf("DeleteDriver: %v", err)
+	}
+	if err := repo.RestoreDriver(ctx, "d1"); err != nil {
+		t.Fatalf("RestoreDriver once the plate is free: %v", err)
+	}
+}
+
+func TestMemoryDriverRepository_UpdateDriverMiss(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository(1000)
+
+	if err := repo.CreateDriver(ctx, newTestDriver("d1", "34 ABC 123")); err != nil {
+		t.Fatalf("CreateDriver: %v", err)
+	}
+
+	stale := newTestDriver("d1", "34 ABC 123")
+	stale.Version = 0
+	if err := repo.UpdateDriver(ctx, stale); !errors.Is(err, domain.ErrDriverVersionMismatch) {
+		t.Fatalf("UpdateDriver with a stale version = %v, want ErrDriverVersionMismatch", err)
+	}
+
+	if err := repo.DeleteDriver(ctx, "d1", "left the fleet"); err != nil {
+		t.Fatalf("DeleteDriver: %v", err)
+	}
+	if err := repo.UpdateDriver(ctx, newTestDriver("d1", "34 ABC 123")); !errors.Is(err, domain.ErrDriverNotFound) {
+		t.Fatalf("UpdateDriver of a deleted driver = %v, want ErrDriverNotFound", err)
+	}
+	if err := repo.UpdateDriver(ctx, newTestDriver("d2", "06 XY 42")); !errors.Is(err, domain.ErrDriverNotFound) {
+		t.Fatalf("UpdateDriver of a missing driver = %v, want ErrDriverNotFound", err)
+	}
+}
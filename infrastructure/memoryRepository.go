@@ -0,0 +1,65 @@
+package infrastructure
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hekanemre/taxihub/application/audit"
+	application "github.com/hekanemre/taxihub/application/driver"
+	"github.com/hekanemre/taxihub/application/pricing"
+	"github.com/hekanemre/taxihub/application/ride"
+	"github.com/hekanemre/taxihub/application/surge"
+	"github.com/hekanemre/taxihub/domain"
+)
+
+// MemoryRepository keeps drivers, shifts, rides, tariffs, surge, revoked tokens, the audit log and users in process memory.
+// It is meant for tests and local development where MongoDB is not available.
+type MemoryRepository struct {
+	mu             sync.RWMutex
+	drivers        map[string]*domain.Driver
+	order          []string // insertion order, mirrors Mongo natural order
+	nearbyDistance int      // in meters, same as config nearbyDistance
+	shifts         map[string]*domain.Shift
+	rides          map[string]*domain.Ride
+	tariffs        map[string]*domain.Tariff
+	surgeCells     map[string]*domain.SurgeCell
+	surgeHistory   []*domain.SurgeChange
+	revokedTokens  map[string]time.Time // token id to expiry
+	auditLog       []*domain.AuditRecord
+	users          map[string]*domain.User // by user_id
+	// rotated refresh token ids per user_id, the Mongo store keeps them on the user document
+	rotatedRefreshTokens map[string][]string
+}
+
+// make sure we stay in sync with the application port
+var _ application.Repository = (*MemoryRepository)(nil)
+var _ application.ShiftRepository = (*MemoryRepository)(nil)
+var _ ride.Repository = (*MemoryRepository)(nil)
+var _ pricing.Repository = (*MemoryRepository)(nil)
+var _ surge.Repository = (*MemoryRepository)(nil)
+var _ surge.HistoryRepository = (*MemoryRepository)(nil)
+var _ audit.Repository = (*MemoryRepository)(nil)
+
+func NewMemoryRepository(nearbyDistance int) *MemoryRepository {
+	return &MemoryRepository{
+		drivers:        make(map[string]*domain.Driver),
+		nearbyDistance: nearbyDistance,
+		shifts:         make(map[string]*domain.Shift),
+		rides:          make(map[string]*domain.Ride),
+		tariffs:        make(map[string]*domain.Tariff),
+		surgeCells:     make(map[string]*domain.SurgeCell),
+		revokedTokens:  make(map[string]time.Time),
+		users:          make(map[string]*domain.User),
+
+		rotatedRefreshTokens: make(map[string][]string),
+	}
+}
+
+// copyDriver returns a deep copy so callers can never mutate stored state
+func copyDriver(driver *domain.Driver) *domain.Driver {
+	cp := *driver
+	if driver.Location.Coordinates != nil {
+		cp.Location.Coordinates = append([]float64(nil), driver.Location.Coordinates...)
+	}
+	return &cp
+}
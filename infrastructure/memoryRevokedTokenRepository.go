@@ -0,0 +1,30 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+)
+
+func (r *MemoryRepository) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	// drop what expired on the way, there is no TTL monitor here
+	for id, exp := range r.revokedTokens {
+		if !exp.After(now) {
+			delete(r.revokedTokens, id)
+		}
+	}
+
+	r.revokedTokens[tokenID] = expiresAt
+	return nil
+}
+
+func (r *MemoryRepository) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	expiresAt, exists := r.revokedTokens[tokenID]
+	return exists && expiresAt.After(time.Now()), nil
+}
@@ -0,0 +1,102 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hekanemre/taxihub/application/ride"
+	"github.com/hekanemre/taxihub/domain"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (r *MemoryRepository) CreateRide(ctx context.Context, rd *domain.Ride) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.rides[rd.ID]; exists {
+		return errors.New("ride with the same id already exists")
+	}
+
+	r.rides[rd.ID] = copyRide(rd)
+	return nil
+}
+
+func (r *MemoryRepository) UpdateRide(ctx context.Context, rd *domain.Ride) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, exists := r.rides[rd.ID]
+	if !exists || stored.Version != rd.Version {
+		return ride.ErrRideConflict
+	}
+
+	rd.Version++
+	r.rides[rd.ID] = copyRide(rd)
+	return nil
+}
+
+func (r *MemoryRepository) GetRideByID(ctx context.Context, id string) (*domain.Ride, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rd, exists := r.rides[id]
+	if !exists {
+		return nil, domain.ErrRideNotFound.Wrap(mongo.ErrNoDocuments)
+	}
+
+	return copyRide(rd), nil
+}
+
+func (r *MemoryRepository) HasActiveRide(ctx context.Context, driverID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, rd := range r.rides {
+		if !rd.IsActive() {
+			continue
+		}
+		if rd.DriverID == driverID || (rd.Status == domain.RideRequested && rd.OfferedDriverID == driverID) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (r *MemoryRepository) GetRidesWithExpiredOffers(ctx context.Context, now time.Time) ([]*domain.Ride, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rides []*domain.Ride
+	for _, rd := range r.rides {
+		if rd.Status == domain.RideRequested && rd.OfferExpired(now) {
+			rides = append(rides, copyRide(rd))
+		}
+	}
+
+	return rides, nil
+}
+
+func (r *MemoryRepository) GetRidesRequestedSince(ctx context.Context, since time.Time) ([]*domain.Ride, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var rides []*domain.Ride
+	for _, rd := range r.rides {
+		if !rd.CreatedAt.Before(since) {
+			rides = append(rides, copyRide(rd))
+		}
+	}
+
+	return rides, nil
+}
+
+func copyRide(rd *domain.Ride) *domain.Ride {
+	cp := *rd
+	cp.Pickup.Coordinates = append([]float64(nil), rd.Pickup.Coordinates...)
+	cp.Dropoff.Coordinates = append([]float64(nil), rd.Dropoff.Coordinates...)
+	cp.DeclinedDriverIDs = append([]string{}, rd.DeclinedDriverIDs...)
+	// time pointers are never mutated in place, sharing them is safe
+	return &cp
+}
@@ -0,0 +1,78 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (r *MemoryRepository) CreateShift(ctx context.Context, shift *domain.Shift) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.shifts[shift.ID]; exists {
+		return errors.New("shift with the same id already exists")
+	}
+
+	r.shifts[shift.ID] = copyShift(shift)
+	return nil
+}
+
+func (r *MemoryRepository) UpdateShift(ctx context.Context, shift *domain.Shift) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.shifts[shift.ID]; exists {
+		r.shifts[shift.ID] = copyShift(shift)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) DeleteShift(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.shifts, id)
+	return nil
+}
+
+func (r *MemoryRepository) GetShiftByID(ctx context.Context, id string) (*domain.Shift, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	shift, exists := r.shifts[id]
+	if !exists {
+		return nil, domain.ErrShiftNotFound.Wrap(mongo.ErrNoDocuments)
+	}
+
+	return copyShift(shift), nil
+}
+
+func (r *MemoryRepository) GetShiftsByDriver(ctx context.Context, driverID string, from, to time.Time) ([]*domain.Shift, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var shifts []*domain.Shift
+	for _, shift := range r.shifts {
+		if shift.DriverID != driverID || shift.StartedAt.Before(from) || !shift.StartedAt.Before(to) {
+			continue
+		}
+		shifts = append(shifts, copyShift(shift))
+	}
+
+	sort.Slice(shifts, func(i, j int) bool {
+		return shifts[i].StartedAt.After(shifts[j].StartedAt)
+	})
+
+	return shifts, nil
+}
+
+func copyShift(shift *domain.Shift) *domain.Shift {
+	cp := *shift
+	cp.Breaks = append([]domain.Break{}, shift.Breaks...)
+	return &cp
+}
@@ -0,0 +1,97 @@
+package infrastructure
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (r *MemoryRepository) SaveSurgeCells(ctx context.Context, cells []*domain.SurgeCell) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, cell := range cells {
+		cp := *cell
+		r.surgeCells[cell.ID] = &cp
+	}
+	return nil
+}
+
+func (r *MemoryRepository) DeleteSurgeCells(ctx context.Context, ids []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, id := range ids {
+		delete(r.surgeCells, id)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) GetSurgeCell(ctx context.Context, id string) (*domain.SurgeCell, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	cell, exists := r.surgeCells[id]
+	if !exists {
+		return nil, domain.ErrSurgeCellNotFound.Wrap(mongo.ErrNoDocuments)
+	}
+
+	cp := *cell
+	return &cp, nil
+}
+
+func (r *MemoryRepository) GetAllSurgeCells(ctx context.Context) ([]*domain.SurgeCell, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var cells []*domain.SurgeCell
+	for _, cell := range r.surgeCells {
+		cp := *cell
+		cells = append(cells, &cp)
+	}
+
+	sort.Slice(cells, func(i, j int) bool {
+		return cells[i].ID < cells[j].ID
+	})
+
+	return cells, nil
+}
+
+func (r *MemoryRepository) AppendSurgeChanges(ctx context.Context, changes []*domain.SurgeChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, change := range changes {
+		cp := *change
+		r.surgeHistory = append(r.surgeHistory, &cp)
+	}
+	return nil
+}
+
+func (r *MemoryRepository) GetSurgeHistory(ctx context.Context, cellID string, from, to time.Time, limit int) ([]*domain.SurgeChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var changes []*domain.SurgeChange
+	// appended in time order, walking backwards gives newest first
+	for i := len(r.surgeHistory) - 1; i >= 0; i-- {
+		change := r.surgeHistory[i]
+		if cellID != "" && change.CellID != cellID {
+			continue
+		}
+		if change.ChangedAt.Before(from) || !change.ChangedAt.Before(to) {
+			continue
+		}
+
+		cp := *change
+		changes = append(changes, &cp)
+		if limit > 0 && len(changes) == limit {
+			break
+		}
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,59 @@
+package infrastructure
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hekanemre/taxihub/domain"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (r *MemoryRepository) GetTariff(ctx context.Context, taxiType string) (*domain.Tariff, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tariff, exists := r.tariffs[taxiType]
+	if !exists {
+		return nil, domain.ErrTariffNotFound.Wrap(mongo.ErrNoDocuments)
+	}
+
+	cp := *tariff
+	return &cp, nil
+}
+
+func (r *MemoryRepository) GetAllTariffs(ctx context.Context) ([]*domain.Tariff, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var tariffs []*domain.Tariff
+	for _, tariff := range r.tariffs {
+		cp := *tariff
+		tariffs = append(tariffs, &cp)
+	}
+
+	sort.Slice(tariffs, func(i, j int) bool {
+		return tariffs[i].TaxiType < tariffs[j].TaxiType
+	})
+
+	return tariffs, nil
+}
+
+func (r *MemoryRepository) SaveTariff(ctx context.Context, tariff *domain.Tariff) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cp := *tariff
+	r.tariffs[tariff.TaxiType] = &cp
+	return nil
+}
+
+func (r *MemoryRepository) CreateTariffIfMissing(ctx context.Context, tariff *domain.Tariff) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.tariffs[tariff.TaxiType]; !exists {
+		cp := *tariff
+		r.tariffs[tariff.TaxiType] = &cp
+	}
+	return nil
+}
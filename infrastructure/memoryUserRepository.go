@@ -0,0 +1,129 @@
+package infrastructure
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func (r *MemoryRepository) UserExists(ctx context.Context, email, phone string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.userTaken(email, phone), nil
+}
+
+func (r *MemoryRepository) CreateUser(ctx context.Context, user *domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// same as the unique indexes on users.email and users.phone
+	if r.userTaken(stringValue(user.Email), stringValue(user.Phone)) {
+		return domain.ErrEmailOrPhoneExists
+	}
+
+	r.users[user.User_id] = copyUser(user)
+	return nil
+}
+
+func (r *MemoryRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, user := range r.users {
+		if stringValue(user.Email) == email {
+			return copyUser(user), nil
+		}
+	}
+	return nil, domain.ErrUserNotFound.Wrap(mongo.ErrNoDocuments)
+}
+
+func (r *MemoryRepository) GetUserByID(ctx context.Context, uid string) (*domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, exists := r.users[uid]
+	if !exists {
+		return nil, domain.ErrUserNotFound.Wrap(mongo.ErrNoDocuments)
+	}
+	return copyUser(user), nil
+}
+
+func (r *MemoryRepository) SetUserType(ctx context.Context, uid, userType string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, exists := r.users[uid]
+	if !exists {
+		return domain.ErrUserNotFound.Wrap(mongo.ErrNoDocuments)
+	}
+	user.User_type = &userType
+	user.Updated_at = time.Now()
+	return nil
+}
+
+func (r *MemoryRepository) SetUserTokens(ctx context.Context, uid, token, refreshToken string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if user, exists := r.users[uid]; exists {
+		user.Token = &token
+		user.Refresh_token = &refreshToken
+		user.Updated_at = time.Now()
+	}
+	return nil
+}
+
+func (r *MemoryRepository) RotateUserTokens(ctx context.Context, uid, presentedRefreshToken, token, refreshToken, usedTokenID string, keep int) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user, exists := r.users[uid]
+	if !exists || stringValue(user.Refresh_token) != presentedRefreshToken {
+		return false, nil
+	}
+
+	user.Token = &token
+	user.Refresh_token = &refreshToken
+	user.Updated_at = time.Now()
+
+	rotated := append(r.rotatedRefreshTokens[uid], usedTokenID)
+	if len(rotated) > keep {
+		rotated = rotated[len(rotated)-keep:]
+	}
+	r.rotatedRefreshTokens[uid] = rotated
+	return true, nil
+}
+
+func (r *MemoryRepository) IsRefreshTokenRotated(ctx context.Context, uid, tokenID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Contains(r.rotatedRefreshTokens[uid], tokenID), nil
+}
+
+// userTaken must be called with r.mu held
+func (r *MemoryRepository) userTaken(email, phone string) bool {
+	for _, user := range r.users {
+		if stringValue(user.Email) == email || stringValue(user.Phone) == phone {
+			return true
+		}
+	}
+	return false
+}
+
+// copyUser returns a copy callers can change, the string pointers are never written through
+func copyUser(user *domain.User) *domain.User {
+	cp := *user
+	return &cp
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,164 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hekanemre/taxihub/domain"
+)
+
+func newTestUser(uid, email, phone string) *domain.User {
+	firstName, lastName, userType := "Ada", "Lovelace", "USER"
+	return &domain.User{
+		First_name: &firstName,
+		Last_name:  &lastName,
+		Email:      &email,
+		Phone:      &phone,
+		User_type:  &userType,
+		User_id:    uid,
+	}
+}
+
+func TestMemoryUserRepository_CreateUser(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository(1000)
+
+	if err := repo.CreateUser(ctx, newTestUser("u1", "ada@example.com", "+100")); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	exists, err := repo.UserExists(ctx, "ada@example.com", "+999")
+	if err != nil || !exists {
+		t.Fatalf("UserExists by email = %v, %v, want true", exists, err)
+	}
+	exists, err = repo.UserExists(ctx, "other@example.com", "+100")
+	if err != nil || !exists {
+		t.Fatalf("UserExists by phone = %v, %v, want true", exists, err)
+	}
+
+	err = repo.CreateUser(ctx, newTestUser("u2", "ada@example.com", "+200"))
+	if !errors.Is(err, domain.ErrEmailOrPhoneExists) {
+		t.Fatalf("CreateUser with a taken email = %v, want ErrEmailOrPhoneExists", err)
+	}
+}
+
+func TestMemoryUserRepository_GetUser(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository(1000)
+	if err := repo.CreateUser(ctx, newTestUser("u1", "ada@example.com", "+100")); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	user, err := repo.GetUserByEmail(ctx, "ada@example.com")
+	if err != nil || user.User_id != "u1" {
+		t.Fatalf("GetUserByEmail = %v, %v, want u1", user, err)
+	}
+	if _, err := repo.GetUserByEmail(ctx, "nobody@example.com"); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Fatalf("GetUserByEmail of an unknown email = %v, want ErrUserNotFound", err)
+	}
+	if _, err := repo.GetUserByID(ctx, "u2"); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Fatalf("GetUserByID of an unknown id = %v, want ErrUserNotFound", err)
+	}
+
+	// a returned user is a copy, changing it must not change the stored one
+	user.User_id = "changed"
+	if _, err := repo.GetUserByID(ctx, "u1"); err != nil {
+		t.Fatalf("GetUserByID after changing the copy: %v", err)
+	}
+}
+
+func TestMemoryUserRepository_SetUserTokens(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository(1000)
+	if err := repo.CreateUser(ctx, newTestUser("u1", "ada@example.com", "+100")); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	if err := repo.SetUserTokens(ctx, "u1", "access-1", "refresh-1"); err != nil {
+		t.Fatalf("SetUserTokens: %v", err)
+	}
+	user, err := repo.GetUserByID(ctx, "u1")
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if *user.Token != "access-1" || *user.Refresh_token != "refresh-1" {
+		t.Fatalf("stored tokens = %q, %q, want access-1, refresh-1", *user.Token, *user.Refresh_token)
+	}
+
+	// same as a Mongo update without upsert, an unknown user is not an error
+	if err := repo.SetUserTokens(ctx, "u2", "access-2", "refresh-2"); err != nil {
+		t.Fatalf("SetUserTokens of an unknown user: %v", err)
+	}
+	if _, err := repo.GetUserByID(ctx, "u2"); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Fatalf("GetUserByID after SetUserTokens of an unknown user = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestMemoryUserRepository_RotateUserTokens(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository(1000)
+	if err := repo.CreateUser(ctx, newTestUser("u1", "ada@example.com", "+100")); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if err := repo.SetUserTokens(ctx, "u1", "access-1", "refresh-1"); err != nil {
+		t.Fatalf("SetUserTokens: %v", err)
+	}
+
+	rotated, err := repo.RotateUserTokens(ctx, "u1", "refresh-1", "access-2", "refresh-2", "jti-1", 2)
+	if err != nil || !rotated {
+		t.Fatalf("RotateUserTokens with the current token = %v, %v, want true", rotated, err)
+	}
+
+	// the used token is not the current one anymore
+	rotated, err = repo.RotateUserTokens(ctx, "u1", "refresh-1", "access-3", "refresh-3", "jti-1", 2)
+	if err != nil || rotated {
+		t.Fatalf("RotateUserTokens with a used token = %v, %v, want false", rotated, err)
+	}
+
+	user, err := repo.GetUserByID(ctx, "u1")
+	if err != nil {
+		t.Fatalf("GetUserByID: %v", err)
+	}
+	if *user.Token != "access-2" || *user.Refresh_token != "refresh-2" {
+		t.Fatalf("stored tokens = %q, %q, want access-2, refresh-2", *user.Token, *user.Refresh_token)
+	}
+
+	if reused, _ := repo.IsRefreshTokenRotated(ctx, "u1", "jti-1"); !reused {
+		t.Fatal("IsRefreshTokenRotated(jti-1) = false, want true")
+	}
+
+	// only the last keep ids are remembered
+	if _, err := repo.RotateUserTokens(ctx, "u1", "refresh-2", "access-3", "refresh-3", "jti-2", 2); err != nil {
+		t.Fatalf("RotateUserTokens: %v", err)
+	}
+	if _, err := repo.RotateUserTokens(ctx, "u1", "refresh-3", "access-4", "refresh-4", "jti-3", 2); err != nil {
+		t.Fatalf("RotateUserTokens: %v", err)
+	}
+	if reused, _ := repo.IsRefreshTokenRotated(ctx, "u1", "jti-1"); reused {
+		t.Fatal("IsRefreshTokenRotated(jti-1) = true after it fell out of the kept ids")
+	}
+	if reused, _ := repo.IsRefreshTokenRotated(ctx, "u1", "jti-3"); !reused {
+		t.Fatal("IsRefreshTokenRotated(jti-3) = false, want true")
+	}
+}
+
+func TestMemoryUserRepository_SetUserType(t *testing.T) {
+	ctx := context.Background()
+	repo := NewMemoryRepository(1000)
+	if err := repo.CreateUser(ctx, newTestUser("u1", "ada@example.com", "+100")); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	if err := repo.SetUserType(ctx, "u1", "DISPATCHER"); err != nil {
+		t.Fatalf("SetUserType: %v", err)
+	}
+	user, err := repo.GetUserByID(ctx, "u1")
+	if err != nil || *user.User_type != "DISPATCHER" {
+		t.Fatalf("GetUserByID = %v, %v, want a DISPATCHER", user, err)
+	}
+
+	if err := repo.SetUserType(ctx, "u2", "ADMIN"); !errors.Is(err, domain.ErrUserNotFound) {
+		t.Fatalf("SetUserType of an unknown user = %v, want ErrUserNotFound", err)
+	}
+}
@@ -0,0 +1,294 @@
+package infrastructure
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hekanemre/taxihub/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migrations is every schema change in the order it is applied.
+// Append new migrations with the next version, never edit or reorder applied ones.
+var Migrations = []Migration{
+	{
+		Version:     1,
+		Description: "2dsphere index on drivers.location",
+		Up: createIndex("drivers", mongo.IndexModel{
+			Keys:    bson.D{{Key: "location", Value: "2dsphere"}},
+			Options: options.Index().SetName("location_2dsphere"),
+		}),
+	},
+	{
+		Version:     2,
+		Description: "normalize stored driver plates",
+		Up:          normalizePlates,
+	},
+	{
+		Version:     3,
+		Description: "unique index on drivers.plate",
+		// drivers without a plate are left out of the index
+		Up: createIndex("drivers", mongo.IndexModel{
+			Keys: bson.D{{Key: "plate", Value: 1}},
+			Options: options.Index().
+				SetName("plate_unique").
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"plate": bson.M{"$gt": ""}}),
+		}),
+	},
+	{
+		Version:     4,
+		Description: "unique indexes on users.email, users.phone and users.user_id",
+		Up: createIndex("users",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "email", Value: 1}},
+				Options: options.Index().SetName("email_unique").SetUnique(true),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "phone", Value: 1}},
+				Options: options.Index().SetName("phone_unique").SetUnique(true),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "user_id", Value: 1}},
+				Options: options.Index().SetName("user_id_unique").SetUnique(true),
+			},
+		),
+	},
+	{
+		Version:     5,
+		Description: "ttl index on revoked_tokens.expiresAt",
+		Up: createIndex("revoked_tokens", mongo.IndexModel{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetName("expiresAt_ttl").SetExpireAfterSeconds(0),
+		}),
+	},
+	{
+		Version:     6,
+		Description: "set OFFLINE on drivers stored before statuses existed",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			_, err := db.Collection("drivers").UpdateMany(ctx,
+				bson.M{"status": bson.M{"$exists": false}},
+				bson.M{"$set": bson.M{"status": domain.DriverOffline}},
+			)
+			return mongoError(err)
+		},
+	},
+	{
+		Version:     7,
+		Description: "query indexes on shifts, rides and surge_history",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			if err := createIndex("shifts", mongo.IndexModel{
+				Keys:    bson.D{{Key: "driverId", Value: 1}, {Key: "startedAt", Value: -1}},
+				Options: options.Index().SetName("driverId_startedAt"),
+			})(ctx, db); err != nil {
+				return err
+			}
+			if err := createIndex("rides",
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "createdAt", Value: 1}},
+					Options: options.Index().SetName("createdAt"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "driverId", Value: 1}, {Key: "status", Value: 1}},
+					Options: options.Index().SetName("driverId_status"),
+				},
+				mongo.IndexModel{
+					Keys:    bson.D{{Key: "offeredDriverId", Value: 1}, {Key: "status", Value: 1}},
+					Options: options.Index().SetName("offeredDriverId_status"),
+				},
+			)(ctx, db); err != nil {
+				return err
+			}
+			return createIndex("surge_history", mongo.IndexModel{
+				Keys:    bson.D{{Key: "cellId", Value: 1}, {Key: "changedAt", Value: -1}},
+				Options: options.Index().SetName("cellId_changedAt"),
+			})(ctx, db)
+		},
+	},
+	{
+		Version:     8,
+		Description: "ttl index on rate_limits.expiresAt",
+		Up: createIndex("rate_limits", mongo.IndexModel{
+			Keys:    bson.D{{Key: "expiresAt", Value: 1}},
+			Options: options.Index().SetName("expiresAt_ttl").SetExpireAfterSeconds(0),
+		}),
+	},
+	{
+		Version:     9,
+		Description: "sort indexes for the driver listing",
+		// the id ends every listing sort so cursors are unambiguous
+		Up: createIndex("drivers",
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}},
+				Options: options.Index().SetName("createdAt_id"),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "updatedAt", Value: 1}, {Key: "_id", Value: 1}},
+				Options: options.Index().SetName("updatedAt_id"),
+			},
+			mongo.IndexModel{
+				Keys:    bson.D{{Key: "firstName", Value: 1}, {Key: "lastName", Value: 1}, {Key: "_id", Value: 1}},
+				Options: options.Index().SetName("firstName_lastName_id"),
+			},
+		),
+	},
+	{
+		Version:     10,
+		Description: "search grams on drivers and their index",
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			if err := backfillSearchGrams(ctx, db); err != nil {
+				return err
+			}
+			return createIndex("drivers", mongo.IndexModel{
+				Keys:    bson.D{{Key: "searchGrams", Value: 1}},
+				Options: options.Index().SetName("searchGrams"),
+			})(ctx, db)
+		},
+	},
+	{
+		Version:     11,
+		Description: "partial index on drivers.deletedAt for the purge",
+		// only soft deleted drivers carry the field, the index stays as small as they are few
+		Up: createIndex("drivers", mongo.IndexModel{
+			Keys: bson.D{{Key: "deletedAt", Value: 1}},
+			Options: options.Index().
+				SetName("deletedAt_partial").
+				SetPartialFilterExpression(bson.M{"deletedAt": bson.M{"$exists": true}}),
+		}),
+	},
+	{
+		Version:     12,
+		Description: "history index on audit_log",
+		// the id ends the sort so history cursors are unambiguous
+		Up: createIndex("audit_log", mongo.IndexModel{
+			Keys: bson.D{
+				{Key: "resource", Value: 1},
+				{Key: "resourceId", Value: 1},
+				{Key: "timestamp", Value: -1},
+				{Key: "_id", Value: -1},
+			},
+			Options: options.Index().SetName("resource_resourceId_timestamp_id"),
+		}),
+	},
+	{
+		Version:     13,
+		Description: "index on drivers.userId",
+		// rides look up the driver of the calling account on every driver action
+		Up: createIndex("drivers", mongo.IndexModel{
+			Keys:    bson.D{{Key: "userId", Value: 1}},
+			Options: options.Index().SetName("userId"),
+		}),
+	},
+	{
+		Version:     14,
+		Description: "unique plates among drivers that are not deleted",
+		// a partial filter can not match a missing deletedAt, so the index covers activePlate,
+		// which only drivers that are not deleted carry. Soft deleted drivers free their plate.
+		Up: func(ctx context.Context, db *mongo.Database) error {
+			collection := db.Collection("drivers")
+			_, err := collection.UpdateMany(ctx,
+				bson.M{"deletedAt": nil, "plate": bson.M{"$gt": ""}},
+				mongo.Pipeline{{{Key: "$set", Value: bson.M{"activePlate": "$plate"}}}},
+			)
+			if err != nil {
+				return mongoError(err)
+			}
+			if err := createIndex("drivers", mongo.IndexModel{
+				Keys: bson.D{{Key: "activePlate", Value: 1}},
+				Options: options.Index().
+					SetName("activePlate_unique").
+					SetUnique(true).
+					SetPartialFilterExpression(bson.M{"activePlate": bson.M{"$gt": ""}}),
+			})(ctx, db); err != nil {
+				return err
+			}
+			return dropIndex(ctx, collection, "plate_unique")
+		},
+	},
+}
+
+// RequiredIndexes are the indexes, by collection, the service does not work correctly without.
+// Readiness fails while one is missing.
+var RequiredIndexes = map[string][]string{
+	"drivers":        {"location_2dsphere", "activePlate_unique"},
+	"users":          {"email_unique", "phone_unique"},
+	"revoked_tokens": {"expiresAt_ttl"},
+}
+
+// createIndex is a migration creating indexes on one collection.
+// Creating an index that exists with the same definition is a no op in Mongo, so it is idempotent.
+func createIndex(collection string, indexes ...mongo.IndexModel) func(ctx context.Context, db *mongo.Database) error {
+	return func(ctx context.Context, db *mongo.Database) error {
+		_, err := db.Collection(collection).Indexes().CreateMany(ctx, indexes)
+		return mongoError(err)
+	}
+}
+
+// dropIndex drops an index by name, an index that is gone already is fine so reruns are no ops
+func dropIndex(ctx context.Context, collection *mongo.Collection, name string) error {
+	_, err := collection.Indexes().DropOne(ctx, name)
+	var cmdErr mongo.CommandError
+	if errors.As(err, &cmdErr) && cmdErr.Name == "IndexNotFound" {
+		return nil
+	}
+	return mongoError(err)
+}
+
+// normalizePlates rewrites plates stored before normalization existed.
+// Two plates normalizing to the same value make the unique index migration fail, those must be merged by hand.
+func normalizePlates(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection("drivers")
+
+	cursor, err := collection.Find(ctx, bson.M{"plate": bson.M{"$gt": ""}}, options.Find().SetProjection(bson.M{"plate": 1}))
+	if err != nil {
+		return mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var driver struct {
+			ID    any    `bson:"_id"`
+			Plate string `bson:"plate"`
+		}
+		if err := cursor.Decode(&driver); err != nil {
+			return mongoError(err)
+		}
+
+		normalized := domain.NormalizePlate(driver.Plate)
+		if normalized == driver.Plate {
+			continue
+		}
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": driver.ID}, bson.M{"$set": bson.M{"plate": normalized}}); err != nil {
+			return mongoError(err)
+		}
+	}
+	return mongoError(cursor.Err())
+}
+
+// backfillSearchGrams writes the search grams of drivers stored before search existed.
+// Running it again recomputes them, which is harmless.
+func backfillSearchGrams(ctx context.Context, db *mongo.Database) error {
+	collection := db.Collection("drivers")
+
+	cursor, err := collection.Find(ctx, bson.M{})
+	if err != nil {
+		return mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var driver domain.Driver
+		if err := cursor.Decode(&driver); err != nil {
+			return mongoError(err)
+		}
+		// old drivers may have an ObjectID, the raw id matches either kind
+		id := cursor.Current.Lookup("_id")
+		grams := domain.DriverSearchGrams(&driver)
+		if _, err := collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"searchGrams": grams}}); err != nil {
+			return mongoError(err)
+		}
+	}
+	return mongoError(cursor.Err())
+}
@@ -0,0 +1,138 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.uber.org/zap"
+)
+
+const migrationsCollection = "migrations"
+
+// Migration is one versioned change to the database.
+// Up must be idempotent, it runs again if the process dies before the version is recorded,
+// and two instances starting at the same time may both run it.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(ctx context.Context, db *mongo.Database) error
+}
+
+// MigrationRecord is stored in the migrations collection once a version is applied
+type MigrationRecord struct {
+	Version     int       `bson:"_id" json:"version"`
+	Description string    `bson:"description" json:"description"`
+	AppliedAt   time.Time `bson:"appliedAt" json:"appliedAt"`
+	DurationMs  int64     `bson:"durationMs" json:"durationMs"`
+}
+
+// MigrationStatus is one known migration and whether it ran
+type MigrationStatus struct {
+	Version     int
+	Description string
+	AppliedAt   *time.Time
+}
+
+type Migrator struct {
+	db         *mongo.Database
+	migrations []Migration
+}
+
+// NewMigrator checks that versions are unique and ascending, so the order migrations run in is obvious from the list
+func NewMigrator(db *mongo.Database, migrations []Migration) (*Migrator, error) {
+	for i, m := range migrations {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("migration %q has no version", m.Description)
+		}
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			return nil, fmt.Errorf("migration %d is listed after %d, versions must be ascending", m.Version, migrations[i-1].Version)
+		}
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+func (m *Migrator) applied(ctx context.Context) (map[int]MigrationRecord, error) {
+	cursor, err := m.db.Collection(migrationsCollection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var records []MigrationRecord
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, mongoError(err)
+	}
+
+	applied := make(map[int]MigrationRecord, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// Up runs every migration that is not recorded yet, in version order, and stops at the first failure
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	ran := 0
+	for _, migration := range m.migrations {
+		if _, done := applied[migration.Version]; done {
+			continue
+		}
+
+		zap.L().Info("Running migration", zap.Int("version", migration.Version), zap.String("description", migration.Description))
+		started := time.Now()
+		if err := migration.Up(ctx, m.db); err != nil {
+			return ran, fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Description, err)
+		}
+
+		record := MigrationRecord{
+			Version:     migration.Version,
+			Description: migration.Description,
+			AppliedAt:   time.Now(),
+			DurationMs:  time.Since(started).Milliseconds(),
+		}
+		// another instance may have recorded it meanwhile, the migration is idempotent so that is fine
+		_, err := m.db.Collection(migrationsCollection).UpdateOne(ctx,
+			bson.M{"_id": record.Version},
+			bson.M{"$setOnInsert": record},
+			options.Update().SetUpsert(true),
+		)
+		if err != nil {
+			return ran, mongoError(err)
+		}
+		ran++
+	}
+
+	return ran, nil
+}
+
+// Status lists every known migration with the time it was applied, nil for pending ones
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Version: migration.Version, Description: migration.Description}
+		if record, done := applied[migration.Version]; done {
+			appliedAt := record.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
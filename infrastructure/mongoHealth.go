@@ -0,0 +1,43 @@
+package infrastructure
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// PingMongo checks the primary answers, through the same pool requests use
+func PingMongo(client *mongo.Client) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		return mongoError(client.Ping(ctx, readpref.Primary()))
+	}
+}
+
+// CheckIndexes fails while an index in required, by collection, does not exist yet, e.g. before migrations ran
+func CheckIndexes(db *mongo.Database, required map[string][]string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		var missing []string
+		for collection, names := range required {
+			existing, err := db.Collection(collection).Indexes().ListSpecifications(ctx)
+			if err != nil {
+				return mongoError(err)
+			}
+
+			for _, name := range names {
+				if !slices.ContainsFunc(existing, func(spec *mongo.IndexSpecification) bool { return spec.Name == name }) {
+					missing = append(missing, collection+"."+name)
+				}
+			}
+		}
+
+		if len(missing) > 0 {
+			slices.Sort(missing)
+			return fmt.Errorf("missing indexes: %s", strings.Join(missing, ", "))
+		}
+		return nil
+	}
+}
@@ -0,0 +1,51 @@
+package infrastructure
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// TakeToken refills and takes from a token bucket in one atomic update, so every instance sharing
+// the collection sees the same bucket. Times come from the server clock, instance clocks may differ.
+// A bucket expires once it would be full again, the ttl index of migration 8 drops it.
+func (r *MongoRepository) TakeToken(ctx context.Context, key string, rate float64, burst int) (float64, bool, error) {
+	ctx, done := r.observe(ctx, "TakeToken")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	elapsedSeconds := bson.M{"$divide": bson.A{
+		bson.M{"$subtract": bson.A{"$$NOW", bson.M{"$ifNull": bson.A{"$updatedAt", "$$NOW"}}}},
+		1000,
+	}}
+	refillMs := int64(float64(burst) / rate * 1000)
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"tokens": bson.M{"$min": bson.A{burst, bson.M{"$add": bson.A{
+				bson.M{"$ifNull": bson.A{"$tokens", burst}},
+				bson.M{"$multiply": bson.A{elapsedSeconds, rate}},
+			}}}},
+			"updatedAt": "$$NOW",
+		}}},
+		{{Key: "$set", Value: bson.M{"allowed": bson.M{"$gte": bson.A{"$tokens", 1}}}}},
+		{{Key: "$set", Value: bson.M{
+			"tokens":    bson.M{"$cond": bson.A{"$allowed", bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}},
+			"expiresAt": bson.M{"$add": bson.A{"$$NOW", refillMs}},
+		}}},
+	}
+
+	var bucket struct {
+		Tokens  float64 `bson:"tokens"`
+		Allowed bool    `bson:"allowed"`
+	}
+	err := collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline,
+		options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After),
+	).Decode(&bucket)
+	if err != nil {
+		return 0, false, mongoError(err)
+	}
+	return bucket.Tokens, bucket.Allowed, nil
+}
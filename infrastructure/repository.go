@@ -2,33 +2,107 @@ package infrastructure
 
 import (
 	"context"
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/hekanemre/taxihub/config"
+	"github.com/hekanemre/taxihub/metrics"
+	"github.com/hekanemre/taxihub/tracing"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type MongoRepository struct {
-	DB         *mongo.Database
-	Collection string
+	DB             *mongo.Database
+	Collection     string
+	NearbyDistance int // meters, only used by the drivers repository
 }
 
-func NewMongoRepository(collection string) (*MongoRepository, error) {
-	appConfig := config.Read()
+// NewMongoRepository works on one collection of the shared database, it opens no connection of its own
+func NewMongoRepository(db *mongo.Database, collection string) *MongoRepository {
+	return &MongoRepository{
+		DB:         db,
+		Collection: collection,
+	}
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// observe opens the span of one repository call and records its latency once the returned func runs
+func (r *MongoRepository) observe(ctx context.Context, method string) (context.Context, func()) {
+	started := time.Now()
+	ctx, span := tracing.Start(ctx, r.Collection+"."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNameMongoDB,
+			semconv.DBCollectionName(r.Collection),
+			semconv.DBOperationName(method),
+		),
+	)
+	return ctx, func() {
+		span.End()
+		metrics.ObserveMongo(r.Collection, method, started)
+	}
+}
+
+// NewMongoClient builds the one client the whole process shares. Every repository borrows
+// connections from its pool, so it is created once in main and disconnected on shutdown.
+func NewMongoClient(ctx context.Context, cfg config.MongoConfig) (*mongo.Client, error) {
+	clientOptions := options.Client().
+		ApplyURI(cfg.Host).
+		SetMaxPoolSize(cfg.MaxPoolSize).
+		SetMinPoolSize(cfg.MinPoolSize)
 
-	client, err := mongo.Connect(ctx, options.Client().ApplyURI(appConfig.MongoDB.Host))
-	if err != nil {
-		return nil, err
+	if cfg.MaxConnIdleTime > 0 {
+		clientOptions.SetMaxConnIdleTime(cfg.MaxConnIdleTime)
+	}
+	if cfg.ConnectTimeout > 0 {
+		clientOptions.SetConnectTimeout(cfg.ConnectTimeout)
+	}
+	if cfg.ServerSelectionTimeout > 0 {
+		clientOptions.SetServerSelectionTimeout(cfg.ServerSelectionTimeout)
+	}
+	if cfg.ReadConcern != "" {
+		clientOptions.SetReadConcern(&readconcern.ReadConcern{Level: cfg.ReadConcern})
+	}
+	if cfg.WriteConcern != "" {
+		wc, err := writeConcern(cfg.WriteConcern, cfg.Journal)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.SetWriteConcern(wc)
+	}
+	if cfg.ReadPreference != "" {
+		mode, err := readpref.ModeFromString(cfg.ReadPreference)
+		if err != nil {
+			return nil, err
+		}
+		pref, err := readpref.New(mode)
+		if err != nil {
+			return nil, err
+		}
+		clientOptions.SetReadPreference(pref)
 	}
 
-	db := client.Database(appConfig.MongoDB.DBName)
+	return mongo.Connect(ctx, clientOptions)
+}
 
-	return &MongoRepository{
-		DB:         db,
-		Collection: collection,
-	}, nil
+// writeConcern takes "majority" or the number of nodes that must acknowledge a write
+func writeConcern(w string, journal bool) (*writeconcern.WriteConcern, error) {
+	wc := &writeconcern.WriteConcern{W: w}
+	if w != "majority" {
+		nodes, err := strconv.Atoi(w)
+		if err != nil || nodes < 0 {
+			return nil, fmt.Errorf("invalid write concern %q, use majority or a node count", w)
+		}
+		wc.W = nodes
+	}
+	if journal {
+		wc.Journal = &journal
+	}
+	return wc, nil
 }
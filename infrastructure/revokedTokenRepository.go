@@ -0,0 +1,34 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RevokeToken denylists a token until it expires, the ttl index of migration 5 drops it afterwards
+func (r *MongoRepository) RevokeToken(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ctx, done := r.observe(ctx, "RevokeToken")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": tokenID},
+		bson.M{"$set": bson.M{"expiresAt": expiresAt}},
+		options.Update().SetUpsert(true))
+	return mongoError(err)
+}
+
+func (r *MongoRepository) IsTokenRevoked(ctx context.Context, tokenID string) (bool, error) {
+	ctx, done := r.observe(ctx, "IsTokenRevoked")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	// the TTL monitor only runs once a minute, expired entries may still be around
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": tokenID, "expiresAt": bson.M{"$gt": time.Now()}})
+	if err != nil {
+		return false, mongoError(err)
+	}
+	return count > 0, nil
+}
@@ -0,0 +1,133 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/application/ride"
+	"github.com/hekanemre/taxihub/domain"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (r *MongoRepository) CreateRide(ctx context.Context, ride *domain.Ride) error {
+	ctx, done := r.observe(ctx, "CreateRide")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+	_, err := collection.InsertOne(ctx, ride)
+	return mongoError(err)
+}
+
+func (r *MongoRepository) UpdateRide(ctx context.Context, rd *domain.Ride) error {
+	ctx, done := r.observe(ctx, "UpdateRide")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	expectedVersion := rd.Version
+	rd.Version++
+
+	filter := bson.M{"_id": rd.ID, "version": expectedVersion}
+	result, err := collection.ReplaceOne(ctx, filter, rd)
+	if err != nil {
+		rd.Version = expectedVersion
+		return mongoError(err)
+	}
+
+	if result.MatchedCount == 0 {
+		rd.Version = expectedVersion
+		return ride.ErrRideConflict
+	}
+
+	return nil
+}
+
+func (r *MongoRepository) GetRideByID(ctx context.Context, id string) (*domain.Ride, error) {
+	ctx, done := r.observe(ctx, "GetRideByID")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	var ride domain.Ride
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&ride)
+	if err != nil {
+		return nil, findError(err, domain.ErrRideNotFound)
+	}
+
+	return &ride, nil
+}
+
+func (r *MongoRepository) HasActiveRide(ctx context.Context, driverID string) (bool, error) {
+	ctx, done := r.observe(ctx, "HasActiveRide")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	filter := bson.M{
+		"$or": bson.A{
+			bson.M{
+				"driverId": driverID,
+				"status":   bson.M{"$in": bson.A{domain.RideAccepted, domain.RideAtPickup, domain.RideInProgress}},
+			},
+			bson.M{
+				"offeredDriverId": driverID,
+				"status":          domain.RideRequested,
+			},
+		},
+	}
+
+	count, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return false, mongoError(err)
+	}
+
+	return count > 0, nil
+}
+
+func (r *MongoRepository) GetRidesWithExpiredOffers(ctx context.Context, now time.Time) ([]*domain.Ride, error) {
+	ctx, done := r.observe(ctx, "GetRidesWithExpiredOffers")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	filter := bson.M{
+		"status":          domain.RideRequested,
+		"offeredDriverId": bson.M{"$exists": true, "$ne": ""},
+		"offerExpiresAt":  bson.M{"$lte": now},
+	}
+
+	cursor, err := collection.Find(ctx, filter)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var rides []*domain.Ride
+	for cursor.Next(ctx) {
+		var ride domain.Ride
+		if err := cursor.Decode(&ride); err != nil {
+			return nil, mongoError(err)
+		}
+		rides = append(rides, &ride)
+	}
+
+	return rides, nil
+}
+
+func (r *MongoRepository) GetRidesRequestedSince(ctx context.Context, since time.Time) ([]*domain.Ride, error) {
+	ctx, done := r.observe(ctx, "GetRidesRequestedSince")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	cursor, err := collection.Find(ctx, bson.M{"createdAt": bson.M{"$gte": since}})
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var rides []*domain.Ride
+	for cursor.Next(ctx) {
+		var ride domain.Ride
+		if err := cursor.Decode(&ride); err != nil {
+			return nil, mongoError(err)
+		}
+		rides = append(rides, &ride)
+	}
+
+	return rides, nil
+}
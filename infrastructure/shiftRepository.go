@@ -0,0 +1,77 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *MongoRepository) CreateShift(ctx context.Context, shift *domain.Shift) error {
+	ctx, done := r.observe(ctx, "CreateShift")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+	_, err := collection.InsertOne(ctx, shift)
+	return mongoError(err)
+}
+
+func (r *MongoRepository) UpdateShift(ctx context.Context, shift *domain.Shift) error {
+	ctx, done := r.observe(ctx, "UpdateShift")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": shift.ID}, shift)
+	return mongoError(err)
+}
+
+func (r *MongoRepository) DeleteShift(ctx context.Context, id string) error {
+	ctx, done := r.observe(ctx, "DeleteShift")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+	_, err := collection.DeleteOne(ctx, bson.M{"_id": id})
+	return mongoError(err)
+}
+
+func (r *MongoRepository) GetShiftByID(ctx context.Context, id string) (*domain.Shift, error) {
+	ctx, done := r.observe(ctx, "GetShiftByID")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	var shift domain.Shift
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&shift)
+	if err != nil {
+		return nil, findError(err, domain.ErrShiftNotFound)
+	}
+
+	return &shift, nil
+}
+
+func (r *MongoRepository) GetShiftsByDriver(ctx context.Context, driverID string, from, to time.Time) ([]*domain.Shift, error) {
+	ctx, done := r.observe(ctx, "GetShiftsByDriver")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	filter := bson.M{
+		"driverId":  driverID,
+		"startedAt": bson.M{"$gte": from, "$lt": to},
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "startedAt", Value: -1}})
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var shifts []*domain.Shift
+	for cursor.Next(ctx) {
+		var shift domain.Shift
+		if err := cursor.Decode(&shift); err != nil {
+			return nil, mongoError(err)
+		}
+		shifts = append(shifts, &shift)
+	}
+
+	return shifts, nil
+}
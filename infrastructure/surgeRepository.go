@@ -0,0 +1,131 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *MongoRepository) SaveSurgeCells(ctx context.Context, cells []*domain.SurgeCell) error {
+	ctx, done := r.observe(ctx, "SaveSurgeCells")
+	defer done()
+	if len(cells) == 0 {
+		return nil
+	}
+
+	collection := r.DB.Collection(r.Collection)
+
+	models := make([]mongo.WriteModel, 0, len(cells))
+	for _, cell := range cells {
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": cell.ID}).
+			SetReplacement(cell).
+			SetUpsert(true))
+	}
+
+	_, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return mongoError(err)
+}
+
+func (r *MongoRepository) DeleteSurgeCells(ctx context.Context, ids []string) error {
+	ctx, done := r.observe(ctx, "DeleteSurgeCells")
+	defer done()
+	if len(ids) == 0 {
+		return nil
+	}
+
+	collection := r.DB.Collection(r.Collection)
+	_, err := collection.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	return mongoError(err)
+}
+
+func (r *MongoRepository) GetSurgeCell(ctx context.Context, id string) (*domain.SurgeCell, error) {
+	ctx, done := r.observe(ctx, "GetSurgeCell")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	var cell domain.SurgeCell
+	err := collection.FindOne(ctx, bson.M{"_id": id}).Decode(&cell)
+	if err != nil {
+		return nil, findError(err, domain.ErrSurgeCellNotFound)
+	}
+
+	return &cell, nil
+}
+
+func (r *MongoRepository) GetAllSurgeCells(ctx context.Context) ([]*domain.SurgeCell, error) {
+	ctx, done := r.observe(ctx, "GetAllSurgeCells")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var cells []*domain.SurgeCell
+	for cursor.Next(ctx) {
+		var cell domain.SurgeCell
+		if err := cursor.Decode(&cell); err != nil {
+			return nil, mongoError(err)
+		}
+		cells = append(cells, &cell)
+	}
+
+	return cells, nil
+}
+
+func (r *MongoRepository) AppendSurgeChanges(ctx context.Context, changes []*domain.SurgeChange) error {
+	ctx, done := r.observe(ctx, "AppendSurgeChanges")
+	defer done()
+	if len(changes) == 0 {
+		return nil
+	}
+
+	collection := r.DB.Collection(r.Collection)
+
+	docs := make([]any, 0, len(changes))
+	for _, change := range changes {
+		docs = append(docs, change)
+	}
+
+	_, err := collection.InsertMany(ctx, docs)
+	return mongoError(err)
+}
+
+func (r *MongoRepository) GetSurgeHistory(ctx context.Context, cellID string, from, to time.Time, limit int) ([]*domain.SurgeChange, error) {
+	ctx, done := r.observe(ctx, "GetSurgeHistory")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	filter := bson.M{"changedAt": bson.M{"$gte": from, "$lt": to}}
+	if cellID != "" {
+		filter["cellId"] = cellID
+	}
+
+	findOptions := options.Find().
+		SetSort(bson.D{{Key: "changedAt", Value: -1}}).
+		SetLimit(int64(limit))
+
+	cursor, err := collection.Find(ctx, filter, findOptions)
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var changes []*domain.SurgeChange
+	for cursor.Next(ctx) {
+		var change domain.SurgeChange
+		if err := cursor.Decode(&change); err != nil {
+			return nil, mongoError(err)
+		}
+		changes = append(changes, &change)
+	}
+
+	return changes, nil
+}
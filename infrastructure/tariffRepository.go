@@ -0,0 +1,65 @@
+package infrastructure
+
+import (
+	"context"
+
+	"github.com/hekanemre/taxihub/domain"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (r *MongoRepository) GetTariff(ctx context.Context, taxiType string) (*domain.Tariff, error) {
+	ctx, done := r.observe(ctx, "GetTariff")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	var tariff domain.Tariff
+	err := collection.FindOne(ctx, bson.M{"_id": taxiType}).Decode(&tariff)
+	if err != nil {
+		return nil, findError(err, domain.ErrTariffNotFound)
+	}
+
+	return &tariff, nil
+}
+
+func (r *MongoRepository) GetAllTariffs(ctx context.Context) ([]*domain.Tariff, error) {
+	ctx, done := r.observe(ctx, "GetAllTariffs")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	cursor, err := collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, mongoError(err)
+	}
+	defer cursor.Close(ctx)
+
+	var tariffs []*domain.Tariff
+	for cursor.Next(ctx) {
+		var tariff domain.Tariff
+		if err := cursor.Decode(&tariff); err != nil {
+			return nil, mongoError(err)
+		}
+		tariffs = append(tariffs, &tariff)
+	}
+
+	return tariffs, nil
+}
+
+func (r *MongoRepository) SaveTariff(ctx context.Context, tariff *domain.Tariff) error {
+	ctx, done := r.observe(ctx, "SaveTariff")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+	_, err := collection.ReplaceOne(ctx, bson.M{"_id": tariff.TaxiType}, tariff, options.Replace().SetUpsert(true))
+	return mongoError(err)
+}
+
+func (r *MongoRepository) CreateTariffIfMissing(ctx context.Context, tariff *domain.Tariff) error {
+	ctx, done := r.observe(ctx, "CreateTariffIfMissing")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"_id": tariff.TaxiType},
+		bson.M{"$setOnInsert": tariff},
+		options.Update().SetUpsert(true))
+	return mongoError(err)
+}
@@ -0,0 +1,124 @@
+package infrastructure
+
+import (
+	"context"
+	"time"
+
+	"github.com/hekanemre/taxihub/domain"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func (r *MongoRepository) UserExists(ctx context.Context, email, phone string) (bool, error) {
+	ctx, done := r.observe(ctx, "UserExists")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	count, err := collection.CountDocuments(ctx, bson.M{"$or": bson.A{
+		bson.M{"email": email},
+		bson.M{"phone": phone},
+	}})
+	if err != nil {
+		return false, mongoError(err)
+	}
+	return count > 0, nil
+}
+
+// CreateUser relies on the unique indexes of migration 4, a signup racing another one gets a conflict
+func (r *MongoRepository) CreateUser(ctx context.Context, user *domain.User) error {
+	ctx, done := r.observe(ctx, "CreateUser")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+	_, err := collection.InsertOne(ctx, user)
+	return writeError(err, domain.ErrEmailOrPhoneExists)
+}
+
+func (r *MongoRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	ctx, done := r.observe(ctx, "GetUserByEmail")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	var user domain.User
+	if err := collection.FindOne(ctx, bson.M{"email": email}).Decode(&user); err != nil {
+		return nil, findError(err, domain.ErrUserNotFound)
+	}
+	return &user, nil
+}
+
+func (r *MongoRepository) GetUserByID(ctx context.Context, uid string) (*domain.User, error) {
+	ctx, done := r.observe(ctx, "GetUserByID")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	var user domain.User
+	if err := collection.FindOne(ctx, bson.M{"user_id": uid}).Decode(&user); err != nil {
+		return nil, findError(err, domain.ErrUserNotFound)
+	}
+	return &user, nil
+}
+
+func (r *MongoRepository) SetUserType(ctx context.Context, uid, userType string) error {
+	ctx, done := r.observe(ctx, "SetUserType")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	result, err := collection.UpdateOne(ctx,
+		bson.M{"user_id": uid},
+		bson.M{"$set": bson.M{"user_type": userType, "updated_at": time.Now()}})
+	if err != nil {
+		return mongoError(err)
+	}
+	if result.MatchedCount == 0 {
+		return domain.ErrUserNotFound
+	}
+	return nil
+}
+
+// SetUserTokens stores the current token pair, empty values log the user out
+func (r *MongoRepository) SetUserTokens(ctx context.Context, uid, token, refreshToken string) error {
+	ctx, done := r.observe(ctx, "SetUserTokens")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	_, err := collection.UpdateOne(ctx,
+		bson.M{"user_id": uid},
+		bson.M{"$set": bson.M{"token": token, "refresh_token": refreshToken, "updated_at": time.Now()}})
+	return mongoError(err)
+}
+
+// RotateUserTokens swaps the token pair only while presentedRefreshToken is still the current one,
+// so two concurrent refreshes can not both win. The id of the used refresh token is remembered.
+func (r *MongoRepository) RotateUserTokens(ctx context.Context, uid, presentedRefreshToken, token, refreshToken, usedTokenID string, keep int) (bool, error) {
+	ctx, done := r.observe(ctx, "RotateUserTokens")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	filter := bson.M{"user_id": uid, "refresh_token": presentedRefreshToken}
+	update := bson.M{
+		"$set": bson.M{
+			"token":         token,
+			"refresh_token": refreshToken,
+			"updated_at":    time.Now(),
+		},
+		"$push": bson.M{
+			"rotated_refresh_tokens": bson.M{"$each": bson.A{usedTokenID}, "$slice": -keep},
+		},
+	}
+
+	result, err := collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, mongoError(err)
+	}
+	return result.MatchedCount == 1, nil
+}
+
+func (r *MongoRepository) IsRefreshTokenRotated(ctx context.Context, uid, tokenID string) (bool, error) {
+	ctx, done := r.observe(ctx, "IsRefreshTokenRotated")
+	defer done()
+	collection := r.DB.Collection(r.Collection)
+
+	count, err := collection.CountDocuments(ctx, bson.M{"user_id": uid, "rotated_refresh_tokens": tokenID})
+	if err != nil {
+		return false, mongoError(err)
+	}
+	return count > 0, nil
+}
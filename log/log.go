@@ -1,26 +1,37 @@
 package log
 
 import (
+	"context"
+	"fmt"
 	"os"
 
+	"github.com/hekanemre/taxihub/config"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 var logger *zap.Logger
 
-func Init() {
+func Init(cfg config.LogConfig) error {
+	level, err := zapcore.ParseLevel(cfg.Level)
+	if err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+	if cfg.Format != "json" && cfg.Format != "console" {
+		return fmt.Errorf("invalid log format %q, use json or console", cfg.Format)
+	}
+
 	encoderCfg := zap.NewProductionEncoderConfig()
 	encoderCfg.TimeKey = "timestamp"
 	encoderCfg.EncodeTime = zapcore.ISO8601TimeEncoder
 
 	config := zap.Config{
-		Level:             zap.NewAtomicLevelAt(zap.InfoLevel),
+		Level:             zap.NewAtomicLevelAt(level),
 		Development:       false,
 		DisableCaller:     false,
 		DisableStacktrace: false,
 		Sampling:          nil,
-		Encoding:          "json",
+		Encoding:          cfg.Format,
 		EncoderConfig:     encoderCfg,
 		OutputPaths: []string{
 			"stderr",
@@ -36,4 +47,20 @@ func Init() {
 	logger = zap.Must(config.Build())
 
 	zap.ReplaceGlobals(logger)
+	return nil
+}
+
+type loggerKey struct{}
+
+// NewContext returns a copy of ctx carrying logger, code further down the request logs through it
+func NewContext(ctx context.Context, logger *zap.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the request scoped logger of ctx, or the global one outside a request
+func FromContext(ctx context.Context) *zap.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*zap.Logger); ok {
+		return logger
+	}
+	return zap.L()
 }
@@ -5,17 +5,33 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
+	_ "time/tzdata" // the alpine image has no zoneinfo, pricing needs the local timezone
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/hekanemre/taxihub/application/audit"
+	application "github.com/hekanemre/taxihub/application/driver"
 	"github.com/hekanemre/taxihub/application/healthcheck"
+	"github.com/hekanemre/taxihub/application/pricing"
+	"github.com/hekanemre/taxihub/application/ride"
+	"github.com/hekanemre/taxihub/application/stream"
+	"github.com/hekanemre/taxihub/application/surge"
+	"github.com/hekanemre/taxihub/application/validation"
 	"github.com/hekanemre/taxihub/config"
 	_ "github.com/hekanemre/taxihub/docs"
+	"github.com/hekanemre/taxihub/domain"
 	"github.com/hekanemre/taxihub/gateway/helpers"
+	"github.com/hekanemre/taxihub/gateway/middleware"
 	"github.com/hekanemre/taxihub/gateway/routes"
 	"github.com/hekanemre/taxihub/infrastructure"
 	"github.com/hekanemre/taxihub/log"
+	"github.com/hekanemre/taxihub/metrics"
+	"github.com/hekanemre/taxihub/tracing"
 	fiberswagger "github.com/swaggo/fiber-swagger"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.uber.org/zap"
 )
 
@@ -30,31 +46,23 @@ func handle[Req Request, Res Response](h HandlerInterface[Req, Res]) fiber.Handl
 	return func(c *fiber.Ctx) error {
 		var req Req
 		if err := c.BodyParser(&req); err != nil && !errors.Is(err, fiber.ErrUnprocessableEntity) {
-			zap.L().Error("Failed to parse request body", zap.Error(err))
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request body",
-			})
+			return domain.ErrInvalidRequest.WithMessage("Invalid request body").Wrap(err)
 		}
 
 		if err := c.ParamsParser(&req); err != nil {
-			zap.L().Error("Failed to parse request params", zap.Error(err))
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request params",
-			})
+			return domain.ErrInvalidRequest.WithMessage("Invalid request params").Wrap(err)
 		}
 
 		if err := c.QueryParser(&req); err != nil {
-			zap.L().Error("Failed to parse request query", zap.Error(err))
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request query",
-			})
+			return domain.ErrInvalidRequest.WithMessage("Invalid request query").Wrap(err)
 		}
 
 		if err := c.ReqHeaderParser(&req); err != nil {
-			zap.L().Error("Failed to parse request headers", zap.Error(err))
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": "Invalid request headers",
-			})
+			return domain.ErrInvalidRequest.WithMessage("Invalid request headers").Wrap(err)
+		}
+
+		if err := validation.Struct(&req); err != nil {
+			return err
 		}
 
 		//the reason why we use c.UserContext() is to propagate the context from fiber to our handler
@@ -66,21 +74,164 @@ func handle[Req Request, Res Response](h HandlerInterface[Req, Res]) fiber.Handl
 
 		res, err := h.Handle(ctx, &req)
 		if err != nil {
-			zap.L().Error("Handler error", zap.Error(err))
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Internal server error: " + err.Error(),
-			})
+			return err
 		}
 
 		return c.JSON(res)
 	}
 }
 
+func newPricingSettings(appConfig *config.AppConfig) (pricing.Settings, error) {
+	location, err := time.LoadLocation(appConfig.Pricing.Timezone)
+	if err != nil {
+		return pricing.Settings{}, err
+	}
+
+	return pricing.Settings{
+		Calendar: pricing.Calendar{
+			Location:   location,
+			NightStart: appConfig.Pricing.NightStartHour,
+			NightEnd:   appConfig.Pricing.NightEndHour,
+			Holidays:   appConfig.Pricing.Holidays,
+		},
+		RouteFactor:     appConfig.Pricing.RouteFactor,
+		AverageSpeedKmh: appConfig.Pricing.AverageSpeedKmh,
+	}, nil
+}
+
+func connectMongo(appConfig *config.AppConfig) (*mongo.Client, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return infrastructure.NewMongoClient(ctx, appConfig.MongoDB)
+}
+
+func disconnectMongo(client *mongo.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Disconnect(ctx); err != nil {
+		zap.L().Error("Failed to disconnect from MongoDB", zap.Error(err))
+	}
+}
+
+// flushTraces exports the spans still buffered, the last requests would be lost otherwise
+func flushTraces(shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		zap.L().Error("Failed to flush traces", zap.Error(err))
+	}
+}
+
+func runMigrations(db *mongo.Database, appConfig *config.AppConfig) error {
+	migrator, err := infrastructure.NewMigrator(db, infrastructure.Migrations)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), appConfig.Migrations.Timeout)
+	defer cancel()
+
+	ran, err := migrator.Up(ctx)
+	if err != nil {
+		return err
+	}
+	zap.L().Info("Migrations applied", zap.Int("count", ran))
+	return nil
+}
+
+// migrateCommand serves "taxihub migrate [up|status]" and returns the exit code
+func migrateCommand(db *mongo.Database, appConfig *config.AppConfig, args []string) int {
+	command := "up"
+	if len(args) > 0 {
+		command = args[0]
+	}
+
+	switch command {
+	case "up":
+		if err := runMigrations(db, appConfig); err != nil {
+			zap.L().Error("Migration failed", zap.Error(err))
+			return 1
+		}
+		return 0
+
+	case "status":
+		migrator, err := infrastructure.NewMigrator(db, infrastructure.Migrations)
+		if err != nil {
+			zap.L().Error("Invalid migrations", zap.Error(err))
+			return 1
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), appConfig.Migrations.Timeout)
+		defer cancel()
+
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			zap.L().Error("Failed to read migrations", zap.Error(err))
+			return 1
+		}
+		for _, status := range statuses {
+			applied := "pending"
+			if status.AppliedAt != nil {
+				applied = status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%4d  %-25s  %s\n", status.Version, applied, status.Description)
+		}
+		return 0
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown migrate command %q, use up or status\n", command)
+	return 2
+}
+
+func seedTariffs(tariffRepo pricing.Repository, appConfig *config.AppConfig) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var tariffs []domain.Tariff
+	for _, t := range appConfig.Pricing.Tariffs {
+		tariffs = append(tariffs, domain.Tariff{
+			TaxiType:          t.TaxiType,
+			Currency:          t.Currency,
+			BaseFare:          t.BaseFare,
+			PerKm:             t.PerKm,
+			PerMinute:         t.PerMinute,
+			NightMultiplier:   t.NightMultiplier,
+			HolidayMultiplier: t.HolidayMultiplier,
+			MinimumFare:       t.MinimumFare,
+		})
+	}
+
+	return pricing.SeedTariffs(ctx, tariffRepo, tariffs)
+}
+
 func main() {
 	appConfig := config.Read()
-	log.Init()
+	if err := log.Init(appConfig.Log); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid log config: %v\n", err)
+		os.Exit(1)
+	}
 	defer zap.L().Sync()
 
+	shutdownTracing, err := tracing.Init(context.Background(), appConfig.Tracing)
+	if err != nil {
+		zap.L().Error("Invalid tracing config", zap.Error(err))
+		os.Exit(1)
+	}
+
+	mongoClient, err := connectMongo(appConfig)
+	if err != nil {
+		zap.L().Error("Failed to connect to MongoDB", zap.Error(err))
+		os.Exit(1)
+	}
+	db := mongoClient.Database(appConfig.MongoDB.DBName)
+
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		code := migrateCommand(db, appConfig, os.Args[2:])
+		disconnectMongo(mongoClient)
+		zap.L().Sync()
+		os.Exit(code)
+	}
+
 	zap.L().Info("Starting server...")
 
 	app := fiber.New(fiber.Config{
@@ -89,38 +240,198 @@ func main() {
 		ReadTimeout:  appConfig.ReadTimeout,
 		WriteTimeout: appConfig.WriteTimeout,
 		Concurrency:  256 * 1024,
+		// handlers return typed errors, the status code and body are decided in one place
+		ErrorHandler: middleware.ErrorHandler,
 	})
 
-	app.Use(func(c *fiber.Ctx) error {
-		// log request details
-		zap.L().Info("Request", zap.String("method", c.Method()), zap.String("path", c.Path()))
-		return c.Next()
-	})
+	// first, every log line and metric of a request happens inside its span
+	app.Use(middleware.Tracing())
+	// request id and request logger, every line logged for the request carries the id
+	app.Use(middleware.RequestID())
 
-	userRepo, err := infrastructure.NewMongoRepository("users")
-	if err != nil {
-		zap.L().Error("Failed to connect to MongoDB (users)", zap.Error(err))
-		os.Exit(1)
+	if appConfig.Metrics.Enabled {
+		// before auth, so rejected requests are counted too
+		app.Use(middleware.Metrics())
+	}
+
+	// background workers stop on shutdown, after the last request finished
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	runWorker := func(run func()) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run()
+		}()
 	}
 
-	driverRepo, err := infrastructure.NewMongoRepository("drivers")
+	// readiness runs every check registered here, components add their own below
+	healthRegistry := healthcheck.NewRegistry(appConfig.Health.CheckTimeout)
+
+	var driverRepo application.Repository
+	var shiftRepo application.ShiftRepository
+	var rideRepo ride.Repository
+	var tariffRepo pricing.Repository
+	var surgeRepo surge.Repository
+	var surgeHistoryRepo surge.HistoryRepository
+	var revokedTokens helpers.RevocationList
+	var userRepo helpers.UserStore
+	var auditRepo audit.Repository
+	var rateLimitStore middleware.RateLimitStore = middleware.NewMemoryRateLimitStore()
+	switch appConfig.Repository {
+	case "memory":
+		zap.L().Info("Using in-memory driver, shift, ride, tariff, surge, revoked token, audit and user repository")
+		memoryRepo := infrastructure.NewMemoryRepository(appConfig.NearbyDistance)
+		driverRepo = memoryRepo
+		shiftRepo = memoryRepo
+		rideRepo = memoryRepo
+		tariffRepo = memoryRepo
+		surgeRepo = memoryRepo
+		surgeHistoryRepo = memoryRepo
+		revokedTokens = memoryRepo
+		auditRepo = memoryRepo
+		userRepo = memoryRepo
+	default:
+		if appConfig.Migrations.RunOnStartup {
+			if err := runMigrations(db, appConfig); err != nil {
+				zap.L().Error("Migration failed", zap.Error(err))
+				os.Exit(1)
+			}
+		}
+
+		// memory mode never talks to Mongo, an unreachable server must not fail readiness there
+		healthRegistry.Register("mongo", healthcheck.CheckerFunc(infrastructure.PingMongo(mongoClient)))
+		healthRegistry.Register("mongo_indexes", healthcheck.CheckerFunc(infrastructure.CheckIndexes(db, infrastructure.RequiredIndexes)))
+
+		mongoDriverRepo := infrastructure.NewMongoRepository(db, "drivers")
+		mongoDriverRepo.NearbyDistance = appConfig.NearbyDistance
+		driverRepo = mongoDriverRepo
+		shiftRepo = infrastructure.NewMongoRepository(db, "shifts")
+		rideRepo = infrastructure.NewMongoRepository(db, "rides")
+		tariffRepo = infrastructure.NewMongoRepository(db, "tariffs")
+		surgeRepo = infrastructure.NewMongoRepository(db, "surge_cells")
+		surgeHistoryRepo = infrastructure.NewMongoRepository(db, "surge_history")
+		revokedTokens = infrastructure.NewMongoRepository(db, "revoked_tokens")
+		auditRepo = infrastructure.NewMongoRepository(db, "audit_log")
+		userRepo = infrastructure.NewMongoRepository(db, "users")
+		if appConfig.RateLimit.Store == "mongo" {
+			rateLimitStore = infrastructure.NewMongoRepository(db, "rate_limits")
+		}
+	}
+	jwtKeys, err := helpers.NewKeySet(appConfig.JWT.SigningKid, appConfig.JWT.LegacyKid, appConfig.JWT.Keys)
 	if err != nil {
-		zap.L().Error("Failed to connect to MongoDB (drivers)", zap.Error(err))
+		zap.L().Error("Invalid jwt config", zap.Error(err))
 		os.Exit(1)
 	}
-	tokenHelper := helpers.NewTokenHelper(userRepo)
+	tokenHelper := helpers.NewTokenHelper(userRepo, revokedTokens, jwtKeys)
+
+	// every status and location write is pushed to live subscribers through the hub
+	hub := stream.NewHub(appConfig.Stream.BufferSize, appConfig.Stream.MaxDroppedEvents)
+	// live streams never finish on their own, they are closed first on shutdown so draining does not wait for them
+	streamCtx, stopStreams := context.WithCancel(workerCtx)
+	runWorker(func() { hub.Run(streamCtx) })
+	driverRepo = application.NewPublishingRepository(driverRepo, hub)
+	// every create, update and delete of a driver leaves an audit record
+	auditRecorder := audit.NewRecorder(auditRepo)
+	driverRepo = application.NewAuditingRepository(driverRepo, auditRecorder)
+
+	locationBatcher := application.NewLocationBatcher(driverRepo, appConfig.LocationIngest.FlushInterval, appConfig.LocationIngest.MaxBatchSize)
+	runWorker(func() { locationBatcher.Run(workerCtx) })
+	healthRegistry.Register("location_batcher", locationBatcher)
 
 	app.Get("/swagger/*", fiberswagger.WrapHandler)
 	healthCheckHandler := healthcheck.NewHealthCheckHandler()
 	app.Get("/health", handle[healthcheck.HealthCheckRequest, healthcheck.HealthCheckResponse](healthCheckHandler))
+	routes.HealthRoutes(app, healthRegistry)
+	if appConfig.Metrics.Enabled {
+		metrics.RegisterOnlineDrivers(driverRepo, appConfig.Metrics.ScrapeTimeout)
+		app.Get(appConfig.Metrics.Path, metrics.Handler())
+	}
+
+	var rateLimiter *middleware.RateLimiter
+	if appConfig.RateLimit.Enabled {
+		limiter, err := middleware.NewRateLimiter(rateLimitStore, appConfig.RateLimit.Groups)
+		if err != nil {
+			zap.L().Error("Invalid rate limit config", zap.Error(err))
+			os.Exit(1)
+		}
+		rateLimiter = limiter
+	}
+
+	routes.AuthRoutes(app, tokenHelper, auditRecorder, rateLimiter)
+	routes.StreamRoutes(app, hub, appConfig.Stream.HeartbeatInterval, appConfig.WriteTimeout, tokenHelper)
+
+	// every route registered from here on needs a token and shares the api rate limit, per IP and per uid.
+	// Route groups must not authenticate again, that would parse and revocation check each token twice.
+	app.Use(middleware.Authenticate(tokenHelper))
+	app.Use(middleware.RateLimit(rateLimiter, "api"))
+	routes.DriverRoutes(app, driverRepo, shiftRepo, auditRepo, locationBatcher)
+
+	dispatcher := ride.NewDispatcher(rideRepo, driverRepo, appConfig.Dispatch.OfferTimeout)
+	routes.RideRoutes(app, rideRepo, driverRepo, dispatcher)
+
+	surgeEngine := surge.NewEngine(surgeRepo, surgeHistoryRepo, driverRepo, rideRepo, surge.Settings{
+		Precision:     appConfig.Surge.Precision,
+		Window:        appConfig.Surge.Window,
+		Threshold:     appConfig.Surge.Threshold,
+		Sensitivity:   appConfig.Surge.Sensitivity,
+		MaxMultiplier: appConfig.Surge.MaxMultiplier,
+		Step:          appConfig.Surge.Step,
+	})
+	healthRegistry.Register("surge_engine", surgeEngine)
+	routes.SurgeRoutes(app, surgeEngine, surgeRepo, surgeHistoryRepo)
+
+	pricingSettings, err := newPricingSettings(appConfig)
+	if err != nil {
+		zap.L().Error("Invalid pricing config", zap.Error(err))
+		os.Exit(1)
+	}
+	if err := seedTariffs(tariffRepo, appConfig); err != nil {
+		zap.L().Error("Failed to seed tariffs", zap.Error(err))
+		os.Exit(1)
+	}
+	routes.PricingRoutes(app, tariffRepo, surgeEngine, pricingSettings)
 
-	routes.AuthRoutes(app, tokenHelper)
-	routes.DriverRoutes(app, driverRepo, tokenHelper)
+	// hands timed out offers to the next driver for as long as the server runs
+	runWorker(func() { dispatcher.Run(workerCtx, appConfig.Dispatch.SweepInterval) })
+	runWorker(func() { surgeEngine.Run(workerCtx, appConfig.Surge.RecomputeInterval) })
+	if appConfig.DriverPurge.Interval > 0 {
+		driverPurger := application.NewDriverPurger(driverRepo, appConfig.DriverPurge.Retention)
+		// purged drivers are audited with the job as the actor
+		purgeCtx := audit.WithActor(workerCtx, domain.AuditActor{System: "driver-purge"})
+		runWorker(func() { driverPurger.Run(purgeCtx, appConfig.DriverPurge.Interval) })
+	}
 
+	signalCtx, stopSignals := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stopSignals()
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(fmt.Sprintf(":%s", appConfig.Port))
+	}()
 	zap.L().Info("Server started on port", zap.String("port", appConfig.Port))
 
-	if err := app.Listen(fmt.Sprintf(":%s", appConfig.Port)); err != nil {
+	exitCode := 0
+	select {
+	case err := <-listenErr:
 		zap.L().Error("Failed to start server", zap.Error(err))
-		os.Exit(1)
+		exitCode = 1
+	case <-signalCtx.Done():
+		zap.L().Info("Shutting down, draining in-flight requests", zap.Duration("timeout", appConfig.ShutdownTimeout))
 	}
+
+	// stop accepting connections and wait for running requests, then let workers flush
+	// what they hold before the database goes away
+	stopStreams()
+	if err := app.ShutdownWithTimeout(appConfig.ShutdownTimeout); err != nil {
+		zap.L().Error("Failed to drain requests", zap.Error(err))
+	}
+	stopWorkers()
+	workers.Wait()
+	disconnectMongo(mongoClient)
+	flushTraces(shutdownTracing)
+
+	zap.L().Info("Server stopped")
+	zap.L().Sync()
+	os.Exit(exitCode)
 }
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/hekanemre/taxihub/domain"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.uber.org/zap"
+)
+
+const namespace = "taxihub"
+
+// Registry holds every TaxiHub metric, it is what /metrics exposes
+var Registry = prometheus.NewRegistry()
+
+var (
+	HTTPRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route pattern and status code.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method, route pattern and status code.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestsInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "http_requests_in_flight",
+		Help:      "HTTP requests currently being served.",
+	})
+
+	MongoOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongo_operation_duration_seconds",
+		Help:      "Latency of MongoRepository methods by collection and method.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"collection", "method"})
+
+	NearbySearchResults = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "nearby_search_results",
+		Help:      "Drivers returned by a nearby search, by taxi type.",
+		Buckets:   []float64{0, 1, 2, 5, 10, 20, 50, 100},
+	}, []string{"taxi_type"})
+
+	AuthAttempts = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "auth_attempts_total",
+		Help:      "Signup, login and token refresh attempts by outcome.",
+	}, []string{"action", "outcome"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		HTTPRequests,
+		HTTPRequestDuration,
+		HTTPRequestsInFlight,
+		MongoOperationDuration,
+		NearbySearchResults,
+		AuthAttempts,
+	)
+}
+
+// Handler serves the registry in the Prometheus text format
+func Handler() fiber.Handler {
+	return adaptor.HTTPHandler(promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+}
+
+// ObserveMongo records how long a repository method took, call it deferred with the start time
+func ObserveMongo(collection, method string, started time.Time) {
+	MongoOperationDuration.WithLabelValues(collection, method).Observe(time.Since(started).Seconds())
+}
+
+// AvailableDriverSource is the part of the driver repository the online drivers gauge reads
+type AvailableDriverSource interface {
+	GetAvailableDrivers(ctx context.Context) ([]*domain.Driver, error)
+}
+
+// onlineDrivers counts available drivers per taxi type when Prometheus scrapes,
+// so the gauge is never stale and costs nothing between scrapes
+type onlineDrivers struct {
+	drivers AvailableDriverSource
+	timeout time.Duration
+	desc    *prometheus.Desc
+}
+
+// RegisterOnlineDrivers exposes taxihub_drivers_online per taxi type
+func RegisterOnlineDrivers(drivers AvailableDriverSource, timeout time.Duration) {
+	Registry.MustRegister(&onlineDrivers{
+		drivers: drivers,
+		timeout: timeout,
+		desc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "drivers_online"),
+			"Drivers currently available for rides, by taxi type.",
+			[]string{"taxi_type"}, nil,
+		),
+	})
+}
+
+func (o *onlineDrivers) Describe(ch chan<- *prometheus.Desc) {
+	ch <- o.desc
+}
+
+func (o *onlineDrivers) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), o.timeout)
+	defer cancel()
+
+	drivers, err := o.drivers.GetAvailableDrivers(ctx)
+	if err != nil {
+		// an invalid metric fails the scrape, better than reporting zero online drivers
+		zap.L().Error("Failed to count online drivers", zap.Error(err))
+		ch <- prometheus.NewInvalidMetric(o.desc, err)
+		return
+	}
+
+	perType := make(map[string]int)
+	for _, taxiType := range domain.TaxiTypes {
+		perType[taxiType] = 0
+	}
+	for _, driver := range drivers {
+		perType[driver.TaxiType]++
+	}
+
+	for taxiType, count := range perType {
+		ch <- prometheus.MustNewConstMetric(o.desc, prometheus.GaugeValue, float64(count), taxiType)
+	}
+}